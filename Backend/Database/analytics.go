@@ -0,0 +1,150 @@
+// Backend/Database/analytics.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Acumulados de analíticas para el panel de administrador.
+Se recalculan periódicamente para que las consultas no recorran el historial.
+*/
+
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// RefreshAnalytics recalcula las tablas de analíticas. Los acumulados por
+// día y por mes se recalculan solo desde el refresco anterior (con un día
+// de margen); la primera vez se recorre todo el historial. El historial que
+// importan los usuarios no cuenta.
+func RefreshAnalytics() error {
+	var last sql.NullTime
+	if err := db.QueryRow("SELECT MAX(refreshed_at) FROM analytics_refreshes").Scan(&last); err != nil {
+		return fmt.Errorf("error leyendo último refresco de analíticas: %v", err)
+	}
+	start := time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local)
+	if last.Valid {
+		start = last.Time.In(time.Local).AddDate(0, 0, -1)
+	}
+	startDay := start.Format("2006-01-02")
+	startMonth := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.Local).Format("2006-01-02")
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	steps := []struct {
+		name  string
+		query string
+		args  []interface{}
+	}{
+		{"diarios", "DELETE FROM analytics_daily WHERE day >= ?", []interface{}{startDay}},
+		{"diarios", `
+			INSERT INTO analytics_daily (day, active_users, plays, listen_seconds, uploads, upload_bytes, signups)
+			SELECT day, SUM(active_users), SUM(plays), SUM(listen_seconds), SUM(uploads), SUM(upload_bytes), SUM(signups)
+			FROM (
+				SELECT day, COUNT(DISTINCT user_id) AS active_users, SUM(plays) AS plays, SUM(seconds) AS listen_seconds,
+				       0 AS uploads, 0 AS upload_bytes, 0 AS signups
+				FROM listening_daily WHERE day >= ? AND NOT imported GROUP BY day
+				UNION ALL
+				SELECT DATE(created_at), 0, 0, 0, COUNT(*), SUM(file_size), 0
+				FROM songs WHERE created_at >= ? GROUP BY DATE(created_at)
+				UNION ALL
+				SELECT DATE(created_at), 0, 0, 0, 0, 0, COUNT(*)
+				FROM users WHERE created_at >= ? GROUP BY DATE(created_at)
+			) t
+			GROUP BY day`, []interface{}{startDay, startDay, startDay}},
+		// Los usuarios activos del mes se cuentan aparte: no son la suma de los diarios
+		{"mensuales", "DELETE FROM analytics_monthly WHERE month >= ?", []interface{}{startMonth}},
+		{"mensuales", `
+			INSERT INTO analytics_monthly (month, active_users, plays, listen_seconds, uploads, upload_bytes, signups)
+			SELECT month, SUM(active_users), SUM(plays), SUM(listen_seconds), SUM(uploads), SUM(upload_bytes), SUM(signups)
+			FROM (
+				SELECT DATE_FORMAT(day, '%Y-%m-01') AS month, COUNT(DISTINCT user_id) AS active_users,
+				       SUM(plays) AS plays, SUM(seconds) AS listen_seconds, 0 AS uploads, 0 AS upload_bytes, 0 AS signups
+				FROM listening_daily WHERE day >= ? AND NOT imported GROUP BY month
+				UNION ALL
+				SELECT DATE_FORMAT(created_at, '%Y-%m-01'), 0, 0, 0, COUNT(*), SUM(file_size), 0
+				FROM songs WHERE created_at >= ? GROUP BY DATE_FORMAT(created_at, '%Y-%m-01')
+				UNION ALL
+				SELECT DATE_FORMAT(created_at, '%Y-%m-01'), 0, 0, 0, 0, 0, COUNT(*)
+				FROM users WHERE created_at >= ? GROUP BY DATE_FORMAT(created_at, '%Y-%m-01')
+			) t
+			GROUP BY month`, []interface{}{startMonth, startMonth, startMonth}},
+		// Solo se actualizan las canciones que se escucharon desde el último refresco
+		{"por canción", `
+			INSERT INTO analytics_song_plays (song_id, plays, last_played)
+			SELECT song_id, SUM(plays), MAX(day)
+			FROM listening_daily
+			WHERE NOT imported AND song_id IN (SELECT DISTINCT song_id FROM listening_daily WHERE day >= ? AND NOT imported)
+			GROUP BY song_id
+			ON DUPLICATE KEY UPDATE plays = VALUES(plays), last_played = VALUES(last_played)`, []interface{}{startDay}},
+		{"del embudo", "DELETE FROM analytics_funnel", nil},
+		{"del embudo", `
+			INSERT INTO analytics_funnel (month, signups, activated, engaged, retained)
+			SELECT DATE_FORMAT(u.created_at, '%Y-%m-01') AS month,
+			       COUNT(*),
+			       SUM(EXISTS(SELECT 1 FROM listening_daily d WHERE d.user_id = u.id AND NOT d.imported)),
+			       SUM(EXISTS(SELECT 1 FROM user_favorites f WHERE f.user_id = u.id)
+			           OR EXISTS(SELECT 1 FROM playlists p WHERE p.user_id = u.id)),
+			       SUM(EXISTS(SELECT 1 FROM listening_daily d
+			                  WHERE d.user_id = u.id AND NOT d.imported
+			                    AND d.day >= DATE(u.created_at) + INTERVAL 7 DAY))
+			FROM users u
+			WHERE u.role = 'user'
+			GROUP BY month`, nil},
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step.query, step.args...); err != nil {
+			return fmt.Errorf("error actualizando acumulados %s: %v", step.name, err)
+		}
+	}
+
+	if err := refreshGenreStorage(tx); err != nil {
+		return fmt.Errorf("error actualizando almacenamiento por género: %v", err)
+	}
+
+	if _, err := tx.Exec("REPLACE INTO analytics_refreshes (id, refreshed_at) VALUES (1, NOW())"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// refreshGenreStorage recalcula el espacio ocupado por género
+func refreshGenreStorage(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT id, genre, file_size FROM songs")
+	if err != nil {
+		return err
+	}
+	var songs []models.Song
+	for rows.Next() {
+		var s models.Song
+		if err := rows.Scan(&s.ID, &s.Genre, &s.FileSize); err != nil {
+			rows.Close()
+			return err
+		}
+		songs = append(songs, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM analytics_genre_storage"); err != nil {
+		return err
+	}
+	for _, g := range models.StorageByGenre(songs) {
+		if _, err := tx.Exec(
+			"INSERT INTO analytics_genre_storage (genre, songs, bytes) VALUES (?, ?, ?)",
+			g.Genre, g.Songs, g.Bytes,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
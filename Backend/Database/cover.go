@@ -0,0 +1,153 @@
+// Backend/Database/cover.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Almacenamiento de las portadas por hash de contenido: la misma
+imagen (por ejemplo todas las pistas de un álbum) se guarda una sola vez.
+*/
+
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// coversDir guarda una carpeta por hash con un JPEG por tamaño estándar
+const coversDir = "./covers"
+
+// coverGrace es cuánto se espera antes de borrar una portada sin canciones:
+// al subir, los archivos se escriben antes de insertar la canción
+const coverGrace = time.Hour
+
+// CoverPath devuelve la ruta del JPEG de la portada en el tamaño indicado
+func CoverPath(hash string, size int) string {
+	return filepath.Join(coversDir, hash, fmt.Sprintf("%d.jpg", size))
+}
+
+// SaveCover valida la imagen, la guarda en todos los tamaños estándar y
+// devuelve su hash. Si ya existía no se vuelve a procesar.
+func SaveCover(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	complete := true
+	for _, size := range models.CoverSizes {
+		if _, err := os.Stat(CoverPath(hash, size)); err != nil {
+			complete = false
+			break
+		}
+	}
+	if complete {
+		// Renovar la fecha para que la recolección no la borre antes de
+		// que se inserte la canción que la usa
+		now := time.Now()
+		os.Chtimes(filepath.Join(coversDir, hash), now, now)
+		return hash, nil
+	}
+
+	img, err := models.DecodeCover(data)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Join(coversDir, hash), 0755); err != nil {
+		return "", err
+	}
+	for _, size := range models.CoverSizes {
+		if err := writeJPEG(CoverPath(hash, size), models.ResizeCover(img, size)); err != nil {
+			return "", err
+		}
+	}
+	return hash, nil
+}
+
+func writeJPEG(path string, img *image.RGBA) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 85}); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// CollectCoverGarbage borra las portadas que ya no usa ninguna canción
+// (tampoco las de la papelera)
+func CollectCoverGarbage() (int, error) {
+	entries, err := os.ReadDir(coversDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < coverGrace {
+			continue
+		}
+		var inUse bool
+		if err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM songs WHERE cover_hash = ?)", entry.Name()).Scan(&inUse); err != nil {
+			return removed, err
+		}
+		if inUse {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(coversDir, entry.Name())); err != nil {
+			log.Printf("Error borrando portada %s: %v", entry.Name(), err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// extractSongCover guarda la portada incluida en el archivo de la canción y
+// anota su origen; si no tiene, queda como CoverNone para no revisarla de nuevo
+func extractSongCover(songID int, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	data, err := models.ExtractCover(f)
+	f.Close()
+
+	hash, source := "", models.CoverNone
+	if err == nil {
+		if hash, err = SaveCover(data); err != nil {
+			log.Printf("Portada incluida inválida en la canción %d: %v", songID, err)
+			hash = ""
+		} else {
+			source = models.CoverEmbedded
+		}
+	} else if err != models.ErrNoCover {
+		return err
+	}
+
+	_, err = db.Exec(
+		"UPDATE songs SET cover_hash = NULLIF(?, ''), cover_source = ? WHERE id = ? AND cover_source IS NULL",
+		hash, source, songID,
+	)
+	return err
+}
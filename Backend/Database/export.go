@@ -0,0 +1,316 @@
+// Backend/Database/export.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Consultas para exportar el catálogo, los favoritos, las
+playlists y el historial de escucha, e importación de esos archivos
+buscando cada entrada entre las canciones existentes.
+*/
+
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// SongLocation es la URL del audio de una canción. Pasa por
+// /api/songs/{id}/stream, que pide sesión y aplica la calidad del plan;
+// baseURL es "" para una ruta relativa al servidor.
+func SongLocation(baseURL string, songID int) string {
+	return fmt.Sprintf("%s/api/songs/%d/stream", baseURL, songID)
+}
+
+// CatalogEntries devuelve las canciones que no están en la papelera
+func CatalogEntries() ([]models.CatalogEntry, error) {
+	rows, err := db.Query(`
+		SELECT s.id, s.title, s.artist, s.album, s.genre, COALESCE(w.duration_ms, 0) DIV 1000,
+		       s.file_size, s.file_path, COALESCE(s.checksum, ''), s.created_at
+		FROM songs s
+		LEFT JOIN song_waveforms w ON w.song_id = s.id
+		WHERE s.deleted_at IS NULL
+		ORDER BY s.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.CatalogEntry{}
+	for rows.Next() {
+		var e models.CatalogEntry
+		if err := rows.Scan(&e.ID, &e.Title, &e.Artist, &e.Album, &e.Genre, &e.DurationSeconds,
+			&e.FileSize, &e.FilePath, &e.Checksum, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// FavoriteTracks devuelve los favoritos del usuario, los más recientes primero
+func FavoriteTracks(userID int, baseURL string) ([]models.PlaylistTrack, error) {
+	return queryTracks(baseURL, `
+		SELECT s.id, s.title, s.artist, s.album, COALESCE(w.duration_ms, 0) DIV 1000,
+		       COALESCE(s.checksum, '')
+		FROM user_favorites f
+		JOIN songs s ON s.id = f.song_id AND s.deleted_at IS NULL
+		LEFT JOIN song_waveforms w ON w.song_id = s.id
+		WHERE f.user_id = ?
+		ORDER BY f.liked_at DESC, s.id`, userID)
+}
+
+// PlaylistTracks devuelve el nombre y las canciones de una playlist en su
+// orden. No revisa permisos: eso lo hace quien la pide.
+func PlaylistTracks(playlistID int, baseURL string) (string, []models.PlaylistTrack, error) {
+	var name string
+	if err := db.QueryRow("SELECT name FROM playlists WHERE id = ?", playlistID).Scan(&name); err != nil {
+		return "", nil, err
+	}
+	tracks, err := queryTracks(baseURL, `
+		SELECT s.id, s.title, s.artist, s.album, COALESCE(w.duration_ms, 0) DIV 1000,
+		       COALESCE(s.checksum, '')
+		FROM playlist_songs ps
+		JOIN songs s ON s.id = ps.song_id AND s.deleted_at IS NULL
+		LEFT JOIN song_waveforms w ON w.song_id = s.id
+		WHERE ps.playlist_id = ?
+		ORDER BY ps.position, ps.id`, playlistID)
+	return name, tracks, err
+}
+
+func queryTracks(baseURL, query string, args ...interface{}) ([]models.PlaylistTrack, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tracks := []models.PlaylistTrack{}
+	for rows.Next() {
+		var t models.PlaylistTrack
+		if err := rows.Scan(&t.SongID, &t.Title, &t.Artist, &t.Album, &t.DurationSeconds, &t.Checksum); err != nil {
+			return nil, err
+		}
+		t.Location = SongLocation(baseURL, t.SongID)
+		tracks = append(tracks, t)
+	}
+	return tracks, rows.Err()
+}
+
+// ExportHistory recorre las reproducciones del usuario en orden cronológico
+// y llama a fn con cada una, para escribirlas sin cargarlas todas en memoria.
+// Un from o to en cero no limita el periodo.
+func ExportHistory(userID int, from, to time.Time, fn func(models.HistoryEntry) error) error {
+	query := `
+		SELECT p.song_id, s.title, s.artist, s.album, s.file_path, COALESCE(s.checksum, ''),
+		       p.played_at, p.duration, p.status
+		FROM playbacks p
+		JOIN songs s ON s.id = p.song_id
+		WHERE p.user_id = ?`
+	args := []interface{}{userID}
+	if !from.IsZero() {
+		query += " AND p.played_at >= ?"
+		args = append(args, from)
+	}
+	if !to.IsZero() {
+		query += " AND p.played_at < ?"
+		args = append(args, to)
+	}
+	rows, err := db.Query(query+" ORDER BY p.played_at, p.id", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.HistoryEntry
+		if err := rows.Scan(&e.SongID, &e.Title, &e.Artist, &e.Album, &e.FilePath, &e.Checksum,
+			&e.PlayedAt, &e.Seconds, &e.Status); err != nil {
+			return err
+		}
+		e.FilePath = filepath.Base(e.FilePath)
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// UserIDByEmail busca un usuario por su correo (para la línea de comandos)
+func UserIDByEmail(email string) (int, error) {
+	var id int
+	err := db.QueryRow("SELECT id FROM users WHERE email = ?", email).Scan(&id)
+	return id, err
+}
+
+// importMatch es una entrada importada junto con su canción
+type importMatch struct {
+	item   models.ImportItem
+	songID int
+}
+
+// matchImport busca la canción de cada entrada. Las que no se encuentran
+// quedan en el reporte.
+func matchImport(format string, list *models.ImportList) (*models.ImportReport, []importMatch, error) {
+	report := models.NewImportReport(format, list)
+	if report.Total == 0 {
+		return nil, nil, models.ErrEmptyImport
+	}
+
+	rows, err := db.Query(`
+		SELECT id, title, artist, file_path, COALESCE(checksum, '')
+		FROM songs WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, nil, err
+	}
+	var songs []models.MatchCandidate
+	for rows.Next() {
+		var s models.MatchCandidate
+		if err := rows.Scan(&s.ID, &s.Title, &s.Artist, &s.FilePath, &s.Checksum); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		songs = append(songs, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	matcher := models.NewSongMatcher(songs)
+	var matches []importMatch
+	for _, item := range list.Items {
+		songID, by := matcher.Match(item)
+		if songID == 0 {
+			report.Unmatched = append(report.Unmatched, models.ImportUnmatched{
+				Line: item.Line, Text: item.Text, Reason: "no se encontró la canción",
+			})
+			continue
+		}
+		report.Matched++
+		report.MatchedBy[by]++
+		matches = append(matches, importMatch{item: item, songID: songID})
+	}
+	return report, matches, nil
+}
+
+// ImportCatalog compara un catálogo (por ejemplo el de otro servidor) con
+// el actual y reporta qué canciones existen y cuáles faltan. No modifica
+// nada: las canciones se agregan subiendo sus archivos.
+func ImportCatalog(format string, list *models.ImportList) (*models.ImportReport, error) {
+	report, _, err := matchImport(format, list)
+	return report, err
+}
+
+// ImportFavorites marca como favoritas las canciones encontradas. Las que
+// ya eran favoritas no cuentan como importadas.
+func ImportFavorites(userID int, format string, list *models.ImportList) (*models.ImportReport, error) {
+	report, matches, err := matchImport(format, list)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, m := range matches {
+		result, err := tx.Exec("INSERT IGNORE INTO user_favorites (user_id, song_id) VALUES (?, ?)", userID, m.songID)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			report.Imported++
+		}
+	}
+	return report, tx.Commit()
+}
+
+// ImportPlaylist crea una playlist privada con las canciones encontradas, en
+// el orden del archivo. name vacío usa el nombre del archivo. Si ninguna
+// canción se encuentra no se crea. La cuota la revisa quien la llama.
+func ImportPlaylist(userID int, name, format string, list *models.ImportList) (*models.ImportReport, error) {
+	if name == "" {
+		name = list.Name
+	}
+	name, err := models.ValidatePlaylistName(name)
+	if err != nil {
+		name = models.DefaultImportPlaylistName
+	}
+
+	report, matches, err := matchImport(format, list)
+	if err != nil || len(matches) == 0 {
+		return report, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO playlists (user_id, name) VALUES (?, ?)", userID, name)
+	if err != nil {
+		return nil, err
+	}
+	playlistID, _ := result.LastInsertId()
+	for i, m := range matches {
+		if _, err := tx.Exec(
+			"INSERT INTO playlist_songs (playlist_id, song_id, position, added_by) VALUES (?, ?, ?, ?)",
+			playlistID, m.songID, int64(i+1)*models.PlaylistPositionGap, userID,
+		); err != nil {
+			return nil, err
+		}
+		report.Imported++
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	report.PlaylistID = int(playlistID)
+	return report, nil
+}
+
+// ImportHistory agrega las reproducciones al historial y a los acumulados
+// de estadísticas. Una reproducción de la misma canción en el mismo
+// segundo ya existe, así que importar dos veces el mismo archivo no duplica.
+func ImportHistory(userID int, list *models.ImportList) (*models.ImportReport, error) {
+	report, matches, err := matchImport(models.FormatJSONL, list)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, m := range matches {
+		playedAt := m.item.PlayedAt.Local().Truncate(time.Second)
+		result, err := tx.Exec(`
+			INSERT INTO playbacks (user_id, song_id, played_at, status, duration, source)
+			SELECT ?, ?, ?, ?, ?, ?
+			FROM DUAL
+			WHERE NOT EXISTS (
+				SELECT 1 FROM playbacks WHERE user_id = ? AND song_id = ? AND played_at = ?
+			)`,
+			userID, m.songID, playedAt, m.item.Status, m.item.Seconds, models.SourceImport,
+			userID, m.songID, playedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			continue
+		}
+		if err := RecordListening(tx, userID, m.songID, playedAt, 1, m.item.Seconds, true); err != nil {
+			return nil, err
+		}
+		report.Imported++
+	}
+	return report, tx.Commit()
+}
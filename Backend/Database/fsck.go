@@ -0,0 +1,193 @@
+// Backend/Database/fsck.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Revisión de integridad entre la tabla songs y los archivos
+de ./uploads/songs (archivos huérfanos, faltantes, tamaños y checksums).
+*/
+
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// Reconcile compara las canciones con los archivos del disco y repara lo
+// que indiquen las opciones. Las canciones en la papelera también se
+// revisan porque su archivo sigue en uso hasta el borrado definitivo.
+func Reconcile(opts models.FsckOptions) (*models.FsckReport, error) {
+	report := &models.FsckReport{StartedAt: time.Now(), Issues: []models.FsckIssue{}}
+
+	type songFile struct {
+		id       int
+		path     string
+		size     int64
+		checksum string
+	}
+	rows, err := db.Query("SELECT id, file_path, file_size, COALESCE(checksum, '') FROM songs ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo canciones: %v", err)
+	}
+	var songs []songFile
+	for rows.Next() {
+		var s songFile
+		if err := rows.Scan(&s.id, &s.path, &s.size, &s.checksum); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		songs = append(songs, s)
+	}
+	rows.Close()
+
+	// Archivos en uso o pendientes de borrar por CollectFileGarbage
+	known := make(map[string]bool)
+	for _, s := range songs {
+		known[filepath.Clean(SongFilePath(s.path))] = true
+	}
+	pending, err := db.Query("SELECT file_path FROM file_deletions")
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo archivos pendientes de borrar: %v", err)
+	}
+	for pending.Next() {
+		var path string
+		if err := pending.Scan(&path); err != nil {
+			pending.Close()
+			return nil, err
+		}
+		known[filepath.Clean(SongFilePath(path))] = true
+	}
+	pending.Close()
+
+	for _, s := range songs {
+		report.SongsChecked++
+		path := SongFilePath(s.path)
+
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			issue := report.Add(models.FsckIssue{Kind: models.FsckMissingFile, SongID: s.id, FilePath: s.path})
+			if opts.TrashMissing {
+				repair(issue, trashSong(s.id))
+			}
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if info.Size() != s.size {
+			issue := report.Add(models.FsckIssue{
+				Kind: models.FsckSizeMismatch, SongID: s.id, FilePath: s.path,
+				Expected: strconv.FormatInt(s.size, 10), Actual: strconv.FormatInt(info.Size(), 10),
+			})
+			if opts.FixSizes {
+				_, err := db.Exec("UPDATE songs SET file_size = ? WHERE id = ?", info.Size(), s.id)
+				repair(issue, err)
+			}
+		}
+
+		if !opts.VerifyChecksums && !(opts.FillChecksums && s.checksum == "") {
+			continue
+		}
+		sum, err := FileChecksum(path)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case s.checksum == "":
+			issue := report.Add(models.FsckIssue{Kind: models.FsckMissingChecksum, SongID: s.id, FilePath: s.path, Actual: sum})
+			if opts.FillChecksums {
+				_, err := db.Exec("UPDATE songs SET checksum = ? WHERE id = ? AND checksum IS NULL", sum, s.id)
+				repair(issue, err)
+			}
+		case s.checksum != sum:
+			issue := report.Add(models.FsckIssue{
+				Kind: models.FsckChecksumError, SongID: s.id, FilePath: s.path, Expected: s.checksum, Actual: sum,
+			})
+			if opts.TrashCorrupt {
+				repair(issue, trashSong(s.id))
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(songsDir)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo directorio songs: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		report.FilesScanned++
+		path := filepath.Join(songsDir, entry.Name())
+		if known[filepath.Clean(path)] {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		// Puede ser una subida que todavía no registró la canción
+		if time.Since(info.ModTime()) < opts.OrphanGrace {
+			continue
+		}
+		issue := report.Add(models.FsckIssue{
+			Kind: models.FsckOrphanFile, FilePath: path, Actual: strconv.FormatInt(info.Size(), 10),
+		})
+		if opts.DeleteOrphans {
+			repair(issue, os.Remove(path))
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+func repair(issue *models.FsckIssue, err error) {
+	if err != nil {
+		issue.Error = err.Error()
+		return
+	}
+	issue.Repaired = true
+}
+
+// trashSong envía la canción a la papelera igual que DELETE /api/songs/{id}.
+// El índice de sugerencias en memoria se actualiza al reiniciar el servidor.
+func trashSong(songID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE songs SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL", songID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM song_search_terms WHERE song_id = ?", songID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FileChecksum calcula el SHA-256 del archivo en hexadecimal
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
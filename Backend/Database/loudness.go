@@ -0,0 +1,124 @@
+// Backend/Database/loudness.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Análisis de sonoridad de las canciones (EBU R128) y cálculo
+de la ganancia de pista y de álbum para normalizar el volumen.
+*/
+
+package database
+
+import (
+	"encoding/json"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// saveLoudness guarda la sonoridad medida de la canción y recalcula la
+// ganancia de su álbum
+func saveLoudness(songID int, artist, album string, analysis *models.LoudnessAnalysis) error {
+	loudness := models.NewSongLoudness(analysis)
+	histogram, err := json.Marshal(analysis.Histogram)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		INSERT INTO song_loudness
+			(song_id, integrated_lufs, true_peak_dbtp, track_gain_db, track_peak,
+			 album_gain_db, album_peak, histogram, duration_seconds, error, analyzed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULL, NOW())
+		ON DUPLICATE KEY UPDATE
+			integrated_lufs = VALUES(integrated_lufs),
+			true_peak_dbtp = VALUES(true_peak_dbtp),
+			track_gain_db = VALUES(track_gain_db),
+			track_peak = VALUES(track_peak),
+			album_gain_db = VALUES(album_gain_db),
+			album_peak = VALUES(album_peak),
+			histogram = VALUES(histogram),
+			duration_seconds = VALUES(duration_seconds),
+			error = NULL,
+			analyzed_at = VALUES(analyzed_at)`,
+		songID, loudness.IntegratedLUFS, loudness.TruePeakDBTP, loudness.TrackGainDB, loudness.TrackPeak,
+		loudness.AlbumGainDB, loudness.AlbumPeak, histogram, analysis.Duration,
+	)
+	if err != nil {
+		return err
+	}
+	return updateAlbumGain(artist, album)
+}
+
+// saveLoudnessError anota que el archivo no se pudo analizar, para no
+// reintentarlo en cada inicio
+func saveLoudnessError(songID int, cause error) error {
+	_, err := db.Exec(`
+		INSERT INTO song_loudness (song_id, error, analyzed_at) VALUES (?, ?, NOW())
+		ON DUPLICATE KEY UPDATE
+			integrated_lufs = NULL, true_peak_dbtp = NULL, track_gain_db = NULL, track_peak = NULL,
+			album_gain_db = NULL, album_peak = NULL, histogram = NULL, duration_seconds = NULL,
+			error = VALUES(error), analyzed_at = VALUES(analyzed_at)`,
+		songID, truncate(cause.Error(), 255),
+	)
+	return err
+}
+
+// updateAlbumGain mide el álbum completo sumando los histogramas de sus
+// pistas y guarda la misma ganancia en todas. Las canciones sin álbum usan
+// la ganancia de pista.
+func updateAlbumGain(artist, album string) error {
+	if album == "" {
+		return nil
+	}
+
+	const albumFilter = `
+		FROM song_loudness l
+		JOIN songs s ON s.id = l.song_id
+		WHERE s.artist = ? AND s.album = ? AND s.deleted_at IS NULL AND l.error IS NULL`
+
+	rows, err := db.Query("SELECT l.histogram, l.track_peak"+albumFilter, artist, album)
+	if err != nil {
+		return err
+	}
+	total := models.LoudnessHistogram{}
+	var peak float64
+	for rows.Next() {
+		var raw []byte
+		var trackPeak float64
+		if err := rows.Scan(&raw, &trackPeak); err != nil {
+			rows.Close()
+			return err
+		}
+		var histogram models.LoudnessHistogram
+		if err := json.Unmarshal(raw, &histogram); err != nil {
+			rows.Close()
+			return err
+		}
+		total.Merge(histogram)
+		if trackPeak > peak {
+			peak = trackPeak
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var gain float64
+	if lufs, ok := total.Integrated(); ok {
+		gain = models.GainFor(lufs)
+	}
+	_, err = db.Exec(`
+		UPDATE song_loudness l
+		JOIN songs s ON s.id = l.song_id
+		SET l.album_gain_db = ?, l.album_peak = ?
+		WHERE s.artist = ? AND s.album = ? AND s.deleted_at IS NULL AND l.error IS NULL`,
+		gain, peak, artist, album,
+	)
+	return err
+}
+
+func truncate(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max])
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+// Backend/Database/lyrics.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Letras de las canciones (texto plano o LRC) guardadas en
+song_lyrics. El origen queda en songs.lyrics_source, igual que la portada.
+*/
+
+package database
+
+import (
+	"database/sql"
+	"os"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// SaveLyrics guarda (o reemplaza) la letra de una canción. El texto ya debe
+// venir normalizado con models.NormalizeLyrics. updatedBy es 0 si la letra
+// no la cargó un usuario. No actualiza el índice de búsqueda.
+func SaveLyrics(songID int, text, format, language, source string, updatedBy int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveLyrics(tx, songID, text, format, language, updatedBy); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE songs SET lyrics_source = ? WHERE id = ?", source, songID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveLyrics(tx *sql.Tx, songID int, text, format, language string, updatedBy int) error {
+	_, err := tx.Exec(`
+		INSERT INTO song_lyrics (song_id, format, body, language, updated_by)
+		VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, 0))
+		ON DUPLICATE KEY UPDATE
+			format = VALUES(format),
+			body = VALUES(body),
+			language = VALUES(language),
+			updated_by = VALUES(updated_by)`,
+		songID, format, text, language, updatedBy,
+	)
+	return err
+}
+
+// DeleteLyrics borra la letra. Queda como LyricsNone para que no se vuelva a
+// extraer del archivo.
+func DeleteLyrics(songID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM song_lyrics WHERE song_id = ?", songID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.Exec("UPDATE songs SET lyrics_source = ? WHERE id = ?", models.LyricsNone, songID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// extractSongLyrics guarda la letra incluida en las etiquetas del archivo.
+// Solo se aplica si mientras tanto nadie cargó una a mano.
+func extractSongLyrics(songID int, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	lyrics, err := models.ExtractLyrics(f)
+	f.Close()
+
+	source := models.LyricsEmbedded
+	if err == models.ErrNoLyrics {
+		source = models.LyricsNone
+	} else if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"UPDATE songs SET lyrics_source = ? WHERE id = ? AND lyrics_source IS NULL", source, songID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 || lyrics == nil {
+		return tx.Commit()
+	}
+	if err := saveLyrics(tx, songID, lyrics.Text, lyrics.Format, lyrics.Language, 0); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return reindexSong(songID)
+}
+
+// reindexSong vuelve a indexar la canción con sus datos actuales
+func reindexSong(songID int) error {
+	var title, artist, genre string
+	err := db.QueryRow("SELECT title, artist, genre FROM songs WHERE id = ?", songID).Scan(&title, &artist, &genre)
+	if err != nil {
+		return err
+	}
+	return IndexSong(songID, title, artist, genre)
+}
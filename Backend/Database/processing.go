@@ -0,0 +1,158 @@
+// Backend/Database/processing.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Procesamiento del audio de las canciones subidas o encontradas
+al iniciar: se decodifica una sola vez para medir la sonoridad, generar la
+forma de onda y codificar las versiones de menor calidad, y se extraen la
+portada y la letra incluidas en las etiquetas.
+*/
+
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// processMu hace que las canciones se procesen de a una: decodificar es
+// costoso y así dos pistas del mismo álbum no recalculan su ganancia a la vez
+var processMu sync.Mutex
+
+// ProcessSong hace los pasos que le faltan a la canción: extraer la portada
+// y la letra (si no se subieron) y decodificar el audio para la sonoridad, la forma de
+// onda y las versiones de menor calidad. Si el archivo no se puede
+// decodificar el error queda anotado en song_loudness para no reintentarlo
+// en cada inicio.
+func ProcessSong(songID int) error {
+	processMu.Lock()
+	defer processMu.Unlock()
+
+	var filePath, artist, album string
+	var needsCover, needsLyrics, needsAnalysis, needsRenditions bool
+	err := db.QueryRow(`
+		SELECT s.file_path, s.artist, s.album, s.cover_source IS NULL, s.lyrics_source IS NULL,
+		       l.song_id IS NULL OR (w.song_id IS NULL AND l.error IS NULL),
+		       l.error IS NULL AND NOT EXISTS(SELECT 1 FROM song_renditions r WHERE r.song_id = s.id)
+		FROM songs s
+		LEFT JOIN song_loudness l ON l.song_id = s.id
+		LEFT JOIN song_waveforms w ON w.song_id = s.id
+		WHERE s.id = ? AND s.deleted_at IS NULL`, songID,
+	).Scan(&filePath, &artist, &album, &needsCover, &needsLyrics, &needsAnalysis, &needsRenditions)
+	if err != nil {
+		return err
+	}
+	path := SongFilePath(filePath)
+
+	if needsCover {
+		if err := extractSongCover(songID, path); err != nil {
+			log.Printf("Error extrayendo portada de la canción %d: %v", songID, err)
+		}
+	}
+	if needsLyrics {
+		if err := extractSongLyrics(songID, path); err != nil {
+			log.Printf("Error extrayendo letra de la canción %d: %v", songID, err)
+		}
+	}
+	if !needsAnalysis && !needsRenditions {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	stream, err := models.OpenAudio(f, path)
+	var meter *models.LoudnessMeter
+	var waveform *models.WaveformBuilder
+	var renditions []*rendition
+	if err == nil {
+		var sinks []models.AudioSink
+		if needsAnalysis {
+			meter = models.NewLoudnessMeter(stream.Channels(), stream.SampleRate())
+			waveform = models.NewWaveformBuilder(stream.Channels(), stream.SampleRate())
+			sinks = append(sinks, meter, waveform)
+		}
+		if needsRenditions {
+			if renditions, err = startRenditions(songID, stream, info.Size()); err != nil {
+				return fmt.Errorf("error preparando versiones: %v", err)
+			}
+			sinks = append(sinks, renditionSinks(renditions)...)
+		}
+		err = models.DecodeAll(stream, sinks...)
+	}
+	if err != nil {
+		discardRenditions(renditions)
+		if needsAnalysis {
+			if dbErr := saveLoudnessError(songID, err); dbErr != nil {
+				log.Printf("Error guardando fallo de análisis de la canción %d: %v", songID, dbErr)
+			}
+		}
+		return fmt.Errorf("error decodificando %s: %v", filePath, err)
+	}
+
+	if needsAnalysis {
+		if err := saveLoudness(songID, artist, album, meter.Result()); err != nil {
+			return fmt.Errorf("error guardando sonoridad: %v", err)
+		}
+		if err := saveWaveform(songID, waveform.Waveform()); err != nil {
+			return fmt.Errorf("error guardando forma de onda: %v", err)
+		}
+	}
+	if needsRenditions {
+		if err := saveRenditions(songID, renditions, info.Size()); err != nil {
+			return fmt.Errorf("error guardando versiones: %v", err)
+		}
+	}
+	return nil
+}
+
+// ProcessPendingSongs procesa las canciones a las que les falta la
+// portada, la letra, la sonoridad, la forma de onda o las versiones (por ejemplo las
+// registradas antes de existir el procesamiento)
+func ProcessPendingSongs() error {
+	rows, err := db.Query(`
+		SELECT s.id FROM songs s
+		LEFT JOIN song_loudness l ON l.song_id = s.id
+		LEFT JOIN song_waveforms w ON w.song_id = s.id
+		WHERE s.deleted_at IS NULL
+		  AND (s.cover_source IS NULL OR s.lyrics_source IS NULL OR l.song_id IS NULL OR (w.song_id IS NULL AND l.error IS NULL)
+		       OR (l.error IS NULL AND NOT EXISTS(SELECT 1 FROM song_renditions r WHERE r.song_id = s.id)))`)
+	if err != nil {
+		return fmt.Errorf("error buscando canciones sin procesar: %v", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	processed := 0
+	for _, id := range ids {
+		if err := ProcessSong(id); err != nil && err != sql.ErrNoRows {
+			log.Printf("Error procesando la canción %d: %v", id, err)
+			continue
+		}
+		processed++
+	}
+	if processed > 0 {
+		log.Printf("Canciones procesadas (portada, letra, sonoridad, forma de onda y versiones): %d", processed)
+	}
+	return nil
+}
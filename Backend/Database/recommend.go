@@ -0,0 +1,67 @@
+// Backend/Database/recommend.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Lectura de los datos que alimentan el motor de recomendaciones
+(catálogo, historial de reproducción y favoritos).
+*/
+
+package database
+
+import (
+	"fmt"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// LoadRecommendationData lee el catálogo, las reproducciones de los últimos
+// 180 días y los favoritos para recalcular las recomendaciones
+func LoadRecommendationData() (models.RecommendationData, error) {
+	var data models.RecommendationData
+
+	rows, err := db.Query("SELECT id, title, artist, genre FROM songs WHERE deleted_at IS NULL")
+	if err != nil {
+		return data, fmt.Errorf("error leyendo canciones: %v", err)
+	}
+	for rows.Next() {
+		var s models.Song
+		if err := rows.Scan(&s.ID, &s.Title, &s.Artist, &s.Genre); err != nil {
+			rows.Close()
+			return data, err
+		}
+		data.Songs = append(data.Songs, s)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT user_id, song_id, COUNT(*)
+		FROM playbacks
+		WHERE played_at >= NOW() - INTERVAL 180 DAY AND source <> ?
+		GROUP BY user_id, song_id`, models.SourceImport)
+	if err != nil {
+		return data, fmt.Errorf("error leyendo reproducciones: %v", err)
+	}
+	for rows.Next() {
+		var p models.UserSongCount
+		if err := rows.Scan(&p.UserID, &p.SongID, &p.Count); err != nil {
+			rows.Close()
+			return data, err
+		}
+		data.Plays = append(data.Plays, p)
+	}
+	rows.Close()
+
+	rows, err = db.Query("SELECT user_id, song_id FROM user_favorites")
+	if err != nil {
+		return data, fmt.Errorf("error leyendo favoritos: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var f models.UserSong
+		if err := rows.Scan(&f.UserID, &f.SongID); err != nil {
+			return data, err
+		}
+		data.Favorites = append(data.Favorites, f)
+	}
+	return data, rows.Err()
+}
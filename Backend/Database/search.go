@@ -0,0 +1,111 @@
+// Backend/Database/search.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Índice de búsqueda de canciones guardado en la tabla
+song_search_terms (una fila por palabra normalizada de cada canción,
+incluidas las de la letra).
+*/
+
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// IndexSong regenera los términos de búsqueda de una canción (los datos
+// recibidos y la letra guardada)
+func IndexSong(songID int, title, artist, genre string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM song_search_terms WHERE song_id = ?", songID); err != nil {
+		return err
+	}
+
+	// La letra también se indexa, con el menor peso
+	var lyrics, format string
+	err = tx.QueryRow("SELECT body, format FROM song_lyrics WHERE song_id = ?", songID).Scan(&lyrics, &format)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	for _, t := range models.SearchTerms(title, artist, genre, models.LyricsText(lyrics, format)) {
+		_, err := tx.Exec(
+			"INSERT INTO song_search_terms (song_id, term, field, weight) VALUES (?, ?, ?, ?)",
+			songID, t.Term, t.Field, t.Weight,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// IndexMissingSongs indexa las canciones que todavía no tienen términos de
+// búsqueda (por ejemplo las registradas antes de existir el índice)
+func IndexMissingSongs() error {
+	rows, err := db.Query(`
+		SELECT id, title, artist, genre FROM songs
+		WHERE deleted_at IS NULL AND id NOT IN (SELECT DISTINCT song_id FROM song_search_terms)`)
+	if err != nil {
+		return fmt.Errorf("error buscando canciones sin indexar: %v", err)
+	}
+
+	type pending struct {
+		id                   int
+		title, artist, genre string
+	}
+	var songs []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.title, &p.artist, &p.genre); err != nil {
+			rows.Close()
+			return err
+		}
+		songs = append(songs, p)
+	}
+	rows.Close()
+
+	for _, p := range songs {
+		if err := IndexSong(p.id, p.title, p.artist, p.genre); err != nil {
+			log.Printf("Error indexando canción %d: %v", p.id, err)
+		}
+	}
+	if len(songs) > 0 {
+		log.Printf("Canciones indexadas para búsqueda: %d", len(songs))
+	}
+	return nil
+}
+
+// LoadFuzzyIndex carga en el índice en memoria todas las canciones del catálogo
+func LoadFuzzyIndex(index *models.FuzzyIndex) error {
+	rows, err := db.Query("SELECT id, title, artist FROM songs WHERE deleted_at IS NULL")
+	if err != nil {
+		return fmt.Errorf("error cargando canciones para sugerencias: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var title, artist string
+		if err := rows.Scan(&id, &title, &artist); err != nil {
+			return err
+		}
+		index.Upsert(id, title, artist)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	log.Printf("Índice de sugerencias cargado: %d canciones", index.Len())
+	return nil
+}
@@ -0,0 +1,91 @@
+// Backend/Database/stats.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Acumulados de escucha por usuario (por día y canción, y por
+día y hora) que alimentan las estadísticas sin recorrer playbacks.
+*/
+
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// execer es lo común de *sql.DB y *sql.Tx para escribir
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// RecordListening actualiza los acumulados diarios y por hora del usuario.
+// Se llama al iniciar (una reproducción más) y al cerrar una reproducción
+// (segundos escuchados), y al importar un historial con imported, que
+// cuenta en las estadísticas del usuario pero no en las analíticas.
+func RecordListening(q execer, userID, songID int, at time.Time, plays, seconds int, imported bool) error {
+	day := at.Format("2006-01-02")
+	if _, err := q.Exec(`
+		INSERT INTO listening_daily (user_id, day, song_id, imported, plays, seconds) VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE plays = plays + VALUES(plays), seconds = seconds + VALUES(seconds)`,
+		userID, day, songID, imported, plays, seconds,
+	); err != nil {
+		return err
+	}
+	if seconds == 0 {
+		return nil
+	}
+	_, err := q.Exec(`
+		INSERT INTO listening_hours (user_id, day, hour, seconds) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE seconds = seconds + VALUES(seconds)`,
+		userID, day, at.Hour(), seconds,
+	)
+	return err
+}
+
+// BackfillListeningStats genera los acumulados a partir del historial
+// existente. Solo se ejecuta si todavía no hay acumulados, por ejemplo la
+// primera vez que se inicia el servidor con las estadísticas.
+func BackfillListeningStats() error {
+	var pending bool
+	err := db.QueryRow(`
+		SELECT NOT EXISTS(SELECT 1 FROM listening_daily) AND EXISTS(SELECT 1 FROM playbacks)`,
+	).Scan(&pending)
+	if err != nil {
+		return fmt.Errorf("error verificando acumulados de escucha: %v", err)
+	}
+	if !pending {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO listening_daily (user_id, day, song_id, imported, plays, seconds)
+		SELECT user_id, DATE(played_at), song_id, source = ?, COUNT(*), SUM(duration)
+		FROM playbacks
+		GROUP BY user_id, DATE(played_at), song_id, source = ?`, models.SourceImport, models.SourceImport); err != nil {
+		return fmt.Errorf("error generando acumulados diarios: %v", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO listening_hours (user_id, day, hour, seconds)
+		SELECT user_id, DATE(played_at), HOUR(played_at), SUM(duration)
+		FROM playbacks
+		WHERE duration > 0
+		GROUP BY user_id, DATE(played_at), HOUR(played_at)`); err != nil {
+		return fmt.Errorf("error generando acumulados por hora: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Println("Acumulados de escucha generados a partir del historial")
+	return nil
+}
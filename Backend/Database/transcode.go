@@ -0,0 +1,157 @@
+// Backend/Database/transcode.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Versiones de menor tasa de bits de cada canción (renditions)
+para adaptar la reproducción al ancho de banda. Se guardan junto al
+archivo original y se codifican durante el mismo decodificado que la
+sonoridad y la forma de onda.
+*/
+
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// renditionsDir es donde se guardan las versiones. Solo se entregan con
+// /api/songs/{id}/stream, que valida la calidad que permite el plan.
+const renditionsDir = "./renditions"
+
+// RenditionPath devuelve la ruta de la versión de una canción en la calidad indicada
+func RenditionPath(songID int, quality string) string {
+	return filepath.Join(renditionsDir, fmt.Sprintf("%d_%s.mp3", songID, quality))
+}
+
+// rendition es una versión que se está codificando. serveOriginal indica
+// que el original no es más pesado que esta calidad y puede servirse en su
+// lugar; una versión que no se pudo codificar no lo tiene.
+type rendition struct {
+	quality       string
+	bitrate       int
+	path          string
+	file          *os.File
+	encoder       *models.MP3Encoder
+	serveOriginal bool
+}
+
+// startRenditions prepara un codificador por cada calidad que sea más
+// liviana que el original. Las demás quedan sin archivo y se sirve el
+// original en su lugar.
+func startRenditions(songID int, stream models.AudioStream, sourceSize int64) ([]*rendition, error) {
+	if err := os.MkdirAll(renditionsDir, 0755); err != nil {
+		return nil, err
+	}
+
+	// Tasa promedio del original en kbps (0 si no se conoce la duración)
+	var sourceKbps int64
+	if length, ok := stream.(models.AudioLength); ok && length.Frames() > 0 {
+		sourceKbps = sourceSize * 8 * int64(stream.SampleRate()) / length.Frames() / 1000
+	}
+
+	var list []*rendition
+	for _, quality := range models.Qualities {
+		r := &rendition{quality: quality, bitrate: models.QualityBitrates[quality]}
+		list = append(list, r)
+		if sourceKbps > 0 && int64(r.bitrate) >= sourceKbps {
+			r.serveOriginal = true
+			continue
+		}
+
+		r.path = RenditionPath(songID, quality)
+		file, err := os.Create(r.path + ".tmp")
+		if err != nil {
+			discardRenditions(list)
+			return nil, err
+		}
+		r.file = file
+		r.encoder, err = models.NewMP3Encoder(file, stream.Channels(), stream.SampleRate(), r.bitrate)
+		if err != nil {
+			// Formato que MP3 no admite (por ejemplo un WAV de 6 canales)
+			log.Printf("Sin versión %s para la canción %d: %v", quality, songID, err)
+			file.Close()
+			os.Remove(r.path + ".tmp")
+			r.file, r.path = nil, ""
+		}
+	}
+	return list, nil
+}
+
+// renditionSinks devuelve los codificadores para pasarlos a DecodeAll
+func renditionSinks(list []*rendition) []models.AudioSink {
+	var sinks []models.AudioSink
+	for _, r := range list {
+		if r.encoder != nil {
+			sinks = append(sinks, r.encoder)
+		}
+	}
+	return sinks
+}
+
+// discardRenditions borra los archivos temporales si el decodificado falló
+func discardRenditions(list []*rendition) {
+	for _, r := range list {
+		if r.file != nil {
+			r.file.Close()
+			os.Remove(r.path + ".tmp")
+		}
+	}
+}
+
+// saveRenditions termina los archivos y registra las versiones. Una
+// versión que resultó más pesada que el original (tasa variable) se descarta
+// y se sirve el original; una que falló queda sin archivo ni original.
+func saveRenditions(songID int, list []*rendition, sourceSize int64) error {
+	sizes := make(map[string]int64)
+	for _, r := range list {
+		if r.file == nil {
+			continue
+		}
+		err := r.encoder.Close()
+		if closeErr := r.file.Close(); err == nil {
+			err = closeErr
+		}
+		var size int64
+		if info, statErr := os.Stat(r.path + ".tmp"); err == nil && statErr == nil {
+			size = info.Size()
+		}
+		if err != nil || size == 0 || size >= sourceSize {
+			os.Remove(r.path + ".tmp")
+			r.path = ""
+			r.serveOriginal = err == nil && size > 0
+			if err != nil {
+				log.Printf("Error codificando versión %s de la canción %d: %v", r.quality, songID, err)
+			}
+			continue
+		}
+		if err := os.Rename(r.path+".tmp", r.path); err != nil {
+			os.Remove(r.path + ".tmp")
+			return err
+		}
+		sizes[r.quality] = size
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM song_renditions WHERE song_id = ?", songID); err != nil {
+		return err
+	}
+	for _, r := range list {
+		if _, err := tx.Exec(
+			"INSERT INTO song_renditions (song_id, quality, bitrate, file_path, size, serve_original) VALUES (?, ?, ?, NULLIF(?, ''), ?, ?)",
+			songID, r.quality, r.bitrate, r.path, sizes[r.quality], r.serveOriginal,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
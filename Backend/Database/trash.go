@@ -0,0 +1,170 @@
+// Backend/Database/trash.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Borrado definitivo de las canciones de la papelera y
+recolección de los archivos que quedaron sin uso.
+*/
+
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// songsDir es donde se guardan los archivos de música. Las canciones
+// registradas al iniciar guardan solo el nombre del archivo.
+const songsDir = "./uploads/songs"
+
+// ErrNotInTrash indica que la canción no existe o no está en la papelera
+var ErrNotInTrash = errors.New("la canción no está en la papelera")
+
+// PurgeSong borra definitivamente una canción que está en la papelera junto
+// con todo lo que la referencia. El archivo no se toca aquí: queda anotado en
+// file_deletions y lo borra CollectFileGarbage después del commit.
+func PurgeSong(songID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var filePath string
+	err = tx.QueryRow(
+		"SELECT file_path FROM songs WHERE id = ? AND deleted_at IS NOT NULL FOR UPDATE", songID,
+	).Scan(&filePath)
+	if err == sql.ErrNoRows {
+		return ErrNotInTrash
+	} else if err != nil {
+		return err
+	}
+
+	// La forma de onda y las versiones se borran junto con el audio
+	var extraPaths []string
+	rows, err := tx.Query(`
+		SELECT file_path FROM song_waveforms WHERE song_id = ?
+		UNION ALL
+		SELECT file_path FROM song_renditions WHERE song_id = ? AND file_path IS NOT NULL`,
+		songID, songID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return err
+		}
+		extraPaths = append(extraPaths, path)
+	}
+	rows.Close()
+
+	// Tablas cuya clave foránea no borra en cascada
+	for _, table := range []string{"library_songs", "playlist_songs", "playback_positions", "playbacks"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE song_id = ?", songID); err != nil {
+			return fmt.Errorf("error borrando %s de la canción %d: %v", table, songID, err)
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM songs WHERE id = ?", songID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO file_deletions (file_path) VALUES (?)", filePath); err != nil {
+		return err
+	}
+	for _, path := range extraPaths {
+		if _, err := tx.Exec("INSERT INTO file_deletions (file_path) VALUES (?)", path); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// PurgeExpiredTrash borra definitivamente las canciones que llevan en la
+// papelera más tiempo que retention
+func PurgeExpiredTrash(retention time.Duration) (int, error) {
+	rows, err := db.Query(
+		"SELECT id FROM songs WHERE deleted_at IS NOT NULL AND deleted_at < ?",
+		time.Now().Add(-retention),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error buscando canciones vencidas en la papelera: %v", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	purged := 0
+	for _, id := range ids {
+		if err := PurgeSong(id); err != nil && err != ErrNotInTrash {
+			log.Printf("Error borrando definitivamente la canción %d: %v", id, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// CollectFileGarbage borra del disco los archivos de las canciones ya
+// eliminadas. Si otra canción sigue usando el mismo archivo no se borra.
+func CollectFileGarbage() (int, error) {
+	rows, err := db.Query("SELECT id, file_path FROM file_deletions ORDER BY id")
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo archivos pendientes de borrar: %v", err)
+	}
+	type pending struct {
+		id   int
+		path string
+	}
+	var files []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.path); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		files = append(files, p)
+	}
+	rows.Close()
+
+	removed := 0
+	for _, f := range files {
+		var inUse bool
+		if err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM songs WHERE file_path = ?)", f.path).Scan(&inUse); err != nil {
+			return removed, err
+		}
+		if !inUse {
+			if err := os.Remove(SongFilePath(f.path)); err != nil && !os.IsNotExist(err) {
+				// Se deja anotado para intentarlo en la próxima pasada
+				log.Printf("Error borrando archivo %s: %v", f.path, err)
+				continue
+			}
+			removed++
+		}
+		if _, err := db.Exec("DELETE FROM file_deletions WHERE id = ?", f.id); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// SongFilePath devuelve la ruta en disco del archivo de una canción
+func SongFilePath(filePath string) string {
+	if filepath.Base(filePath) == filePath {
+		return filepath.Join(songsDir, filePath)
+	}
+	return filePath
+}
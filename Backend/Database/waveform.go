@@ -0,0 +1,60 @@
+// Backend/Database/waveform.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripción: Archivos de forma de onda de las canciones (se guardan aparte
+del audio, en formato binario).
+*/
+
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// waveformsDir es donde se guardan los archivos .wvf de forma de onda
+const waveformsDir = "./waveforms"
+
+// WaveformPath devuelve la ruta del archivo de forma de onda de una canción
+func WaveformPath(songID int) string {
+	return filepath.Join(waveformsDir, fmt.Sprintf("%d.wvf", songID))
+}
+
+// saveWaveform escribe el archivo (primero en uno temporal, para que nunca se
+// sirva a medio escribir) y registra la fecha en que se generó
+func saveWaveform(songID int, w *models.Waveform) error {
+	data, err := w.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(waveformsDir, 0755); err != nil {
+		return err
+	}
+
+	path := WaveformPath(songID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO song_waveforms (song_id, file_path, duration_ms, size, generated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			file_path = VALUES(file_path),
+			duration_ms = VALUES(duration_ms),
+			size = VALUES(size),
+			generated_at = VALUES(generated_at)`,
+		songID, path, w.DurationMs, len(data), time.Now(),
+	)
+	return err
+}
@@ -0,0 +1,292 @@
+// Backend/Handlers/analytics.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase analytics, con sus respectivas
+funciones para el panel de analiticas del administrador
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type AnalyticsHandler struct {
+	db *sql.DB
+}
+
+// AnalyticsOverview es el resumen general del panel
+type AnalyticsOverview struct {
+	TotalUsers      int        `json:"total_users"`
+	TotalSongs      int        `json:"total_songs"`
+	StorageBytes    int64      `json:"storage_bytes"`
+	DailyActive     int        `json:"daily_active_users"`
+	MonthlyActive   int        `json:"monthly_active_users"`
+	PlaysToday      int        `json:"plays_today"`
+	NeverPlayed     int        `json:"never_played_songs"`
+	LastRefreshedAt *time.Time `json:"last_refreshed_at"`
+}
+
+type UsageResponse struct {
+	Granularity string                `json:"granularity"`
+	From        time.Time             `json:"from"`
+	To          time.Time             `json:"to"`
+	Series      []models.DailyMetrics `json:"series"`
+}
+
+type NeverPlayedResponse struct {
+	Songs    []models.SongPlays `json:"songs"`
+	Total    int                `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+}
+
+func NewAnalyticsHandler(db *sql.DB) *AnalyticsHandler {
+	return &AnalyticsHandler{db: db}
+}
+
+// AnalyticsRoutes atiende todas las rutas bajo /api/admin/analytics/.
+// Los datos salen de las tablas de acumulados, no del historial completo.
+func (h *AnalyticsHandler) AnalyticsRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	route := strings.Join(pathSegments(r.URL.Path, "/api/admin/analytics"), "/")
+	switch route {
+	case "", "overview":
+		h.overview(w)
+	case "usage":
+		h.usage(w, r)
+	case "songs/top":
+		h.topSongs(w, r)
+	case "songs/never-played":
+		h.neverPlayed(w, r)
+	case "storage":
+		h.storage(w)
+	case "funnel":
+		h.funnel(w)
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+func (h *AnalyticsHandler) overview(w http.ResponseWriter) {
+	var o AnalyticsOverview
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Format("2006-01-02")
+
+	var refreshed sql.NullTime
+	err := h.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM songs WHERE deleted_at IS NULL),
+			(SELECT COALESCE(SUM(bytes), 0) FROM analytics_genre_storage),
+			(SELECT COALESCE(MAX(active_users), 0) FROM analytics_daily WHERE day = ?),
+			(SELECT COALESCE(MAX(active_users), 0) FROM analytics_monthly WHERE month = ?),
+			(SELECT COALESCE(MAX(plays), 0) FROM analytics_daily WHERE day = ?),
+			(SELECT COUNT(*) FROM songs s LEFT JOIN analytics_song_plays p ON p.song_id = s.id
+			 WHERE s.deleted_at IS NULL AND (p.song_id IS NULL OR p.plays = 0)),
+			(SELECT MAX(refreshed_at) FROM analytics_refreshes)`,
+		today, month, today,
+	).Scan(&o.TotalUsers, &o.TotalSongs, &o.StorageBytes, &o.DailyActive, &o.MonthlyActive,
+		&o.PlaysToday, &o.NeverPlayed, &refreshed)
+	if err != nil {
+		log.Printf("Error obteniendo resumen de analíticas: %v", err)
+		http.Error(w, "Error al obtener las analíticas", http.StatusInternalServerError)
+		return
+	}
+	if refreshed.Valid {
+		o.LastRefreshedAt = &refreshed.Time
+	}
+
+	writeJSON(w, http.StatusOK, o)
+}
+
+// usage devuelve la serie de uso: usuarios activos, reproducciones, minutos,
+// subidas y registros. Parámetros: granularity (day o month), from, to.
+func (h *AnalyticsHandler) usage(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	from, to, err := models.ParseDateRange(params.Get("from"), params.Get("to"), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	granularity := params.Get("granularity")
+	var query string
+	switch granularity {
+	case "", "day":
+		granularity = "day"
+		query = `
+			SELECT day, active_users, plays, listen_seconds, uploads, upload_bytes, signups
+			FROM analytics_daily WHERE day >= ? AND day <= ? ORDER BY day`
+	case "month":
+		from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, from.Location())
+		query = `
+			SELECT month, active_users, plays, listen_seconds, uploads, upload_bytes, signups
+			FROM analytics_monthly WHERE month >= ? AND month <= ? ORDER BY month`
+	default:
+		http.Error(w, "Granularidad inválida, use day o month", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := h.db.Query(query, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		log.Printf("Error obteniendo uso: %v", err)
+		http.Error(w, "Error al obtener las analíticas", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	response := UsageResponse{Granularity: granularity, From: from, To: to, Series: []models.DailyMetrics{}}
+	for rows.Next() {
+		var m models.DailyMetrics
+		var seconds int
+		if err := rows.Scan(&m.Day, &m.ActiveUsers, &m.Plays, &seconds, &m.Uploads, &m.UploadBytes, &m.Signups); err != nil {
+			http.Error(w, "Error al leer las analíticas", http.StatusInternalServerError)
+			return
+		}
+		m.ListenMinutes = seconds / 60
+		response.Series = append(response.Series, m)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// topSongs devuelve las canciones más reproducidas. Parámetro: limit.
+func (h *AnalyticsHandler) topSongs(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	_, limit = models.NormalizePage(1, limit)
+
+	rows, err := h.db.Query(`
+		SELECT s.id, s.title, s.artist, s.genre, p.plays, p.last_played, s.created_at
+		FROM analytics_song_plays p
+		JOIN songs s ON s.id = p.song_id
+		WHERE p.plays > 0 AND s.deleted_at IS NULL
+		ORDER BY p.plays DESC, s.id
+		LIMIT ?`, limit)
+	if err != nil {
+		log.Printf("Error obteniendo canciones más reproducidas: %v", err)
+		http.Error(w, "Error al obtener las analíticas", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	songs, err := scanSongPlays(rows)
+	if err != nil {
+		http.Error(w, "Error al leer las analíticas", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, songs)
+}
+
+// neverPlayed devuelve las canciones que nadie reprodujo, las más antiguas
+// primero. Parámetros: page, page_size.
+func (h *AnalyticsHandler) neverPlayed(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	page, pageSize = models.NormalizePage(page, pageSize)
+
+	const where = `
+		FROM songs s
+		LEFT JOIN analytics_song_plays p ON p.song_id = s.id
+		WHERE s.deleted_at IS NULL AND (p.song_id IS NULL OR p.plays = 0)`
+
+	response := NeverPlayedResponse{Page: page, PageSize: pageSize}
+	if err := h.db.QueryRow("SELECT COUNT(*) " + where).Scan(&response.Total); err != nil {
+		log.Printf("Error contando canciones sin reproducir: %v", err)
+		http.Error(w, "Error al obtener las analíticas", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT s.id, s.title, s.artist, s.genre, COALESCE(p.plays, 0), p.last_played, s.created_at
+		`+where+`
+		ORDER BY s.created_at, s.id
+		LIMIT ? OFFSET ?`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		log.Printf("Error obteniendo canciones sin reproducir: %v", err)
+		http.Error(w, "Error al obtener las analíticas", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	if response.Songs, err = scanSongPlays(rows); err != nil {
+		http.Error(w, "Error al leer las analíticas", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// storage devuelve el espacio ocupado por género
+func (h *AnalyticsHandler) storage(w http.ResponseWriter) {
+	rows, err := h.db.Query("SELECT genre, songs, bytes FROM analytics_genre_storage ORDER BY bytes DESC, genre")
+	if err != nil {
+		log.Printf("Error obteniendo almacenamiento por género: %v", err)
+		http.Error(w, "Error al obtener las analíticas", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	genres := []models.GenreStorage{}
+	for rows.Next() {
+		var g models.GenreStorage
+		if err := rows.Scan(&g.Genre, &g.Songs, &g.Bytes); err != nil {
+			http.Error(w, "Error al leer las analíticas", http.StatusInternalServerError)
+			return
+		}
+		genres = append(genres, g)
+	}
+	writeJSON(w, http.StatusOK, genres)
+}
+
+// funnel devuelve el embudo de registro por mes de alta
+func (h *AnalyticsHandler) funnel(w http.ResponseWriter) {
+	rows, err := h.db.Query(
+		"SELECT month, signups, activated, engaged, retained FROM analytics_funnel ORDER BY month DESC LIMIT 24",
+	)
+	if err != nil {
+		log.Printf("Error obteniendo embudo de registro: %v", err)
+		http.Error(w, "Error al obtener las analíticas", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	cohorts := []models.FunnelCohort{}
+	for rows.Next() {
+		var c models.FunnelCohort
+		if err := rows.Scan(&c.Month, &c.Signups, &c.Activated, &c.Engaged, &c.Retained); err != nil {
+			http.Error(w, "Error al leer las analíticas", http.StatusInternalServerError)
+			return
+		}
+		cohorts = append(cohorts, c)
+	}
+	writeJSON(w, http.StatusOK, cohorts)
+}
+
+func scanSongPlays(rows *sql.Rows) ([]models.SongPlays, error) {
+	songs := []models.SongPlays{}
+	for rows.Next() {
+		var s models.SongPlays
+		var lastPlayed sql.NullTime
+		if err := rows.Scan(&s.SongID, &s.Title, &s.Artist, &s.Genre, &s.Plays, &lastPlayed, &s.AddedAt); err != nil {
+			return nil, err
+		}
+		if lastPlayed.Valid {
+			s.LastPlayed = &lastPlayed.Time
+		}
+		songs = append(songs, s)
+	}
+	return songs, rows.Err()
+}
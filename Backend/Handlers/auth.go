@@ -11,9 +11,13 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
 )
 
 type AuthHandler struct {
@@ -21,8 +25,11 @@ type AuthHandler struct {
 }
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	DeviceID   string `json:"device_id"` // Opcionales: datos del dispositivo que inicia sesión
+	DeviceName string `json:"device_name"`
+	DeviceType string `json:"device_type"`
 }
 
 type LoginResponse struct {
@@ -32,13 +39,15 @@ type LoginResponse struct {
 	Role     string `json:"role"`
 	Token    string `json:"token"`
 	ExpireAt string `json:"expire_at"`
+	DeviceID string `json:"device_id"`
 }
 
 type UserInfo struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	DeviceID string `json:"device_id,omitempty"` // Dispositivo de la sesión (vacío en tokens sin dispositivo)
 }
 
 func NewAuthHandler(db *sql.DB) *AuthHandler {
@@ -80,11 +89,25 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Registrar el dispositivo; el token queda ligado a su sesión
+	device := models.Device{UserID: user.ID, DeviceID: req.DeviceID, Name: req.DeviceName, Type: req.DeviceType}
+	if err := device.Normalize(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	session, err := registerDevice(h.db, &device)
+	if err != nil {
+		log.Printf("Error registrando dispositivo del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+		return
+	}
+	user.DeviceID = device.DeviceID
+
 	// Generar token basado en el rol
 	if user.Role == "admin" {
-		user.Token = "admin-token"
+		user.Token = "admin-token-" + user.Email + ":" + session
 	} else {
-		user.Token = "user-token-" + user.Email
+		user.Token = "user-token-" + user.Email + ":" + session
 	}
 
 	user.ExpireAt = time.Now().Add(24 * time.Hour).Format(time.RFC3339)
@@ -101,7 +124,12 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Invalida el token en caso de ser necesario (por ejemplo, para nuestro proyecto sería en la base de datos)
+	// Cierra la sesión del dispositivo; los tokens sin dispositivo no se guardan
+	if user, err := UserFromRequest(h.db, r); err == nil && user.DeviceID != "" {
+		if err := revokeDevice(h.db, user.ID, user.DeviceID); err != nil && err != sql.ErrNoRows {
+			log.Printf("Error cerrando sesión del dispositivo %s: %v", user.DeviceID, err)
+		}
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Sesión cerrada exitosamente"})
@@ -119,13 +147,8 @@ func (h *AuthHandler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := authHeader[len("Bearer "):]
-
-	var user UserInfo
-	query := `SELECT id, name, email, role FROM users WHERE role || '-token-' || email = ?`
-
-	err := h.db.QueryRow(query, token).Scan(&user.ID, &user.Name, &user.Email, &user.Role)
-	if err == sql.ErrNoRows {
+	user, err := UserFromRequest(h.db, r)
+	if err == ErrInvalidToken {
 		http.Error(w, "Token inválido o usuario no encontrado", http.StatusUnauthorized)
 		return
 	} else if err != nil {
@@ -137,3 +160,75 @@ func (h *AuthHandler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
+
+// ErrInvalidToken indica que el token no corresponde a ningún usuario
+var ErrInvalidToken = errors.New("token inválido")
+
+// UserFromRequest obtiene el usuario dueño del token enviado en la cabecera
+// Authorization. Los tokens tienen la forma "user-token-<email>:<sesión>" o
+// "admin-token-<email>:<sesión>" (ver Login); la sesión identifica al
+// dispositivo y deja de valer cuando se revoca. Un token sin sesión no es
+// válido: el cliente debe volver a iniciar sesión. EventSource y <audio>
+// no permiten enviar cabeceras, por eso solo en esas rutas (ver
+// acceptsQueryToken) también se acepta el parámetro ?token=.
+func UserFromRequest(db *sql.DB, r *http.Request) (*UserInfo, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" && acceptsQueryToken(r.URL.Path) {
+		token = r.URL.Query().Get("token")
+	}
+
+	var email string
+	switch {
+	case strings.HasPrefix(token, "user-token-"):
+		email = strings.TrimPrefix(token, "user-token-")
+	case strings.HasPrefix(token, "admin-token-"):
+		email = strings.TrimPrefix(token, "admin-token-")
+	default:
+		return nil, ErrInvalidToken
+	}
+
+	i := strings.LastIndex(email, ":")
+	if i < 0 || i == len(email)-1 {
+		return nil, ErrInvalidToken
+	}
+	return userFromSession(db, email[:i], email[i+1:])
+}
+
+// acceptsQueryToken indica si la ruta acepta el token en la URL: solo
+// /api/events y /api/songs/{id}/stream. En las demás quedaría en los logs
+// de acceso, los proxies y la cabecera Referer.
+func acceptsQueryToken(path string) bool {
+	if path == "/api/events" {
+		return true
+	}
+	segs := pathSegments(path, "/api/songs/")
+	return strings.HasPrefix(path, "/api/songs/") && len(segs) == 2 && segs[1] == "stream"
+}
+
+// userFromSession valida la sesión de un dispositivo y actualiza cuándo se
+// vio por última vez (como mucho una vez por minuto)
+func userFromSession(db *sql.DB, email, session string) (*UserInfo, error) {
+	var user UserInfo
+	var deviceRow int
+	err := db.QueryRow(`
+		SELECT u.id, u.name, u.email, u.role, d.id, d.device_id
+		FROM user_devices d
+		JOIN users u ON u.id = d.user_id
+		WHERE d.session_token = ? AND u.email = ? AND d.revoked_at IS NULL`,
+		session, email,
+	).Scan(&user.ID, &user.Name, &user.Email, &user.Role, &deviceRow, &user.DeviceID)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if _, err := db.Exec(
+		"UPDATE user_devices SET last_seen_at = ? WHERE id = ? AND last_seen_at < ?",
+		now, deviceRow, now.Add(-time.Minute),
+	); err != nil {
+		log.Printf("Error actualizando dispositivo %s: %v", user.DeviceID, err)
+	}
+	return &user, nil
+}
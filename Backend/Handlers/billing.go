@@ -0,0 +1,429 @@
+// Backend/Handlers/billing.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase billing, con sus respectivas
+funciones para las suscripciones pagas, los webhooks del proveedor
+de pagos y la verificacion de funciones habilitadas por plan
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+const maxWebhookBody = 64 * 1024
+
+type BillingHandler struct {
+	db       *sql.DB
+	provider models.PaymentProvider
+	events   *models.EventHub
+}
+
+// SubscriptionResponse es el plan vigente del usuario y su suscripción
+type SubscriptionResponse struct {
+	Plan         models.Plan              `json:"plan"`
+	Subscription *models.UserSubscription `json:"subscription"`
+	RenewsAt     *time.Time               `json:"renews_at"`
+}
+
+func NewBillingHandler(db *sql.DB, provider models.PaymentProvider, events *models.EventHub) *BillingHandler {
+	return &BillingHandler{db: db, provider: provider, events: events}
+}
+
+// Plans devuelve los planes con sus límites y funciones
+func (h *BillingHandler) Plans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, []models.Plan{models.Plans[models.PlanFree], models.Plans[models.PlanPremium]})
+}
+
+// Subscription atiende /api/me/subscription: GET consulta, POST contrata
+// un plan ({"plan": "premium"}) y DELETE cancela la renovación
+func (h *BillingHandler) Subscription(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.respondSubscription(w, http.StatusOK, user)
+	case http.MethodPost:
+		h.subscribe(w, r, user)
+	case http.MethodDelete:
+		h.cancel(w, user)
+	default:
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *BillingHandler) subscribe(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	var input struct {
+		Plan string `json:"plan"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	plan, ok := models.Plans[input.Plan]
+	if !ok || plan.PriceCents <= 0 {
+		http.Error(w, "Plan inválido", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al crear la suscripción", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Bloquear al usuario para no crear dos suscripciones a la vez
+	var locked int
+	if err := tx.QueryRow("SELECT id FROM users WHERE id = ? FOR UPDATE", user.ID).Scan(&locked); err != nil {
+		http.Error(w, "Error al crear la suscripción", http.StatusInternalServerError)
+		return
+	}
+	current, err := currentSubscription(tx, user.ID)
+	if err != nil {
+		http.Error(w, "Error al obtener la suscripción", http.StatusInternalServerError)
+		return
+	}
+	if current != nil && current.Grants(time.Now()) {
+		http.Error(w, "Ya tienes una suscripción vigente", http.StatusConflict)
+		return
+	}
+
+	ref, periodEnd, err := h.provider.CreateSubscription(user.ID, plan)
+	if err != nil {
+		log.Printf("Error cobrando suscripción del usuario %d: %v", user.ID, err)
+		http.Error(w, "No se pudo procesar el pago", http.StatusPaymentRequired)
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO user_subscriptions
+			(user_id, plan, status, provider, provider_ref, auto_renew, started_at, current_period_end)
+		VALUES (?, ?, ?, ?, ?, TRUE, ?, ?)`,
+		user.ID, plan.Name, models.SubscriptionActive, h.provider.Name(), ref, time.Now(), periodEnd,
+	)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error guardando suscripción del usuario %d: %v", user.ID, err)
+		h.provider.CancelSubscription(ref)
+		http.Error(w, "Error al crear la suscripción", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondSubscription(w, http.StatusCreated, user)
+}
+
+// cancel desactiva la renovación; el plan sigue vigente hasta el fin del período
+func (h *BillingHandler) cancel(w http.ResponseWriter, user *UserInfo) {
+	current, err := currentSubscription(h.db, user.ID)
+	if err != nil {
+		http.Error(w, "Error al obtener la suscripción", http.StatusInternalServerError)
+		return
+	}
+	if current == nil || current.Status != models.SubscriptionActive {
+		http.Error(w, "No tienes una suscripción activa", http.StatusNotFound)
+		return
+	}
+
+	if err := h.provider.CancelSubscription(current.ProviderRef); err != nil {
+		log.Printf("Error cancelando suscripción %d en el proveedor: %v", current.ID, err)
+		http.Error(w, "No se pudo cancelar la suscripción", http.StatusBadGateway)
+		return
+	}
+	if _, err := h.db.Exec(
+		"UPDATE user_subscriptions SET status = ?, auto_renew = FALSE, canceled_at = ? WHERE id = ?",
+		models.SubscriptionCanceled, time.Now(), current.ID,
+	); err != nil {
+		http.Error(w, "Error al cancelar la suscripción", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondSubscription(w, http.StatusOK, user)
+}
+
+func (h *BillingHandler) respondSubscription(w http.ResponseWriter, status int, user *UserInfo) {
+	plan, sub, err := userPlan(h.db, user)
+	if err != nil {
+		log.Printf("Error obteniendo plan del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al obtener la suscripción", http.StatusInternalServerError)
+		return
+	}
+	response := SubscriptionResponse{Plan: plan, Subscription: sub}
+	if sub != nil {
+		response.RenewsAt = sub.RenewsAt()
+	}
+	writeJSON(w, status, response)
+}
+
+// Webhook recibe los avisos del proveedor de pagos. La firma va en la
+// cabecera X-Billing-Signature; los eventos repetidos se ignoran.
+func (h *BillingHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.processWebhook(payload, r.Header.Get("X-Billing-Signature"))
+	if err == errWebhookFailed {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, event)
+}
+
+// Simulate genera un evento firmado del proveedor falso y lo procesa igual
+// que un webhook real. Cuerpo: {"subscription_id": 3, "type": "subscription.renewed"}.
+func (h *BillingHandler) Simulate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	fake, ok := h.provider.(*models.FakePaymentProvider)
+	if !ok {
+		http.Error(w, "Solo disponible con el proveedor de pagos de prueba", http.StatusNotFound)
+		return
+	}
+
+	var input struct {
+		SubscriptionID int    `json:"subscription_id"`
+		Type           string `json:"type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := scanSubscription(h.db.QueryRow(subscriptionSelect+" WHERE id = ?", input.SubscriptionID))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Suscripción no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error al obtener la suscripción", http.StatusInternalServerError)
+		return
+	}
+
+	payload, signature, err := fake.SimulateEvent(input.Type, sub.ProviderRef, sub.CurrentPeriodEnd)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event, err := h.processWebhook(payload, signature)
+	if err == errWebhookFailed {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub, err = scanSubscription(h.db.QueryRow(subscriptionSelect+" WHERE id = ?", input.SubscriptionID))
+	if err != nil {
+		http.Error(w, "Error al obtener la suscripción", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"event":        event,
+		"signature":    signature,
+		"subscription": sub,
+	})
+}
+
+// errWebhookFailed es un error al guardar el evento. Se responde 500 para
+// que el proveedor lo vuelva a enviar; un 4xx lo da por descartado.
+var errWebhookFailed = errors.New("error al procesar el evento")
+
+// processWebhook valida el evento y lo aplica a la suscripción. Cada
+// evento se registra en billing_events para aplicarlo una sola vez. Devuelve
+// errWebhookFailed si falla la base de datos; cualquier otro error es una
+// firma o un evento inválido.
+func (h *BillingHandler) processWebhook(payload []byte, signature string) (*models.WebhookEvent, error) {
+	event, err := h.provider.ParseWebhook(payload, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		log.Printf("Error procesando evento de pago %s: %v", event.ID, err)
+		return nil, errWebhookFailed
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"INSERT IGNORE INTO billing_events (event_id, provider, type, subscription_ref, payload) VALUES (?, ?, ?, ?, ?)",
+		event.ID, h.provider.Name(), event.Type, event.SubscriptionRef, payload,
+	)
+	if err != nil {
+		log.Printf("Error registrando evento de pago %s: %v", event.ID, err)
+		return nil, errWebhookFailed
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return event, nil // Ya procesado
+	}
+
+	sub, err := scanSubscription(tx.QueryRow(
+		subscriptionSelect+" WHERE provider = ? AND provider_ref = ? FOR UPDATE",
+		h.provider.Name(), event.SubscriptionRef,
+	))
+	if err == sql.ErrNoRows {
+		// Se guarda el evento igual para no reintentarlo
+		log.Printf("Evento de pago %s para una suscripción desconocida: %s", event.ID, event.SubscriptionRef)
+		return event, tx.Commit()
+	} else if err != nil {
+		log.Printf("Error obteniendo suscripción del evento de pago %s: %v", event.ID, err)
+		return nil, errWebhookFailed
+	}
+
+	var message string
+	now := time.Now()
+	switch event.Type {
+	case models.WebhookRenewed:
+		if event.PeriodEnd.After(sub.CurrentPeriodEnd) {
+			sub.CurrentPeriodEnd = event.PeriodEnd
+		}
+		sub.Status = models.SubscriptionActive
+		message = fmt.Sprintf("Tu plan %s se renovó hasta el %s", sub.Plan, sub.CurrentPeriodEnd.Format("02-01-2006"))
+	case models.WebhookCanceled:
+		sub.Status, sub.AutoRenew, sub.CanceledAt = models.SubscriptionCanceled, false, &now
+		message = fmt.Sprintf("Tu plan %s fue cancelado y sigue vigente hasta el %s", sub.Plan, sub.CurrentPeriodEnd.Format("02-01-2006"))
+	case models.WebhookPaymentFailed:
+		sub.Status = models.SubscriptionPastDue
+		message = fmt.Sprintf("No pudimos cobrar la renovación de tu plan %s", sub.Plan)
+	case models.WebhookExpired:
+		sub.Status, sub.AutoRenew, sub.EndedAt = models.SubscriptionExpired, false, &now
+		message = fmt.Sprintf("Tu plan %s terminó", sub.Plan)
+	default:
+		log.Printf("Evento de pago %s de tipo desconocido: %s", event.ID, event.Type)
+		return event, tx.Commit()
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE user_subscriptions
+		SET status = ?, auto_renew = ?, current_period_end = ?, canceled_at = ?, ended_at = ?
+		WHERE id = ?`,
+		sub.Status, sub.AutoRenew, sub.CurrentPeriodEnd, sub.CanceledAt, sub.EndedAt, sub.ID,
+	); err != nil {
+		log.Printf("Error actualizando suscripción %d: %v", sub.ID, err)
+		return nil, errWebhookFailed
+	}
+
+	notification, err := createNotification(tx, sub.UserID, models.NotificationSubscription, message,
+		map[string]interface{}{"subscription_id": sub.ID, "status": sub.Status})
+	if err != nil {
+		log.Printf("Error notificando evento de pago %s: %v", event.ID, err)
+		return nil, errWebhookFailed
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error guardando evento de pago %s: %v", event.ID, err)
+		return nil, errWebhookFailed
+	}
+	publishNotification(h.events, notification)
+	return event, nil
+}
+
+// RequireEntitlement deja pasar solo a los usuarios cuyo plan habilita la
+// función; a los demás les responde 402 con los planes que la incluyen
+func RequireEntitlement(db *sql.DB, feature string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := requireUser(db, w, r)
+		if !ok {
+			return
+		}
+		plan, _, err := userPlan(db, user)
+		if err != nil {
+			log.Printf("Error obteniendo plan del usuario %d: %v", user.ID, err)
+			http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+			return
+		}
+		if !plan.Entitlements.Allows(feature) {
+			writeJSON(w, http.StatusPaymentRequired, map[string]interface{}{
+				"error":   "Tu plan no incluye esta función",
+				"feature": feature,
+				"plan":    plan.Name,
+				"plans":   models.PlansWith(feature),
+			})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// userPlan devuelve el plan vigente del usuario: el de su suscripción si
+// está vigente o, si no, el asignado en users.plan
+func userPlan(q queryer, user *UserInfo) (models.Plan, *models.UserSubscription, error) {
+	var assigned string
+	if err := q.QueryRow("SELECT plan FROM users WHERE id = ?", user.ID).Scan(&assigned); err != nil {
+		return models.Plan{}, nil, err
+	}
+	return effectivePlan(q, user, assigned)
+}
+
+func effectivePlan(q queryer, user *UserInfo, assigned string) (models.Plan, *models.UserSubscription, error) {
+	sub, err := currentSubscription(q, user.ID)
+	if err != nil {
+		return models.Plan{}, nil, err
+	}
+	if sub != nil && sub.Grants(time.Now()) {
+		return models.PlanFor(user.Role, sub.Plan), sub, nil
+	}
+	return models.PlanFor(user.Role, assigned), sub, nil
+}
+
+const subscriptionSelect = `
+	SELECT id, user_id, plan, status, provider, provider_ref, auto_renew,
+	       started_at, current_period_end, canceled_at, ended_at
+	FROM user_subscriptions`
+
+// currentSubscription devuelve la última suscripción del usuario, o nil
+func currentSubscription(q queryer, userID int) (*models.UserSubscription, error) {
+	sub, err := scanSubscription(q.QueryRow(subscriptionSelect+" WHERE user_id = ? ORDER BY id DESC LIMIT 1", userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sub, err
+}
+
+func scanSubscription(row rowScanner) (*models.UserSubscription, error) {
+	var sub models.UserSubscription
+	var canceledAt, endedAt sql.NullTime
+	err := row.Scan(&sub.ID, &sub.UserID, &sub.Plan, &sub.Status, &sub.Provider, &sub.ProviderRef, &sub.AutoRenew,
+		&sub.StartedAt, &sub.CurrentPeriodEnd, &canceledAt, &endedAt)
+	if err != nil {
+		return nil, err
+	}
+	if canceledAt.Valid {
+		sub.CanceledAt = &canceledAt.Time
+	}
+	if endedAt.Valid {
+		sub.EndedAt = &endedAt.Time
+	}
+	return &sub, nil
+}
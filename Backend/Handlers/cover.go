@@ -0,0 +1,109 @@
+// Backend/Handlers/cover.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Entrega de la portada de una cancion en los tamanos estandar,
+con una portada generada si la cancion no tiene
+*/
+
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"image/png"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/database"
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// Cover atiende GET /api/songs/{id}/cover?size=N. Es pública porque las
+// etiquetas <img> no pueden mandar el token.
+// El tamaño se ajusta al estándar más cercano (models.CoverSizes). Como las
+// portadas se guardan por hash, si ?v= coincide con el hash actual la
+// respuesta no cambia nunca y se puede guardar en caché indefinidamente.
+func (h *SongHandler) Cover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/songs")
+	if len(segs) != 2 || segs[1] != "cover" {
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+		return
+	}
+	songID, err := strconv.Atoi(segs[0])
+	if err != nil {
+		http.Error(w, "ID de canción inválido", http.StatusBadRequest)
+		return
+	}
+	requested, _ := strconv.Atoi(r.URL.Query().Get("size"))
+	size := models.CoverSize(requested)
+
+	var title, artist string
+	var coverHash sql.NullString
+	err = h.db.QueryRow(
+		"SELECT title, artist, cover_hash FROM songs WHERE id = ? AND deleted_at IS NULL", songID,
+	).Scan(&title, &artist, &coverHash)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error obteniendo portada de la canción %d: %v", songID, err)
+		http.Error(w, "Error al obtener la portada", http.StatusInternalServerError)
+		return
+	}
+
+	if coverHash.Valid {
+		f, err := os.Open(database.CoverPath(coverHash.String, size))
+		if err == nil {
+			defer f.Close()
+			info, err := f.Stat()
+			if err == nil {
+				w.Header().Set("Content-Type", "image/jpeg")
+				w.Header().Set("ETag", fmt.Sprintf(`"%s-%d"`, coverHash.String, size))
+				if r.URL.Query().Get("v") == coverHash.String {
+					w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+				} else {
+					w.Header().Set("Cache-Control", "public, max-age=86400")
+				}
+				http.ServeContent(w, r, "", info.ModTime(), f)
+				return
+			}
+		}
+		// Si falta el archivo se entrega la portada generada
+		log.Printf("Portada %s de la canción %d no disponible: %v", coverHash.String, songID, err)
+	}
+
+	h.placeholderCover(w, r, artist+"\x00"+title, size)
+}
+
+// placeholderCover entrega la portada generada. Depende solo del artista, el
+// título y el tamaño, así que se valida con un ETag derivado de ellos; la
+// caché es más corta porque la canción puede recibir una portada después.
+func (h *SongHandler) placeholderCover(w http.ResponseWriter, r *http.Request, seed string, size int) {
+	sum := sha256.Sum256([]byte(seed))
+	etag := fmt.Sprintf(`"placeholder-%x-%d"`, sum[:8], size)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, models.PlaceholderCover(seed, size)); err != nil {
+		http.Error(w, "Error al generar la portada", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(buf.Bytes()))
+}
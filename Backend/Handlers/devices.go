@@ -0,0 +1,248 @@
+// Backend/Handlers/devices.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase devices, con sus respectivas
+funciones para el manejo de rutas del registro de dispositivos
+(listar, revocar sesiones y "reproducir aqui")
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type DeviceHandler struct {
+	db     *sql.DB
+	events *models.EventHub
+}
+
+func NewDeviceHandler(db *sql.DB, events *models.EventHub) *DeviceHandler {
+	return &DeviceHandler{db: db, events: events}
+}
+
+// DeviceRoutes atiende /api/me/devices:
+//
+//	GET    /api/me/devices                   dispositivos del usuario
+//	DELETE /api/me/devices/{id}              cierra la sesión del dispositivo
+//	POST   /api/me/devices/{id}/play-here    pausa los demás y reproduce en {id}
+func (h *DeviceHandler) DeviceRoutes(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	parts := pathSegments(r.URL.Path, "/api/me/devices")
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		h.listDevices(w, user)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		h.revoke(w, user, parts[0])
+	case len(parts) == 2 && parts[1] == "play-here" && r.Method == http.MethodPost:
+		h.playHere(w, user, parts[0])
+	case len(parts) <= 2:
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *DeviceHandler) listDevices(w http.ResponseWriter, user *UserInfo) {
+	rows, err := h.db.Query(`
+		SELECT id, user_id, device_id, name, type, created_at, last_seen_at, streaming_at, revoked_at
+		FROM user_devices
+		WHERE user_id = ? AND revoked_at IS NULL
+		ORDER BY last_seen_at DESC`, user.ID)
+	if err != nil {
+		log.Printf("Error listando dispositivos del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al obtener los dispositivos", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	devices := []models.Device{}
+	for rows.Next() {
+		var d models.Device
+		var streamingAt, revokedAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.UserID, &d.DeviceID, &d.Name, &d.Type, &d.CreatedAt, &d.LastSeenAt, &streamingAt, &revokedAt); err != nil {
+			http.Error(w, "Error al obtener los dispositivos", http.StatusInternalServerError)
+			return
+		}
+		if streamingAt.Valid {
+			d.StreamingAt = &streamingAt.Time
+		}
+		if revokedAt.Valid {
+			d.RevokedAt = &revokedAt.Time
+		}
+		d.Streaming = d.IsStreaming(now)
+		d.Current = d.DeviceID == user.DeviceID
+		devices = append(devices, d)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error al obtener los dispositivos", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, devices)
+}
+
+// revoke invalida el token del dispositivo y le avisa para que cierre sesión
+func (h *DeviceHandler) revoke(w http.ResponseWriter, user *UserInfo, deviceID string) {
+	err := revokeDevice(h.db, user.ID, deviceID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Dispositivo no encontrado", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error revocando dispositivo %s del usuario %d: %v", deviceID, user.ID, err)
+		http.Error(w, "Error al revocar el dispositivo", http.StatusInternalServerError)
+		return
+	}
+
+	h.events.Publish(user.ID, models.Event{Type: models.EventCommandPause, TargetDevice: deviceID})
+	h.events.Publish(user.ID, models.Event{Type: models.EventDeviceRevoked, TargetDevice: deviceID})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// playHere pausa los demás dispositivos que están reproduciendo y deja el
+// lugar reservado para {id}, así su siguiente inicio no choca con el límite
+// de reproducciones simultáneas
+func (h *DeviceHandler) playHere(w http.ResponseWriter, user *UserInfo, deviceID string) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al cambiar de dispositivo", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(
+		"UPDATE user_devices SET streaming_at = ? WHERE user_id = ? AND device_id = ? AND revoked_at IS NULL",
+		now, user.ID, deviceID,
+	)
+	if err != nil {
+		log.Printf("Error cambiando de dispositivo del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al cambiar de dispositivo", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var exists bool
+		if err := tx.QueryRow(
+			"SELECT EXISTS(SELECT 1 FROM user_devices WHERE user_id = ? AND device_id = ? AND revoked_at IS NULL)",
+			user.ID, deviceID,
+		).Scan(&exists); err != nil || !exists {
+			http.Error(w, "Dispositivo no encontrado", http.StatusNotFound)
+			return
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT device_id FROM user_devices
+		WHERE user_id = ? AND device_id <> ? AND revoked_at IS NULL AND streaming_at > ?
+		FOR UPDATE`,
+		user.ID, deviceID, now.Add(-models.ActiveStreamWindow),
+	)
+	if err != nil {
+		http.Error(w, "Error al cambiar de dispositivo", http.StatusInternalServerError)
+		return
+	}
+	paused := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			http.Error(w, "Error al cambiar de dispositivo", http.StatusInternalServerError)
+			return
+		}
+		paused = append(paused, id)
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(
+		"UPDATE user_devices SET streaming_at = NULL WHERE user_id = ? AND device_id <> ?",
+		user.ID, deviceID,
+	); err != nil {
+		http.Error(w, "Error al cambiar de dispositivo", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al cambiar de dispositivo", http.StatusInternalServerError)
+		return
+	}
+
+	for _, id := range paused {
+		h.events.Publish(user.ID, models.Event{Type: models.EventCommandPause, SourceDevice: deviceID, TargetDevice: id})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"device_id": deviceID, "paused": paused})
+}
+
+// registerDevice crea o reactiva el dispositivo con una sesión nueva y
+// devuelve el secreto de la sesión
+func registerDevice(q queryer, d *models.Device) (string, error) {
+	session := models.NewSessionToken()
+	now := time.Now()
+	_, err := q.Exec(`
+		INSERT INTO user_devices (user_id, device_id, name, type, session_token, created_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			name = VALUES(name),
+			type = VALUES(type),
+			session_token = VALUES(session_token),
+			last_seen_at = VALUES(last_seen_at),
+			streaming_at = NULL,
+			revoked_at = NULL`,
+		d.UserID, d.DeviceID, d.Name, d.Type, session, now, now,
+	)
+	return session, err
+}
+
+// revokeDevice cierra la sesión del dispositivo; devuelve sql.ErrNoRows si
+// no existe o ya estaba revocado
+func revokeDevice(q queryer, userID int, deviceID string) error {
+	result, err := q.Exec(`
+		UPDATE user_devices SET session_token = NULL, streaming_at = NULL, revoked_at = ?
+		WHERE user_id = ? AND device_id = ? AND revoked_at IS NULL`,
+		time.Now(), userID, deviceID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// touchDevice anota que el dispositivo de la sesión sigue conectado y si
+// está sonando. Solo actualiza dispositivos registrados al iniciar sesión;
+// uno revocado no vuelve a contar como reproducción activa.
+func touchDevice(q queryer, userID int, deviceID string, streaming bool) error {
+	now := time.Now()
+	var streamingAt interface{}
+	if streaming {
+		streamingAt = now
+	}
+	_, err := q.Exec(`
+		UPDATE user_devices SET last_seen_at = ?, streaming_at = ?
+		WHERE user_id = ? AND device_id = ? AND revoked_at IS NULL`,
+		now, streamingAt, userID, deviceID,
+	)
+	return err
+}
+
+// requireSessionDevice devuelve el dispositivo ligado a la sesión del token
+// o responde 401. El device_id que envía el cliente no se usa para las
+// reglas: cambiarlo en cada petición evitaría el límite de reproducciones.
+func requireSessionDevice(w http.ResponseWriter, user *UserInfo) (string, bool) {
+	if user.DeviceID == "" {
+		http.Error(w, "La sesión no tiene dispositivo, vuelva a iniciar sesión", http.StatusUnauthorized)
+		return "", false
+	}
+	return user.DeviceID, true
+}
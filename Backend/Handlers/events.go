@@ -0,0 +1,178 @@
+// Backend/Handlers/events.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase events, con sus respectivas
+funciones para el manejo de rutas de sincronizacion en tiempo real
+(Server-Sent Events)
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+const eventsHeartbeatInterval = 15 * time.Second
+
+type EventsHandler struct {
+	db  *sql.DB
+	hub *models.EventHub
+}
+
+type PublishRequest struct {
+	Type         string          `json:"type"`
+	TargetDevice string          `json:"target_device"`
+	Data         json.RawMessage `json:"data"`
+}
+
+func NewEventsHandler(db *sql.DB, hub *models.EventHub) *EventsHandler {
+	return &EventsHandler{db: db, hub: hub}
+}
+
+// Stream abre el canal SSE del dispositivo de la sesión. Al reconectar, el
+// navegador envía la cabecera Last-Event-ID y se reenvían los eventos que se
+// perdieron.
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+	deviceID, ok := requireSessionDevice(w, user)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "El servidor no soporta streaming", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	lastID, _ := strconv.ParseInt(lastEventID, 10, 64)
+
+	sub, replay := h.hub.Subscribe(user.ID, deviceID, lastID)
+	defer h.hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// Tiempo de espera sugerido al navegador antes de reconectar
+	fmt.Fprint(w, "retry: 3000\n\n")
+	for _, event := range replay {
+		writeEvent(w, deviceID, event)
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(eventsHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-sub.Events:
+			if !open {
+				return
+			}
+			writeEvent(w, deviceID, event)
+			flusher.Flush()
+		case <-ticker.C:
+			// Comentario SSE para mantener viva la conexión a través de proxies
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// Publish recibe un cambio de estado de un cliente (play, pause, cambio de
+// canción) o una orden de control remoto y la reenvía a los demás
+// dispositivos. El origen es el dispositivo de la sesión.
+func (h *EventsHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	deviceID, ok := requireSessionDevice(w, user)
+	if !ok {
+		return
+	}
+
+	var req PublishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	if !models.IsClientEvent(req.Type) {
+		http.Error(w, "Tipo de evento inválido", http.StatusBadRequest)
+		return
+	}
+
+	event := h.hub.Publish(user.ID, models.Event{
+		Type:         req.Type,
+		SourceDevice: deviceID,
+		TargetDevice: req.TargetDevice,
+		Data:         req.Data,
+	})
+
+	writeJSON(w, http.StatusAccepted, event)
+}
+
+// Devices lista los dispositivos del usuario con el canal abierto
+func (h *EventsHandler) Devices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	devices := h.hub.Devices(user.ID)
+	if devices == nil {
+		devices = []string{}
+	}
+	writeJSON(w, http.StatusOK, devices)
+}
+
+// writeEvent escribe un evento en formato SSE. Los eventos dirigidos a otro
+// dispositivo y los que originó el propio dispositivo no se envían, pero su ID
+// sí avanza para que el Last-Event-ID del cliente siga siendo correcto.
+func writeEvent(w http.ResponseWriter, deviceID string, event models.Event) {
+	skip := (event.TargetDevice != "" && event.TargetDevice != deviceID) ||
+		(deviceID != "" && event.SourceDevice == deviceID)
+
+	if skip {
+		fmt.Fprintf(w, "id: %d\n\n", event.ID)
+		return
+	}
+
+	payload, _ := json.Marshal(event)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload)
+}
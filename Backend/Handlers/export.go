@@ -0,0 +1,319 @@
+// Backend/Handlers/export.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase export, con sus respectivas funciones
+para exportar e importar el catalogo, los favoritos, las playlists y el
+historial de escucha
+*/
+
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/database"
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type ExportHandler struct {
+	db *sql.DB
+}
+
+func NewExportHandler(db *sql.DB) *ExportHandler {
+	return &ExportHandler{db: db}
+}
+
+// ExportRoutes atiende /api/me/export/:
+//
+//	GET favorites?format=m3u8|xspf
+//	GET playlists/{id}?format=m3u8|xspf
+//	GET history?from=AAAA-MM-DD&to=AAAA-MM-DD (JSON Lines)
+func (h *ExportHandler) ExportRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/me/export/")
+	switch {
+	case len(segs) == 1 && segs[0] == "favorites":
+		h.exportFavorites(w, r, user)
+	case len(segs) == 2 && segs[0] == "playlists":
+		h.exportPlaylist(w, r, user, segs[1])
+	case len(segs) == 1 && segs[0] == "history":
+		h.exportHistory(w, r, user)
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+// ImportRoutes atiende POST /api/me/import/{favorites|playlists|history}.
+// El cuerpo es el archivo; sin ?format= se detecta por el contenido. La
+// respuesta es el reporte con las líneas que no se encontraron.
+func (h *ExportHandler) ImportRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/me/import/")
+	if len(segs) != 1 || (segs[0] != "favorites" && segs[0] != "playlists" && segs[0] != "history") {
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+		return
+	}
+	body, format, ok := readImport(w, r)
+	if !ok {
+		return
+	}
+
+	var report *models.ImportReport
+	var err error
+	switch segs[0] {
+	case "favorites", "playlists":
+		if format != models.FormatM3U8 && format != models.FormatXSPF {
+			http.Error(w, "Formato inválido, use m3u8 o xspf", http.StatusBadRequest)
+			return
+		}
+		list, parseErr := models.ParsePlaylist(bytes.NewReader(body), format)
+		if parseErr != nil {
+			http.Error(w, parseErr.Error(), http.StatusBadRequest)
+			return
+		}
+		if segs[0] == "favorites" {
+			report, err = database.ImportFavorites(user.ID, format, list)
+			break
+		}
+		quota, quotaErr := loadQuota(h.db, user, false)
+		if quotaErr != nil {
+			http.Error(w, "Error al obtener la cuota", http.StatusInternalServerError)
+			return
+		}
+		if quotaErr := quota.CheckPlaylist(); quotaErr != nil {
+			writeQuotaError(w, quotaErr)
+			return
+		}
+		report, err = database.ImportPlaylist(user.ID, r.URL.Query().Get("name"), format, list)
+	case "history":
+		if format != models.FormatJSONL {
+			http.Error(w, "Formato inválido, use jsonl", http.StatusBadRequest)
+			return
+		}
+		list, parseErr := models.ParseHistory(bytes.NewReader(body))
+		if parseErr != nil {
+			http.Error(w, parseErr.Error(), http.StatusBadRequest)
+			return
+		}
+		report, err = database.ImportHistory(user.ID, list)
+	}
+
+	if err == models.ErrEmptyImport {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error importando %s del usuario %d: %v", segs[0], user.ID, err)
+		http.Error(w, "Error al importar el archivo", http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusOK
+	if report.PlaylistID != 0 {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, report)
+}
+
+// Catalog atiende /api/admin/catalog: GET exporta el catálogo
+// (?format=csv|json) y POST compara un catálogo con el actual
+func (h *ExportHandler) Catalog(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = models.FormatCSV
+		}
+		if format != models.FormatCSV && format != models.FormatJSON {
+			http.Error(w, "Formato inválido, use csv o json", http.StatusBadRequest)
+			return
+		}
+		entries, err := database.CatalogEntries()
+		if err != nil {
+			log.Printf("Error exportando catálogo: %v", err)
+			http.Error(w, "Error al exportar el catálogo", http.StatusInternalServerError)
+			return
+		}
+		setExportHeaders(w, format, "catalogo")
+		models.WriteCatalog(w, format, entries)
+
+	case http.MethodPost:
+		body, format, ok := readImport(w, r)
+		if !ok {
+			return
+		}
+		if format != models.FormatCSV && format != models.FormatJSON {
+			http.Error(w, "Formato inválido, use csv o json", http.StatusBadRequest)
+			return
+		}
+		list, err := models.ParseCatalog(bytes.NewReader(body), format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		report, err := database.ImportCatalog(format, list)
+		if err == models.ErrEmptyImport {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.Printf("Error comparando catálogo: %v", err)
+			http.Error(w, "Error al importar el catálogo", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+
+	default:
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ExportHandler) exportFavorites(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	format, ok := playlistFormat(w, r)
+	if !ok {
+		return
+	}
+	tracks, err := database.FavoriteTracks(user.ID, requestBaseURL(r))
+	if err != nil {
+		log.Printf("Error exportando favoritos del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al exportar los favoritos", http.StatusInternalServerError)
+		return
+	}
+	setExportHeaders(w, format, "favoritos")
+	models.WritePlaylist(w, format, "Favoritos", user.Name, tracks)
+}
+
+// exportPlaylist exporta una playlist que el usuario puede ver
+func (h *ExportHandler) exportPlaylist(w http.ResponseWriter, r *http.Request, user *UserInfo, rawID string) {
+	format, ok := playlistFormat(w, r)
+	if !ok {
+		return
+	}
+	playlistID, err := strconv.Atoi(rawID)
+	if err != nil {
+		http.Error(w, "ID de playlist inválido", http.StatusBadRequest)
+		return
+	}
+
+	playlist, err := loadPlaylist(h.db, playlistID, false)
+	if err == sql.ErrNoRows || (err == nil && !playlist.CanView(user.ID)) {
+		http.Error(w, "Playlist no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error cargando playlist %d: %v", playlistID, err)
+		http.Error(w, "Error al obtener la playlist", http.StatusInternalServerError)
+		return
+	}
+
+	name, tracks, err := database.PlaylistTracks(playlist.ID, requestBaseURL(r))
+	if err != nil {
+		log.Printf("Error exportando playlist %d: %v", playlist.ID, err)
+		http.Error(w, "Error al exportar la playlist", http.StatusInternalServerError)
+		return
+	}
+	setExportHeaders(w, format, fmt.Sprintf("playlist-%d", playlist.ID))
+	models.WritePlaylist(w, format, name, user.Name, tracks)
+}
+
+// exportHistory entrega el historial en JSON Lines. from y to (inclusive)
+// son opcionales.
+func (h *ExportHandler) exportHistory(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	var from, to time.Time
+	for _, p := range []struct {
+		param  string
+		target *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		raw := r.URL.Query().Get(p.param)
+		if raw == "" {
+			continue
+		}
+		day, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			http.Error(w, "Fecha inválida, use AAAA-MM-DD", http.StatusBadRequest)
+			return
+		}
+		*p.target = day
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	setExportHeaders(w, models.FormatJSONL, "historial")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	// Las cabeceras ya se enviaron: un error a mitad solo se registra
+	err := database.ExportHistory(user.ID, from, to, func(e models.HistoryEntry) error {
+		return enc.Encode(e)
+	})
+	if err != nil {
+		log.Printf("Error exportando historial del usuario %d: %v", user.ID, err)
+	}
+}
+
+// playlistFormat lee ?format= para favoritos y playlists (m3u8 por defecto)
+func playlistFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.FormatM3U8
+	}
+	if format != models.FormatM3U8 && format != models.FormatXSPF {
+		http.Error(w, "Formato inválido, use m3u8 o xspf", http.StatusBadRequest)
+		return "", false
+	}
+	return format, true
+}
+
+// readImport lee el archivo a importar y su formato (?format= o detectado)
+func readImport(w http.ResponseWriter, r *http.Request) ([]byte, string, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, models.MaxImportBytes)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("El archivo supera el máximo de %s", models.FormatBytes(models.MaxImportBytes)),
+			http.StatusRequestEntityTooLarge)
+		return nil, "", false
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.DetectImportFormat(body)
+	}
+	return body, format, true
+}
+
+// setExportHeaders indica el tipo y el nombre del archivo descargado
+func setExportHeaders(w http.ResponseWriter, format, name string) {
+	w.Header().Set("Content-Type", models.ExportContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	w.Header().Set("Cache-Control", "private, no-store")
+}
+
+// requestBaseURL arma "http(s)://host" para que las URLs del M3U8 y del
+// XSPF sean absolutas
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
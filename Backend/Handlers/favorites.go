@@ -1,72 +1,322 @@
+// Backend/Handlers/favorites.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase favorites, con sus respectivas
+funciones para el manejo de rutas de canciones favoritas
+*/
+
 package handlers
 
 import (
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
 )
 
-// AddFavoriteHandler maneja la solicitud para agregar canciones favoritas
-func AddFavoriteHandler(db *sql.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var req struct {
-			SongID string `json:"songId"`
-			UserID string `json:"userId"`
-		}
+type FavoriteHandler struct {
+	db *sql.DB
+}
+
+type FavoritesResponse struct {
+	Favorites []models.Favorite `json:"favorites"`
+	Total     int               `json:"total"`
+	Page      int               `json:"page"`
+	PageSize  int               `json:"page_size"`
+}
+
+// FavoriteStatus indica si una canción es favorita del usuario y cuántos la marcaron
+type FavoriteStatus struct {
+	SongID     int        `json:"song_id"`
+	IsFavorite bool       `json:"is_favorite"`
+	LikedAt    *time.Time `json:"liked_at,omitempty"`
+	LikeCount  int        `json:"like_count"`
+}
+
+func NewFavoriteHandler(db *sql.DB) *FavoriteHandler {
+	return &FavoriteHandler{db: db}
+}
+
+// Favorites lista las canciones favoritas del usuario.
+// Parámetros: sort (date o title), order (asc o desc), page, page_size.
+func (h *FavoriteHandler) Favorites(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	orderBy, err := models.FavoriteOrder(params.Get("sort"), params.Get("order"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, _ := strconv.Atoi(params.Get("page"))
+	pageSize, _ := strconv.Atoi(params.Get("page_size"))
+	page, pageSize = models.NormalizePage(page, pageSize)
+
+	response := FavoritesResponse{
+		Favorites: []models.Favorite{},
+		Page:      page,
+		PageSize:  pageSize,
+	}
+	err = h.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM user_favorites f
+		JOIN songs s ON s.id = f.song_id
+		WHERE f.user_id = ? AND s.deleted_at IS NULL`, user.ID,
+	).Scan(&response.Total)
+	if err != nil {
+		log.Printf("Error contando favoritos del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al obtener favoritos", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT s.id, s.title, s.artist, s.genre, s.file_path, f.liked_at,
+		       (SELECT COUNT(*) FROM user_favorites c WHERE c.song_id = s.id)
+		FROM user_favorites f
+		JOIN songs s ON s.id = f.song_id
+		WHERE f.user_id = ? AND s.deleted_at IS NULL
+		ORDER BY `+orderBy+`
+		LIMIT ? OFFSET ?`,
+		user.ID, pageSize, (page-1)*pageSize,
+	)
+	if err != nil {
+		log.Printf("Error listando favoritos del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al obtener favoritos", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
 
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request", http.StatusBadRequest)
+	for rows.Next() {
+		var f models.Favorite
+		if err := rows.Scan(&f.SongID, &f.Title, &f.Artist, &f.Genre, &f.FilePath, &f.LikedAt, &f.LikeCount); err != nil {
+			http.Error(w, "Error al leer favoritos", http.StatusInternalServerError)
 			return
 		}
+		response.Favorites = append(response.Favorites, f)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
 
-		query := "INSERT INTO user_favorites (user_id, song_id) VALUES (?, ?)"
-		_, err := db.Exec(query, req.UserID, req.SongID)
+// FavoriteRoutes atiende todas las rutas bajo /api/favorites/
+func (h *FavoriteHandler) FavoriteRoutes(w http.ResponseWriter, r *http.Request) {
+	segs := pathSegments(r.URL.Path, "/api/favorites/")
+	if len(segs) == 0 {
+		h.Favorites(w, r)
+		return
+	}
+	if len(segs) != 1 {
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	switch {
+	case segs[0] == "counts" && r.Method == http.MethodGet:
+		h.counts(w, r)
+	// Rutas anteriores: el usuario sale del token, no del cuerpo de la petición
+	case (segs[0] == "add" || segs[0] == "remove") && r.Method == http.MethodPost:
+		var req struct {
+			SongID int `json:"song_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SongID <= 0 {
+			http.Error(w, "ID de canción inválido", http.StatusBadRequest)
+			return
+		}
+		if segs[0] == "add" {
+			h.addFavorite(w, user, req.SongID)
+		} else {
+			h.removeFavorite(w, user, req.SongID)
+		}
+	default:
+		songID, err := strconv.Atoi(segs[0])
 		if err != nil {
-			http.Error(w, "Error adding favorite", http.StatusInternalServerError)
+			http.Error(w, "ID de canción inválido", http.StatusBadRequest)
 			return
 		}
+		switch r.Method {
+		case http.MethodGet:
+			h.status(w, user, songID)
+		case http.MethodPut:
+			h.addFavorite(w, user, songID)
+		case http.MethodDelete:
+			h.removeFavorite(w, user, songID)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+	}
+}
 
-		w.WriteHeader(http.StatusOK)
+// addFavorite marca la canción como favorita. Es idempotente: si ya lo era
+// se conserva la fecha original.
+func (h *FavoriteHandler) addFavorite(w http.ResponseWriter, user *UserInfo, songID int) {
+	result, err := h.db.Exec(
+		"INSERT IGNORE INTO user_favorites (user_id, song_id) SELECT ?, id FROM songs WHERE id = ? AND deleted_at IS NULL",
+		user.ID, songID,
+	)
+	if err != nil {
+		log.Printf("Error agregando favorito %d del usuario %d: %v", songID, user.ID, err)
+		http.Error(w, "Error añadiendo favorito", http.StatusInternalServerError)
+		return
 	}
+
+	status, err := favoriteStatus(h.db, user.ID, songID)
+	if err != nil {
+		http.Error(w, "Error añadiendo favorito", http.StatusInternalServerError)
+		return
+	}
+	if !status.IsFavorite {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	}
+
+	code := http.StatusOK
+	if n, _ := result.RowsAffected(); n > 0 {
+		code = http.StatusCreated
+	}
+	writeJSON(w, code, status)
 }
 
-// GetFavoritesHandler maneja la solicitud para obtener canciones favoritas
-func GetFavoritesHandler(db *sql.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.URL.Query().Get("userId")
-		if userID == "" {
-			http.Error(w, "Missing userId", http.StatusBadRequest)
-			return
-		}
+// removeFavorite quita la canción de favoritos; no falla si no lo era
+func (h *FavoriteHandler) removeFavorite(w http.ResponseWriter, user *UserInfo, songID int) {
+	if _, err := h.db.Exec("DELETE FROM user_favorites WHERE user_id = ? AND song_id = ?", user.ID, songID); err != nil {
+		log.Printf("Error eliminando favorito %d del usuario %d: %v", songID, user.ID, err)
+		http.Error(w, "Error eliminando favorito", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-		query := `
-			SELECT songs.id, songs.title, songs.artist 
-			FROM songs
-			JOIN user_favorites ON songs.id = user_favorites.song_id
-			WHERE user_favorites.user_id = ?`
+func (h *FavoriteHandler) status(w http.ResponseWriter, user *UserInfo, songID int) {
+	status, err := favoriteStatus(h.db, user.ID, songID)
+	if err != nil {
+		http.Error(w, "Error al obtener el favorito", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
 
-		rows, err := db.Query(query, userID)
+// counts devuelve cuántos usuarios marcaron como favorita cada canción.
+// Parámetro: song_ids (lista separada por comas).
+func (h *FavoriteHandler) counts(w http.ResponseWriter, r *http.Request) {
+	var songIDs []int
+	for _, raw := range strings.Split(r.URL.Query().Get("song_ids"), ",") {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+		id, err := strconv.Atoi(raw)
 		if err != nil {
-			http.Error(w, "Error fetching favorites", http.StatusInternalServerError)
+			http.Error(w, "ID de canción inválido", http.StatusBadRequest)
 			return
 		}
-		defer rows.Close()
-
-		var favorites []map[string]string
-		for rows.Next() {
-			var id, title, artist string
-			if err := rows.Scan(&id, &title, &artist); err != nil {
-				http.Error(w, "Error scanning favorites", http.StatusInternalServerError)
-				return
-			}
-			favorites = append(favorites, map[string]string{
-				"id":     id,
-				"title":  title,
-				"artist": artist,
-			})
+		songIDs = append(songIDs, id)
+	}
+	if len(songIDs) == 0 || len(songIDs) > models.MaxSearchPageSize {
+		http.Error(w, "Debe indicar entre 1 y 100 canciones", http.StatusBadRequest)
+		return
+	}
+
+	counts, err := likeCounts(h.db, songIDs)
+	if err != nil {
+		log.Printf("Error contando favoritos: %v", err)
+		http.Error(w, "Error al obtener los favoritos", http.StatusInternalServerError)
+		return
+	}
+
+	// Las claves JSON son los IDs de canción
+	response := make(map[string]int, len(songIDs))
+	for _, id := range songIDs {
+		response[strconv.Itoa(id)] = counts[id]
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func favoriteStatus(q queryer, userID, songID int) (*FavoriteStatus, error) {
+	status := &FavoriteStatus{SongID: songID}
+
+	var likedAt time.Time
+	err := q.QueryRow(
+		"SELECT liked_at FROM user_favorites WHERE user_id = ? AND song_id = ?", userID, songID,
+	).Scan(&likedAt)
+	if err == nil {
+		status.IsFavorite = true
+		status.LikedAt = &likedAt
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if err := q.QueryRow("SELECT COUNT(*) FROM user_favorites WHERE song_id = ?", songID).Scan(&status.LikeCount); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// likeCounts cuenta los favoritos de varias canciones
+func likeCounts(q queryer, songIDs []int) (map[int]int, error) {
+	counts := make(map[int]int)
+	if len(songIDs) == 0 {
+		return counts, nil
+	}
+
+	placeholders := make([]string, len(songIDs))
+	args := make([]interface{}, len(songIDs))
+	for i, id := range songIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := q.Query(
+		"SELECT song_id, COUNT(*) FROM user_favorites WHERE song_id IN ("+strings.Join(placeholders, ",")+") GROUP BY song_id",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var songID, n int
+		if err := rows.Scan(&songID, &n); err != nil {
+			return nil, err
 		}
+		counts[songID] = n
+	}
+	return counts, rows.Err()
+}
+
+// favoriteSet devuelve las canciones favoritas del usuario
+func favoriteSet(q queryer, userID int) (map[int]bool, error) {
+	rows, err := q.Query("SELECT song_id FROM user_favorites WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(favorites)
+	favorites := make(map[int]bool)
+	for rows.Next() {
+		var songID int
+		if err := rows.Scan(&songID); err != nil {
+			return nil, err
+		}
+		favorites[songID] = true
 	}
+	return favorites, rows.Err()
 }
@@ -0,0 +1,56 @@
+// Backend/Handlers/helpers.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Funciones auxiliares compartidas por los manejadores
+(respuestas JSON, lectura de rutas y usuario autenticado)
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// writeJSON responde con el código indicado y el valor serializado en JSON
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// pathSegments devuelve los segmentos de la ruta que siguen al prefijo.
+// Por ejemplo "/api/playlists/4/songs" con prefijo "/api/playlists/"
+// devuelve ["4", "songs"].
+func pathSegments(path, prefix string) []string {
+	rest := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if rest == "" {
+		return nil
+	}
+	return strings.Split(rest, "/")
+}
+
+// requireUser obtiene el usuario autenticado o responde 401 si el token no es válido
+func requireUser(db *sql.DB, w http.ResponseWriter, r *http.Request) (*UserInfo, bool) {
+	user, err := UserFromRequest(db, r)
+	if err == ErrInvalidToken {
+		http.Error(w, "No autorizado", http.StatusUnauthorized)
+		return nil, false
+	} else if err != nil {
+		log.Printf("Error obteniendo usuario: %v", err)
+		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+		return nil, false
+	}
+	return user, true
+}
+
+// queryer agrupa los métodos comunes de *sql.DB y *sql.Tx
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
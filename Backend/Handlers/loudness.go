@@ -0,0 +1,47 @@
+// Backend/Handlers/loudness.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Lectura de los datos de normalizacion de volumen (sonoridad
+EBU R128) que se devuelven junto con las canciones
+*/
+
+package handlers
+
+import (
+	"database/sql"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// loudnessColumns son las columnas de song_loudness (alias l) que lee loudnessRow
+const loudnessColumns = `l.integrated_lufs, l.true_peak_dbtp, l.track_gain_db, l.track_peak, l.album_gain_db, l.album_peak`
+
+// loudnessRow recibe las columnas de un LEFT JOIN con song_loudness
+type loudnessRow struct {
+	integrated, truePeak, trackGain, trackPeak, albumGain, albumPeak sql.NullFloat64
+}
+
+func (l *loudnessRow) dest() []interface{} {
+	return []interface{}{&l.integrated, &l.truePeak, &l.trackGain, &l.trackPeak, &l.albumGain, &l.albumPeak}
+}
+
+// value devuelve nil si la canción no se analizó o el análisis falló
+func (l *loudnessRow) value() *models.SongLoudness {
+	if !l.trackGain.Valid {
+		return nil
+	}
+	s := &models.SongLoudness{
+		TruePeakDBTP:  l.truePeak.Float64,
+		TrackGainDB:   l.trackGain.Float64,
+		TrackPeak:     l.trackPeak.Float64,
+		AlbumGainDB:   l.albumGain.Float64,
+		AlbumPeak:     l.albumPeak.Float64,
+		ReferenceLUFS: models.ReferenceLoudness,
+	}
+	if l.integrated.Valid {
+		lufs := l.integrated.Float64
+		s.IntegratedLUFS = &lufs
+	}
+	return s
+}
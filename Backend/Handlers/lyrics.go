@@ -0,0 +1,171 @@
+// Backend/Handlers/lyrics.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Funciones de la clase songs para consultar y editar la letra de
+una cancion (texto plano o sincronizada en LRC)
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"PROYECTO_STREAMING/Backend/database"
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// lyrics atiende GET /api/songs/{id}/lyrics: la letra en líneas con el
+// tiempo de cada una en milisegundos para resaltar la actual. Con
+// ?format=raw se entrega el texto tal como se guardó (LRC o plano).
+func (h *SongHandler) lyrics(w http.ResponseWriter, r *http.Request, songID int) {
+	lyrics, body, format, err := loadLyrics(h.db, songID)
+	if err == sql.ErrNoRows {
+		http.Error(w, models.ErrNoLyrics.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error obteniendo letra de la canción %d: %v", songID, err)
+		http.Error(w, "Error al obtener la letra", http.StatusInternalServerError)
+		return
+	}
+
+	raw := r.URL.Query().Get("format") == "raw"
+	etag := fmt.Sprintf(`"lyrics-%d-%d`, songID, lyrics.UpdatedAt.UnixMilli())
+	if raw {
+		etag += "-raw"
+	}
+	etag += `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if raw {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if format == models.LyricsLRC {
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%d.lrc"`, songID))
+		}
+		io.WriteString(w, body)
+		return
+	}
+	writeJSON(w, http.StatusOK, lyrics)
+}
+
+// putLyrics carga o reemplaza la letra. Acepta JSON {"text", "language"} o
+// el texto directamente (text/plain, por ejemplo un archivo .lrc). El
+// formato se detecta solo: si alguna línea empieza con [mm:ss] es LRC.
+func (h *SongHandler) putLyrics(w http.ResponseWriter, r *http.Request, user *UserInfo, songID int) {
+	var input struct {
+		Text     string `json:"text"`
+		Language string `json:"language"`
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, models.MaxLyricsBytes*2))
+	if err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.Unmarshal(body, &input); err != nil {
+			http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+			return
+		}
+	} else {
+		input.Text = string(body)
+		input.Language = r.URL.Query().Get("language")
+	}
+
+	text, err := models.NormalizeLyrics(input.Text)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	language := strings.ToLower(strings.TrimSpace(input.Language))
+	if len(language) > 3 {
+		http.Error(w, "El idioma debe ser un código ISO 639-2 de 3 letras", http.StatusBadRequest)
+		return
+	}
+
+	song, err := loadSongMetadata(h.db, songID, false)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error al obtener la canción", http.StatusInternalServerError)
+		return
+	}
+
+	format := models.DetectLyricsFormat(text)
+	if err := database.SaveLyrics(songID, text, format, language, models.LyricsEdit, user.ID); err != nil {
+		log.Printf("Error guardando letra de la canción %d: %v", songID, err)
+		http.Error(w, "Error al guardar la letra", http.StatusInternalServerError)
+		return
+	}
+	if err := database.IndexSong(song.ID, song.Title, song.Artist, song.Genre); err != nil {
+		log.Printf("Error indexando canción %d: %v", songID, err)
+	}
+
+	lyrics, _, _, err := loadLyrics(h.db, songID)
+	if err != nil {
+		http.Error(w, "Error al obtener la letra", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, lyrics)
+}
+
+// deleteLyrics borra la letra (por ejemplo una incluida en el archivo que
+// no corresponde a la canción)
+func (h *SongHandler) deleteLyrics(w http.ResponseWriter, songID int) {
+	song, err := loadSongMetadata(h.db, songID, false)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error al obtener la canción", http.StatusInternalServerError)
+		return
+	}
+
+	err = database.DeleteLyrics(songID)
+	if err == sql.ErrNoRows {
+		http.Error(w, models.ErrNoLyrics.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error borrando letra de la canción %d: %v", songID, err)
+		http.Error(w, "Error al borrar la letra", http.StatusInternalServerError)
+		return
+	}
+	if err := database.IndexSong(song.ID, song.Title, song.Artist, song.Genre); err != nil {
+		log.Printf("Error indexando canción %d: %v", songID, err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadLyrics devuelve la letra en líneas junto con el texto y formato guardados
+func loadLyrics(q queryer, songID int) (*models.Lyrics, string, string, error) {
+	lyrics := models.Lyrics{SongID: songID}
+	var body, format string
+	var language, source sql.NullString
+	err := q.QueryRow(`
+		SELECT l.body, l.format, l.language, l.updated_at, s.lyrics_source
+		FROM song_lyrics l
+		JOIN songs s ON s.id = l.song_id AND s.deleted_at IS NULL
+		WHERE l.song_id = ?`, songID,
+	).Scan(&body, &format, &language, &lyrics.UpdatedAt, &source)
+	if err != nil {
+		return nil, "", "", err
+	}
+	lyrics.Synced = format == models.LyricsLRC
+	lyrics.Language = language.String
+	lyrics.Source = source.String
+	lyrics.Lines = models.ParseLyrics(body, format)
+	return &lyrics, body, format, nil
+}
@@ -0,0 +1,365 @@
+// Backend/Handlers/metadata.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Funciones de la clase songs para editar los datos de una
+cancion (titulo, artista y genero) con historial de cambios
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"PROYECTO_STREAMING/Backend/database"
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// SongRoutes atiende las rutas de una canción bajo /api/songs/{id}:
+// GET, PATCH y DELETE /{id}, GET /{id}/revisions, GET /{id}/waveform,
+// GET, PUT y DELETE /{id}/lyrics y POST /{id}/revisions/{version}/revert.
+// Editar requiere el rol curator o admin; los datos de la canción además
+// requieren la cabecera If-Match con el ETag recibido al consultarla.
+func (h *SongHandler) SongRoutes(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/songs")
+	if len(segs) == 0 {
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+		return
+	}
+	songID, err := strconv.Atoi(segs[0])
+	if err != nil {
+		http.Error(w, "ID de canción inválido", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(segs) == 1 && r.Method == http.MethodGet:
+		h.getSong(w, r, songID)
+	case len(segs) == 1 && r.Method == http.MethodPatch:
+		if requireCurator(w, user) {
+			h.patchSong(w, r, user, songID)
+		}
+	case len(segs) == 1 && r.Method == http.MethodDelete:
+		if user.Role != "admin" {
+			http.Error(w, "No tienes permisos de administrador", http.StatusForbidden)
+			return
+		}
+		h.deleteSong(w, user, songID)
+	case len(segs) == 2 && segs[1] == "revisions" && r.Method == http.MethodGet:
+		h.revisions(w, songID)
+	case len(segs) == 2 && segs[1] == "waveform" && r.Method == http.MethodGet:
+		h.waveform(w, r, songID)
+	case len(segs) == 2 && segs[1] == "lyrics" && r.Method == http.MethodGet:
+		h.lyrics(w, r, songID)
+	case len(segs) == 2 && segs[1] == "lyrics" && r.Method == http.MethodPut:
+		if requireCurator(w, user) {
+			h.putLyrics(w, r, user, songID)
+		}
+	case len(segs) == 2 && segs[1] == "lyrics" && r.Method == http.MethodDelete:
+		if requireCurator(w, user) {
+			h.deleteLyrics(w, songID)
+		}
+	case len(segs) == 4 && segs[1] == "revisions" && segs[3] == "revert" && r.Method == http.MethodPost:
+		if requireCurator(w, user) {
+			h.revert(w, r, user, songID, segs[2])
+		}
+	case len(segs) <= 2:
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+// requireCurator responde 403 si el usuario no puede editar el catálogo
+func requireCurator(w http.ResponseWriter, user *UserInfo) bool {
+	if user.Role != "curator" && user.Role != "admin" {
+		http.Error(w, "Solo los curadores pueden editar canciones", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (h *SongHandler) getSong(w http.ResponseWriter, r *http.Request, songID int) {
+	song, err := loadSongMetadata(h.db, songID, false)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error al obtener la canción", http.StatusInternalServerError)
+		return
+	}
+
+	etag := models.SongETag(song.ID, song.Version)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, song)
+}
+
+// patchSong cambia los campos enviados; los que no vienen se dejan igual.
+// La versión esperada se toma de If-Match o, si no viene, del campo version.
+func (h *SongHandler) patchSong(w http.ResponseWriter, r *http.Request, user *UserInfo, songID int) {
+	var input struct {
+		Title   *string `json:"title"`
+		Artist  *string `json:"artist"`
+		Genre   *string `json:"genre"`
+		Version int     `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	expected := input.Version
+	if header := r.Header.Get("If-Match"); header != "" {
+		version, err := models.ParseSongETag(songID, header)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		expected = version
+	}
+	if expected == 0 {
+		http.Error(w, "Se requiere la cabecera If-Match con la versión de la canción", http.StatusPreconditionRequired)
+		return
+	}
+
+	var title, artist, genre string
+	for _, field := range []struct {
+		value *string
+		dest  *string
+	}{{input.Title, &title}, {input.Artist, &artist}, {input.Genre, &genre}} {
+		if field.value == nil {
+			continue
+		}
+		if *field.dest = strings.TrimSpace(*field.value); *field.dest == "" {
+			http.Error(w, "El título, el artista y el género no pueden quedar vacíos", http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.saveMetadata(w, user, songID, expected, nil, func(song *models.Song) error {
+		return song.UpdateMetadata(title, artist, genre)
+	})
+}
+
+// revert vuelve a los datos de una versión anterior. Crea una versión
+// nueva, así que el historial nunca se pierde. Igual que al editar, la
+// versión esperada se toma de If-Match o, si no viene, del campo version.
+func (h *SongHandler) revert(w http.ResponseWriter, r *http.Request, user *UserInfo, songID int, rawVersion string) {
+	target, err := strconv.Atoi(rawVersion)
+	if err != nil {
+		http.Error(w, "Versión inválida", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		Version int `json:"version"`
+	}
+	// El cuerpo es opcional si viene If-Match
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && err != io.EOF {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	expected := input.Version
+	if header := r.Header.Get("If-Match"); header != "" {
+		if expected, err = models.ParseSongETag(songID, header); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if expected == 0 {
+		http.Error(w, "Se requiere la cabecera If-Match con la versión de la canción", http.StatusPreconditionRequired)
+		return
+	}
+
+	var revision models.SongRevision
+	err = h.db.QueryRow(
+		"SELECT title, artist, genre FROM song_revisions WHERE song_id = ? AND version = ?",
+		songID, target,
+	).Scan(&revision.Title, &revision.Artist, &revision.Genre)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Versión no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error al obtener la versión", http.StatusInternalServerError)
+		return
+	}
+
+	h.saveMetadata(w, user, songID, expected, &target, func(song *models.Song) error {
+		song.Title, song.Artist, song.Genre = revision.Title, revision.Artist, revision.Genre
+		return nil
+	})
+}
+
+// saveMetadata aplica el cambio sobre la versión actual de la canción y
+// registra la revisión. Si la canción ya no está en la versión expected
+// responde 412 con el ETag actual.
+func (h *SongHandler) saveMetadata(w http.ResponseWriter, user *UserInfo, songID, expected int, revertedFrom *int, apply func(*models.Song) error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al actualizar la canción", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	current, err := loadSongMetadata(tx, songID, true)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error al obtener la canción", http.StatusInternalServerError)
+		return
+	}
+	if expected != current.Version {
+		w.Header().Set("ETag", models.SongETag(songID, current.Version))
+		http.Error(w, "La canción fue modificada por otra persona, vuelva a cargarla", http.StatusPreconditionFailed)
+		return
+	}
+
+	before := models.Song{ID: current.ID, Title: current.Title, Artist: current.Artist, Genre: current.Genre}
+	after := before
+	if err := apply(&after); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changes := models.MetadataChanges(before, after)
+	if len(changes) == 0 {
+		w.Header().Set("ETag", models.SongETag(songID, current.Version))
+		writeJSON(w, http.StatusOK, current)
+		return
+	}
+
+	// Las canciones que nunca se editaron no tienen revisiones: se guarda
+	// primero la versión actual para poder volver a ella
+	if _, err := tx.Exec(`
+		INSERT IGNORE INTO song_revisions (song_id, version, title, artist, genre)
+		VALUES (?, ?, ?, ?, ?)`,
+		songID, current.Version, current.Title, current.Artist, current.Genre,
+	); err != nil {
+		log.Printf("Error guardando versión inicial de la canción %d: %v", songID, err)
+		http.Error(w, "Error al actualizar la canción", http.StatusInternalServerError)
+		return
+	}
+
+	version := current.Version + 1
+	if _, err := tx.Exec(
+		"UPDATE songs SET title = ?, artist = ?, genre = ?, version = ? WHERE id = ?",
+		after.Title, after.Artist, after.Genre, version, songID,
+	); err != nil {
+		log.Printf("Error actualizando canción %d: %v", songID, err)
+		http.Error(w, "Error al actualizar la canción", http.StatusInternalServerError)
+		return
+	}
+
+	rawChanges, _ := json.Marshal(changes)
+	if _, err := tx.Exec(`
+		INSERT INTO song_revisions (song_id, version, title, artist, genre, changes, reverted_from, changed_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		songID, version, after.Title, after.Artist, after.Genre, rawChanges, revertedFrom, user.ID,
+	); err != nil {
+		log.Printf("Error guardando revisión de la canción %d: %v", songID, err)
+		http.Error(w, "Error al actualizar la canción", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al actualizar la canción", http.StatusInternalServerError)
+		return
+	}
+
+	// La búsqueda y las sugerencias deben reflejar los datos nuevos
+	if err := database.IndexSong(songID, after.Title, after.Artist, after.Genre); err != nil {
+		log.Printf("Error indexando canción %d: %v", songID, err)
+	}
+	h.index.Upsert(songID, after.Title, after.Artist)
+
+	current.Title, current.Artist, current.Genre, current.Version = after.Title, after.Artist, after.Genre, version
+	w.Header().Set("ETag", models.SongETag(songID, version))
+	writeJSON(w, http.StatusOK, current)
+}
+
+// revisions devuelve el historial de cambios de la canción, el más reciente primero
+func (h *SongHandler) revisions(w http.ResponseWriter, songID int) {
+	var exists bool
+	if err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM songs WHERE id = ?)", songID).Scan(&exists); err != nil {
+		log.Printf("Error verificando canción %d: %v", songID, err)
+		http.Error(w, "Error al obtener el historial", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT r.id, r.song_id, r.version, r.title, r.artist, r.genre, r.changes,
+		       r.reverted_from, r.changed_by, COALESCE(u.name, ''), r.created_at
+		FROM song_revisions r
+		LEFT JOIN users u ON u.id = r.changed_by
+		WHERE r.song_id = ?
+		ORDER BY r.version DESC`, songID)
+	if err != nil {
+		log.Printf("Error obteniendo revisiones de la canción %d: %v", songID, err)
+		http.Error(w, "Error al obtener el historial", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	revisions := []models.SongRevision{}
+	for rows.Next() {
+		var rev models.SongRevision
+		var changes []byte
+		var revertedFrom, changedBy sql.NullInt64
+		if err := rows.Scan(&rev.ID, &rev.SongID, &rev.Version, &rev.Title, &rev.Artist, &rev.Genre, &changes,
+			&revertedFrom, &changedBy, &rev.ChangedName, &rev.CreatedAt); err != nil {
+			http.Error(w, "Error al leer el historial", http.StatusInternalServerError)
+			return
+		}
+		if len(changes) > 0 {
+			json.Unmarshal(changes, &rev.Changes)
+		}
+		if revertedFrom.Valid {
+			v := int(revertedFrom.Int64)
+			rev.RevertedFrom = &v
+		}
+		if changedBy.Valid {
+			id := int(changedBy.Int64)
+			rev.ChangedBy = &id
+		}
+		revisions = append(revisions, rev)
+	}
+
+	writeJSON(w, http.StatusOK, revisions)
+}
+
+// loadSongMetadata lee la canción con su versión; forUpdate la bloquea
+// hasta el final de la transacción
+func loadSongMetadata(q queryer, songID int, forUpdate bool) (*Song, error) {
+	query := "SELECT id, title, artist, genre, file_size, file_path, version FROM songs WHERE id = ? AND deleted_at IS NULL"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var song Song
+	err := q.QueryRow(query, songID).
+		Scan(&song.ID, &song.Title, &song.Artist, &song.Genre, &song.FileSize, &song.FilePath, &song.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &song, nil
+}
@@ -0,0 +1,135 @@
+// Backend/Handlers/notifications.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase notifications, con sus respectivas
+funciones para consultar y marcar como leidos los avisos del usuario
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+const maxNotifications = 50 // Avisos que se devuelven por consulta
+
+type NotificationHandler struct {
+	db *sql.DB
+}
+
+func NewNotificationHandler(db *sql.DB) *NotificationHandler {
+	return &NotificationHandler{db: db}
+}
+
+// NotificationRoutes atiende /api/notifications y /api/notifications/{id}/read.
+// Con ?unread=1 solo se devuelven los avisos sin leer.
+func (h *NotificationHandler) NotificationRoutes(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/notifications")
+	switch {
+	case len(segs) == 0 && r.Method == http.MethodGet:
+		h.list(w, r, user)
+	case len(segs) == 2 && segs[1] == "read" && r.Method == http.MethodPost:
+		id, err := strconv.Atoi(segs[0])
+		if err != nil {
+			http.Error(w, "ID de aviso inválido", http.StatusBadRequest)
+			return
+		}
+		result, err := h.db.Exec(
+			"UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = ? AND user_id = ?",
+			id, user.ID,
+		)
+		if err != nil {
+			http.Error(w, "Error al actualizar el aviso", http.StatusInternalServerError)
+			return
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			var exists bool
+			h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM notifications WHERE id = ? AND user_id = ?)", id, user.ID).Scan(&exists)
+			if !exists {
+				http.Error(w, "Aviso no encontrado", http.StatusNotFound)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+func (h *NotificationHandler) list(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	query := "SELECT id, user_id, type, message, data, read_at, created_at FROM notifications WHERE user_id = ?"
+	if r.URL.Query().Get("unread") == "1" {
+		query += " AND read_at IS NULL"
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+
+	rows, err := h.db.Query(query, user.ID, maxNotifications)
+	if err != nil {
+		log.Printf("Error obteniendo avisos del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al obtener los avisos", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		var data []byte
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Message, &data, &readAt, &n.CreatedAt); err != nil {
+			http.Error(w, "Error al leer los avisos", http.StatusInternalServerError)
+			return
+		}
+		if len(data) > 0 {
+			n.Data = data
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+
+	writeJSON(w, http.StatusOK, notifications)
+}
+
+// createNotification guarda un aviso para el usuario. Se publica con
+// publishNotification una vez confirmada la transacción.
+func createNotification(q queryer, userID int, kind, message string, data interface{}) (*models.Notification, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	result, err := q.Exec(
+		"INSERT INTO notifications (user_id, type, message, data) VALUES (?, ?, ?, ?)",
+		userID, kind, message, raw,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := result.LastInsertId()
+
+	n := &models.Notification{ID: int(id), UserID: userID, Type: kind, Message: message, Data: raw}
+	err = q.QueryRow("SELECT created_at FROM notifications WHERE id = ?", id).Scan(&n.CreatedAt)
+	return n, err
+}
+
+// publishNotification avisa en tiempo real a los dispositivos conectados del usuario
+func publishNotification(events *models.EventHub, n *models.Notification) {
+	if events == nil || n == nil {
+		return
+	}
+	data, _ := json.Marshal(n)
+	events.Publish(n.UserID, models.Event{Type: models.EventNotification, Data: data})
+}
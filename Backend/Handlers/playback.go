@@ -0,0 +1,324 @@
+// Backend/Handlers/playback.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase playback, con sus respectivas
+funciones para el manejo de rutas de posicion de reproduccion
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/database"
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type PlaybackHandler struct {
+	db    *sql.DB
+	rules *models.RulesEngine
+}
+
+type HeartbeatRequest struct {
+	SongID     int   `json:"song_id"`
+	PositionMs int   `json:"position_ms"`
+	DurationMs int   `json:"duration_ms"`
+	ReportedAt int64 `json:"reported_at"` // Milisegundos Unix del cliente (opcional)
+	Paused     bool  `json:"paused"`      // El reporte se envía al pausar
+}
+
+// ResumeResponse indica desde dónde continuar la reproducción
+type ResumeResponse struct {
+	models.PlaybackPosition
+	ResumeAtMs int  `json:"resume_at_ms"`
+	Song       Song `json:"song"`
+}
+
+func NewPlaybackHandler(db *sql.DB, rules *models.RulesEngine) *PlaybackHandler {
+	return &PlaybackHandler{db: db, rules: rules}
+}
+
+// Heartbeat recibe periódicamente la posición del cabezal de un cliente
+func (h *PlaybackHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	deviceID, ok := requireSessionDevice(w, user)
+	if !ok {
+		return
+	}
+
+	var req HeartbeatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	// Se usa la hora del cliente para ordenar reportes de varios dispositivos,
+	// sin aceptar horas futuras respecto al servidor
+	now := time.Now()
+	reportedAt := now
+	if req.ReportedAt > 0 {
+		if t := time.UnixMilli(req.ReportedAt); t.Before(now) {
+			reportedAt = t
+		}
+	}
+
+	position := models.PlaybackPosition{
+		UserID:     user.ID,
+		SongID:     req.SongID,
+		PositionMs: req.PositionMs,
+		DurationMs: req.DurationMs,
+		DeviceID:   deviceID,
+		ReportedAt: reportedAt,
+	}
+	if err := position.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Un salto de posición es adelantar o retroceder; en la radio depende del
+	// plan y, si no se permite, la posición no se guarda
+	if v, err := h.checkSeek(user, &position); err != nil {
+		log.Printf("Error evaluando reglas del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al evaluar las reglas de reproducción", http.StatusInternalServerError)
+		return
+	} else if v != nil {
+		writeRuleViolation(w, v)
+		return
+	}
+
+	if err := savePosition(h.db, &position); err != nil {
+		log.Printf("Error guardando posición del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al guardar la posición", http.StatusInternalServerError)
+		return
+	}
+	if err := touchDevice(h.db, user.ID, position.DeviceID, !req.Paused); err != nil {
+		log.Printf("Error actualizando dispositivo %s: %v", position.DeviceID, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Resume devuelve la canción actual del usuario y la posición desde la que
+// continuar ("continuar donde lo dejaste")
+func (h *PlaybackHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	query := `
+		SELECT p.user_id, p.song_id, p.position_ms, p.duration_ms, p.device_id, p.reported_at,
+		       s.id, s.title, s.artist, s.genre, s.file_size, s.file_path
+		FROM playback_positions p
+		JOIN songs s ON s.id = p.song_id AND s.deleted_at IS NULL
+		WHERE p.user_id = ?`
+	args := []interface{}{user.ID}
+
+	if songID := r.URL.Query().Get("song_id"); songID != "" {
+		id, err := strconv.Atoi(songID)
+		if err != nil {
+			http.Error(w, "ID de canción inválido", http.StatusBadRequest)
+			return
+		}
+		query += " AND p.song_id = ?"
+		args = append(args, id)
+	}
+	query += " ORDER BY p.reported_at DESC LIMIT 1"
+
+	var resp ResumeResponse
+	err := h.db.QueryRow(query, args...).Scan(
+		&resp.UserID, &resp.SongID, &resp.PositionMs, &resp.DurationMs, &resp.DeviceID, &resp.ReportedAt,
+		&resp.Song.ID, &resp.Song.Title, &resp.Song.Artist, &resp.Song.Genre, &resp.Song.FileSize, &resp.Song.FilePath,
+	)
+	if err == sql.ErrNoRows {
+		http.Error(w, "No hay reproducciones para continuar", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error obteniendo posición del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al obtener la posición", http.StatusInternalServerError)
+		return
+	}
+	resp.ResumeAtMs = resp.PlaybackPosition.ResumeAt()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// savePosition guarda la posición solo si es más reciente que la almacenada,
+// de modo que un reporte atrasado de otro dispositivo no la sobrescribe.
+// reported_at se asigna al final porque MySQL evalúa las asignaciones en orden.
+func savePosition(q queryer, p *models.PlaybackPosition) error {
+	_, err := q.Exec(`
+		INSERT INTO playback_positions (user_id, song_id, position_ms, duration_ms, device_id, reported_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			position_ms = IF(VALUES(reported_at) >= reported_at, VALUES(position_ms), position_ms),
+			duration_ms = IF(VALUES(reported_at) >= reported_at, VALUES(duration_ms), duration_ms),
+			device_id   = IF(VALUES(reported_at) >= reported_at, VALUES(device_id), device_id),
+			reported_at = GREATEST(reported_at, VALUES(reported_at))`,
+		p.UserID, p.SongID, p.PositionMs, p.DurationMs, p.DeviceID, p.ReportedAt,
+	)
+	return err
+}
+
+type PlaybackStartRequest struct {
+	SongID int    `json:"song_id"`
+	Source string `json:"source"` // radio, queue, ...
+}
+
+type PlaybackFinishRequest struct {
+	PlaybackID int  `json:"playback_id"`
+	Duration   int  `json:"duration"` // Segundos escuchados
+	Completed  bool `json:"completed"`
+}
+
+// Start registra en el historial el inicio de la reproducción de una canción
+func (h *PlaybackHandler) Start(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var req PlaybackStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SongID <= 0 {
+		http.Error(w, "ID de canción inválido", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Source) > models.MaxSourceLength {
+		http.Error(w, "Origen de la reproducción inválido", http.StatusBadRequest)
+		return
+	}
+	if !h.enforce(w, user, models.ActionStart, req.Source) {
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al registrar la reproducción", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(
+		"INSERT INTO playbacks (user_id, song_id, played_at, status, source) SELECT ?, id, ?, 'playing', ? FROM songs WHERE id = ? AND deleted_at IS NULL",
+		user.ID, now, req.Source, req.SongID,
+	)
+	if err != nil {
+		log.Printf("Error registrando reproducción del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al registrar la reproducción", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	if err := database.RecordListening(tx, user.ID, req.SongID, now, 1, 0, false); err != nil {
+		log.Printf("Error actualizando estadísticas del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al registrar la reproducción", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al registrar la reproducción", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]int64{"playback_id": id})
+}
+
+// Finish cierra una reproducción con el tiempo escuchado
+func (h *PlaybackHandler) Finish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var req PlaybackFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlaybackID <= 0 || req.Duration < 0 {
+		http.Error(w, "Datos de reproducción inválidos", http.StatusBadRequest)
+		return
+	}
+
+	status := "paused"
+	if req.Completed {
+		status = "completed"
+	}
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al registrar la reproducción", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var songID, previous int
+	var playedAt time.Time
+	err = tx.QueryRow(
+		"SELECT song_id, played_at, duration FROM playbacks WHERE id = ? AND user_id = ? FOR UPDATE",
+		req.PlaybackID, user.ID,
+	).Scan(&songID, &playedAt, &previous)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Reproducción no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error cerrando reproducción %d: %v", req.PlaybackID, err)
+		http.Error(w, "Error al registrar la reproducción", http.StatusInternalServerError)
+		return
+	}
+
+	// Si el cliente reenvía el cierre solo se suma lo escuchado de más
+	duration := req.Duration
+	if duration < previous {
+		duration = previous
+	}
+	if _, err := tx.Exec(
+		"UPDATE playbacks SET status = ?, duration = ? WHERE id = ?",
+		status, duration, req.PlaybackID,
+	); err != nil {
+		log.Printf("Error cerrando reproducción %d: %v", req.PlaybackID, err)
+		http.Error(w, "Error al registrar la reproducción", http.StatusInternalServerError)
+		return
+	}
+	if err := database.RecordListening(tx, user.ID, songID, playedAt.In(time.Local), 0, duration-previous, false); err != nil {
+		log.Printf("Error actualizando estadísticas del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al registrar la reproducción", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al registrar la reproducción", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
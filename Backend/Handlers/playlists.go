@@ -0,0 +1,723 @@
+// Backend/Handlers/playlists.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase playlists, con sus respectivas
+funciones para el manejo de rutas
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type PlaylistHandler struct {
+	db *sql.DB
+}
+
+func NewPlaylistHandler(db *sql.DB) *PlaylistHandler {
+	return &PlaylistHandler{db: db}
+}
+
+// Playlists atiende /api/playlists: listado (GET) y creación (POST)
+func (h *PlaylistHandler) Playlists(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.listPlaylists(w, user)
+	case http.MethodPost:
+		h.createPlaylist(w, r, user)
+	default:
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+	}
+}
+
+// PlaylistRoutes atiende todas las rutas bajo /api/playlists/
+func (h *PlaylistHandler) PlaylistRoutes(w http.ResponseWriter, r *http.Request) {
+	segs := pathSegments(r.URL.Path, "/api/playlists/")
+	if len(segs) == 0 {
+		h.Playlists(w, r)
+		return
+	}
+
+	// Las playlists compartidas por enlace no requieren pertenecer a ellas
+	if segs[0] == "shared" && len(segs) == 2 && r.Method == http.MethodGet {
+		h.getSharedPlaylist(w, segs[1])
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	playlistID, err := strconv.Atoi(segs[0])
+	if err != nil {
+		http.Error(w, "ID de playlist inválido", http.StatusBadRequest)
+		return
+	}
+
+	playlist, err := loadPlaylist(h.db, playlistID, true)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Playlist no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error cargando playlist %d: %v", playlistID, err)
+		http.Error(w, "Error al obtener la playlist", http.StatusInternalServerError)
+		return
+	}
+	if !playlist.CanView(user.ID) {
+		http.Error(w, "Playlist no encontrada", http.StatusNotFound)
+		return
+	}
+	if playlist.OwnerID != user.ID {
+		playlist.ShareToken = ""
+	}
+
+	switch {
+	case len(segs) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, playlist)
+	case len(segs) == 1 && r.Method == http.MethodPut:
+		h.updatePlaylist(w, r, user, playlist)
+	case len(segs) == 1 && r.Method == http.MethodDelete:
+		h.deletePlaylist(w, user, playlist)
+	case len(segs) == 2 && segs[1] == "songs" && r.Method == http.MethodPost:
+		h.addSong(w, r, user, playlist)
+	case len(segs) == 3 && segs[1] == "songs" && r.Method == http.MethodDelete:
+		h.removeEntry(w, user, playlist, segs[2])
+	case len(segs) == 4 && segs[1] == "songs" && segs[3] == "move" && r.Method == http.MethodPut:
+		h.moveEntry(w, r, user, playlist, segs[2])
+	case len(segs) == 2 && segs[1] == "share" && (r.Method == http.MethodPost || r.Method == http.MethodDelete):
+		h.share(w, r, user, playlist)
+	case len(segs) == 2 && segs[1] == "collaborators" && r.Method == http.MethodPost:
+		h.addCollaborator(w, r, user, playlist)
+	case len(segs) == 3 && segs[1] == "collaborators" && r.Method == http.MethodDelete:
+		h.removeCollaborator(w, user, playlist, segs[2])
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+func (h *PlaylistHandler) listPlaylists(w http.ResponseWriter, user *UserInfo) {
+	rows, err := h.db.Query(`
+		SELECT DISTINCT p.id
+		FROM playlists p
+		LEFT JOIN playlist_collaborators c ON c.playlist_id = p.id
+		WHERE p.user_id = ? OR c.user_id = ?
+		ORDER BY p.id`, user.ID, user.ID)
+	if err != nil {
+		http.Error(w, "Error al obtener las playlists", http.StatusInternalServerError)
+		return
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			http.Error(w, "Error al procesar las playlists", http.StatusInternalServerError)
+			return
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	playlists := []*models.Playlist{}
+	for _, id := range ids {
+		playlist, err := loadPlaylist(h.db, id, false)
+		if err != nil {
+			http.Error(w, "Error al obtener las playlists", http.StatusInternalServerError)
+			return
+		}
+		if playlist.OwnerID != user.ID {
+			playlist.ShareToken = ""
+		}
+		playlists = append(playlists, playlist)
+	}
+
+	writeJSON(w, http.StatusOK, playlists)
+}
+
+func (h *PlaylistHandler) createPlaylist(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	var input struct {
+		Name          string `json:"name"`
+		IsPublic      bool   `json:"is_public"`
+		Collaborative bool   `json:"collaborative"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	name, err := models.ValidatePlaylistName(input.Name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al crear la playlist", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	quota, err := loadQuota(tx, user, true)
+	if err != nil {
+		http.Error(w, "Error al obtener la cuota", http.StatusInternalServerError)
+		return
+	}
+	if err := quota.CheckPlaylist(); err != nil {
+		writeQuotaError(w, err)
+		return
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO playlists (user_id, name, is_public, collaborative) VALUES (?, ?, ?, ?)",
+		user.ID, name, input.IsPublic, input.Collaborative,
+	)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		http.Error(w, "Error al crear la playlist", http.StatusInternalServerError)
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	playlist, err := loadPlaylist(h.db, int(id), true)
+	if err != nil {
+		http.Error(w, "Error al obtener la playlist", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, playlist)
+}
+
+// updatePlaylist permite al dueño renombrar la playlist o cambiar su visibilidad
+func (h *PlaylistHandler) updatePlaylist(w http.ResponseWriter, r *http.Request, user *UserInfo, playlist *models.Playlist) {
+	if playlist.OwnerID != user.ID {
+		http.Error(w, "Solo el dueño puede modificar la playlist", http.StatusForbidden)
+		return
+	}
+
+	var input struct {
+		Name          *string `json:"name"`
+		IsPublic      *bool   `json:"is_public"`
+		Collaborative *bool   `json:"collaborative"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	if input.Name != nil {
+		name, err := models.ValidatePlaylistName(*input.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		playlist.Name = name
+	}
+	if input.IsPublic != nil {
+		playlist.IsPublic = *input.IsPublic
+	}
+	if input.Collaborative != nil {
+		playlist.Collaborative = *input.Collaborative
+	}
+
+	_, err := h.db.Exec(
+		"UPDATE playlists SET name = ?, is_public = ?, collaborative = ? WHERE id = ?",
+		playlist.Name, playlist.IsPublic, playlist.Collaborative, playlist.ID,
+	)
+	if err != nil {
+		http.Error(w, "Error al actualizar la playlist", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, playlist)
+}
+
+func (h *PlaylistHandler) deletePlaylist(w http.ResponseWriter, user *UserInfo, playlist *models.Playlist) {
+	if playlist.OwnerID != user.ID {
+		http.Error(w, "Solo el dueño puede eliminar la playlist", http.StatusForbidden)
+		return
+	}
+
+	// Las entradas y colaboradores se eliminan en cascada
+	if _, err := h.db.Exec("DELETE FROM playlists WHERE id = ?", playlist.ID); err != nil {
+		http.Error(w, "Error al eliminar la playlist", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Playlist eliminada correctamente"))
+}
+
+// addSong agrega una canción al final de la playlist o detrás de la entrada
+// indicada en after_entry_id (0 para insertarla al inicio)
+func (h *PlaylistHandler) addSong(w http.ResponseWriter, r *http.Request, user *UserInfo, playlist *models.Playlist) {
+	if !playlist.CanEdit(user.ID) {
+		http.Error(w, "No tienes permisos para editar esta playlist", http.StatusForbidden)
+		return
+	}
+
+	var input struct {
+		SongID       int  `json:"song_id"`
+		AfterEntryID *int `json:"after_entry_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	if err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM songs WHERE id = ? AND deleted_at IS NULL)", input.SongID).Scan(&exists); err != nil {
+		http.Error(w, "Error al verificar la canción", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al agregar la canción", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := lockPlaylist(tx, playlist.ID); err != nil {
+		http.Error(w, "Error al agregar la canción", http.StatusInternalServerError)
+		return
+	}
+
+	position, err := positionAfter(tx, playlist.ID, input.AfterEntryID, 0)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Entrada de referencia no encontrada", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error calculando posición en playlist %d: %v", playlist.ID, err)
+		http.Error(w, "Error al agregar la canción", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO playlist_songs (playlist_id, song_id, position, added_by) VALUES (?, ?, ?, ?)",
+		playlist.ID, input.SongID, position, user.ID,
+	)
+	if err != nil {
+		http.Error(w, "Error al agregar la canción", http.StatusInternalServerError)
+		return
+	}
+	if _, err := tx.Exec("UPDATE playlists SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", playlist.ID); err != nil {
+		http.Error(w, "Error al agregar la canción", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al agregar la canción", http.StatusInternalServerError)
+		return
+	}
+
+	entryID, _ := result.LastInsertId()
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":       entryID,
+		"position": position,
+		"message":  "Canción agregada a la playlist",
+	})
+}
+
+func (h *PlaylistHandler) removeEntry(w http.ResponseWriter, user *UserInfo, playlist *models.Playlist, rawEntryID string) {
+	if !playlist.CanEdit(user.ID) {
+		http.Error(w, "No tienes permisos para editar esta playlist", http.StatusForbidden)
+		return
+	}
+
+	entryID, err := strconv.Atoi(rawEntryID)
+	if err != nil {
+		http.Error(w, "ID de entrada inválido", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.db.Exec("DELETE FROM playlist_songs WHERE id = ? AND playlist_id = ?", entryID, playlist.ID)
+	if err != nil {
+		http.Error(w, "Error al quitar la canción", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "Entrada no encontrada", http.StatusNotFound)
+		return
+	}
+	h.db.Exec("UPDATE playlists SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", playlist.ID)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Canción quitada de la playlist"))
+}
+
+// moveEntry coloca una entrada detrás de after_entry_id (0 para moverla al inicio).
+// Al referenciar entradas por su ID y no por índice, dos ediciones simultáneas
+// no se pisan: cada una solo cambia la posición de su propia fila.
+func (h *PlaylistHandler) moveEntry(w http.ResponseWriter, r *http.Request, user *UserInfo, playlist *models.Playlist, rawEntryID string) {
+	if !playlist.CanEdit(user.ID) {
+		http.Error(w, "No tienes permisos para editar esta playlist", http.StatusForbidden)
+		return
+	}
+
+	entryID, err := strconv.Atoi(rawEntryID)
+	if err != nil {
+		http.Error(w, "ID de entrada inválido", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		AfterEntryID int `json:"after_entry_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	if input.AfterEntryID == entryID {
+		http.Error(w, "Una entrada no puede moverse detrás de sí misma", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al mover la canción", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := lockPlaylist(tx, playlist.ID); err != nil {
+		http.Error(w, "Error al mover la canción", http.StatusInternalServerError)
+		return
+	}
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM playlist_songs WHERE id = ? AND playlist_id = ?)", entryID, playlist.ID).Scan(&exists)
+	if err != nil {
+		http.Error(w, "Error al mover la canción", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Entrada no encontrada", http.StatusNotFound)
+		return
+	}
+
+	position, err := positionAfter(tx, playlist.ID, &input.AfterEntryID, entryID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Entrada de referencia no encontrada", http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.Printf("Error calculando posición en playlist %d: %v", playlist.ID, err)
+		http.Error(w, "Error al mover la canción", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE playlist_songs SET position = ? WHERE id = ?", position, entryID); err != nil {
+		http.Error(w, "Error al mover la canción", http.StatusInternalServerError)
+		return
+	}
+	if _, err := tx.Exec("UPDATE playlists SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", playlist.ID); err != nil {
+		http.Error(w, "Error al mover la canción", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al mover la canción", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":       entryID,
+		"position": position,
+	})
+}
+
+// share genera (POST) o revoca (DELETE) el enlace para compartir la playlist
+func (h *PlaylistHandler) share(w http.ResponseWriter, r *http.Request, user *UserInfo, playlist *models.Playlist) {
+	if playlist.OwnerID != user.ID {
+		http.Error(w, "Solo el dueño puede compartir la playlist", http.StatusForbidden)
+		return
+	}
+
+	var token interface{}
+	if r.Method == http.MethodPost {
+		token = models.NewShareToken()
+	}
+
+	if _, err := h.db.Exec("UPDATE playlists SET share_token = ? WHERE id = ?", token, playlist.ID); err != nil {
+		http.Error(w, "Error al compartir la playlist", http.StatusInternalServerError)
+		return
+	}
+
+	if token == nil {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Enlace revocado correctamente"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"share_token": token,
+		"url":         "/api/playlists/shared/" + token.(string),
+	})
+}
+
+func (h *PlaylistHandler) getSharedPlaylist(w http.ResponseWriter, token string) {
+	var playlistID int
+	err := h.db.QueryRow("SELECT id FROM playlists WHERE share_token = ?", token).Scan(&playlistID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Playlist no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error al obtener la playlist", http.StatusInternalServerError)
+		return
+	}
+
+	playlist, err := loadPlaylist(h.db, playlistID, true)
+	if err != nil {
+		http.Error(w, "Error al obtener la playlist", http.StatusInternalServerError)
+		return
+	}
+	playlist.ShareToken = ""
+
+	writeJSON(w, http.StatusOK, playlist)
+}
+
+// addCollaborator invita a un usuario (por email) como editor de la playlist
+func (h *PlaylistHandler) addCollaborator(w http.ResponseWriter, r *http.Request, user *UserInfo, playlist *models.Playlist) {
+	if playlist.OwnerID != user.ID {
+		http.Error(w, "Solo el dueño puede invitar colaboradores", http.StatusForbidden)
+		return
+	}
+
+	var input struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	var invitedID int
+	err := h.db.QueryRow("SELECT id FROM users WHERE email = ?", input.Email).Scan(&invitedID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Usuario no encontrado", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error al buscar el usuario", http.StatusInternalServerError)
+		return
+	}
+	if invitedID == user.ID {
+		http.Error(w, "El dueño ya puede editar la playlist", http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.db.Exec(
+		"INSERT IGNORE INTO playlist_collaborators (playlist_id, user_id) VALUES (?, ?)",
+		playlist.ID, invitedID,
+	)
+	if err != nil {
+		http.Error(w, "Error al invitar al colaborador", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"user_id": invitedID,
+		"message": "Colaborador invitado correctamente",
+	})
+}
+
+// removeCollaborator quita a un editor; el propio colaborador también puede abandonar la playlist
+func (h *PlaylistHandler) removeCollaborator(w http.ResponseWriter, user *UserInfo, playlist *models.Playlist, rawUserID string) {
+	collaboratorID, err := strconv.Atoi(rawUserID)
+	if err != nil {
+		http.Error(w, "ID de usuario inválido", http.StatusBadRequest)
+		return
+	}
+	if playlist.OwnerID != user.ID && collaboratorID != user.ID {
+		http.Error(w, "No tienes permisos para quitar colaboradores", http.StatusForbidden)
+		return
+	}
+
+	_, err = h.db.Exec(
+		"DELETE FROM playlist_collaborators WHERE playlist_id = ? AND user_id = ?",
+		playlist.ID, collaboratorID,
+	)
+	if err != nil {
+		http.Error(w, "Error al quitar el colaborador", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Colaborador eliminado correctamente"))
+}
+
+// loadPlaylist lee una playlist junto con sus colaboradores y, opcionalmente, sus canciones
+func loadPlaylist(q queryer, id int, withEntries bool) (*models.Playlist, error) {
+	var playlist models.Playlist
+	var shareToken sql.NullString
+	err := q.QueryRow(`
+		SELECT id, user_id, name, is_public, collaborative, share_token, created_at, updated_at
+		FROM playlists WHERE id = ?`, id,
+	).Scan(&playlist.ID, &playlist.OwnerID, &playlist.Name, &playlist.IsPublic,
+		&playlist.Collaborative, &shareToken, &playlist.CreatedAt, &playlist.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	playlist.ShareToken = shareToken.String
+
+	rows, err := q.Query("SELECT user_id FROM playlist_collaborators WHERE playlist_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	playlist.Collaborators = []int{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		playlist.Collaborators = append(playlist.Collaborators, userID)
+	}
+	rows.Close()
+
+	if !withEntries {
+		return &playlist, nil
+	}
+
+	rows, err = q.Query(`
+		SELECT ps.id, ps.song_id, ps.position, ps.added_by, ps.added_at,
+		       s.title, s.artist, s.genre, s.file_path
+		FROM playlist_songs ps
+		JOIN songs s ON s.id = ps.song_id
+		WHERE ps.playlist_id = ? AND s.deleted_at IS NULL
+		ORDER BY ps.position, ps.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	playlist.Entries = []models.PlaylistEntry{}
+	for rows.Next() {
+		var e models.PlaylistEntry
+		if err := rows.Scan(&e.ID, &e.SongID, &e.Position, &e.AddedBy, &e.AddedAt,
+			&e.Title, &e.Artist, &e.Genre, &e.FilePath); err != nil {
+			return nil, err
+		}
+		playlist.Entries = append(playlist.Entries, e)
+	}
+	return &playlist, rows.Err()
+}
+
+// lockPlaylist bloquea la fila de la playlist hasta el fin de la transacción
+// para que dos ediciones simultáneas calculen posiciones una detrás de otra
+func lockPlaylist(tx *sql.Tx, playlistID int) error {
+	var id int
+	return tx.QueryRow("SELECT id FROM playlists WHERE id = ? FOR UPDATE", playlistID).Scan(&id)
+}
+
+// positionAfter calcula la posición para colocar una entrada detrás de
+// afterEntryID (nil = al final, 0 = al inicio), ignorando la entrada que se
+// está moviendo. Si no queda hueco entre las vecinas renumera la playlist.
+func positionAfter(tx *sql.Tx, playlistID int, afterEntryID *int, movingID int) (int64, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		var prev, next *int64
+
+		switch {
+		case afterEntryID == nil:
+			var last sql.NullInt64
+			err := tx.QueryRow(
+				"SELECT MAX(position) FROM playlist_songs WHERE playlist_id = ? AND id <> ?",
+				playlistID, movingID,
+			).Scan(&last)
+			if err != nil {
+				return 0, err
+			}
+			if last.Valid {
+				prev = &last.Int64
+			}
+		default:
+			if *afterEntryID != 0 {
+				var p int64
+				err := tx.QueryRow(
+					"SELECT position FROM playlist_songs WHERE id = ? AND playlist_id = ?",
+					*afterEntryID, playlistID,
+				).Scan(&p)
+				if err != nil {
+					return 0, err
+				}
+				prev = &p
+			}
+
+			var following sql.NullInt64
+			var err error
+			if prev == nil {
+				err = tx.QueryRow(
+					"SELECT MIN(position) FROM playlist_songs WHERE playlist_id = ? AND id <> ?",
+					playlistID, movingID,
+				).Scan(&following)
+			} else {
+				err = tx.QueryRow(
+					"SELECT MIN(position) FROM playlist_songs WHERE playlist_id = ? AND id <> ? AND position > ?",
+					playlistID, movingID, *prev,
+				).Scan(&following)
+			}
+			if err != nil {
+				return 0, err
+			}
+			if following.Valid {
+				next = &following.Int64
+			}
+		}
+
+		if position, ok := models.PositionBetween(prev, next); ok {
+			return position, nil
+		}
+		if err := rebalancePlaylist(tx, playlistID); err != nil {
+			return 0, err
+		}
+	}
+	return 0, errors.New("no fue posible calcular la posición de la entrada")
+}
+
+// rebalancePlaylist renumera todas las entradas conservando su orden
+func rebalancePlaylist(tx *sql.Tx, playlistID int) error {
+	rows, err := tx.Query(
+		"SELECT id FROM playlist_songs WHERE playlist_id = ? ORDER BY position, id",
+		playlistID,
+	)
+	if err != nil {
+		return err
+	}
+	var entries []models.PlaylistEntry
+	for rows.Next() {
+		var e models.PlaylistEntry
+		if err := rows.Scan(&e.ID); err != nil {
+			rows.Close()
+			return err
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+
+	models.RebalancePositions(entries)
+	for _, e := range entries {
+		if _, err := tx.Exec("UPDATE playlist_songs SET position = ? WHERE id = ?", e.Position, e.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+// Backend/Handlers/queue.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase queue, con sus respectivas
+funciones para el manejo de rutas de la cola de reproduccion
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type QueueHandler struct {
+	db     *sql.DB
+	events *models.EventHub
+}
+
+// QueueSong es una entrada de la cola con los datos de su canción.
+// Unavailable marca una canción que se borró después de encolarla; no se
+// puede reproducir y se quita de la cola en el próximo cambio.
+type QueueSong struct {
+	ItemID      int    `json:"item_id"`
+	SongID      int    `json:"song_id"`
+	Title       string `json:"title"`
+	Artist      string `json:"artist"`
+	Genre       string `json:"genre"`
+	FilePath    string `json:"file_path"`
+	Unavailable bool   `json:"unavailable,omitempty"`
+}
+
+// QueueResponse es la representación de la cola que reciben los clientes
+type QueueResponse struct {
+	Songs         []QueueSong       `json:"songs"` // En orden de reproducción
+	CurrentItemID int               `json:"current_item_id"`
+	CurrentIndex  int               `json:"current_index"`
+	Shuffle       bool              `json:"shuffle"`
+	Repeat        models.RepeatMode `json:"repeat"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+func NewQueueHandler(db *sql.DB, events *models.EventHub) *QueueHandler {
+	return &QueueHandler{db: db, events: events}
+}
+
+// QueueRoutes atiende /api/queue y todas las rutas bajo /api/queue/
+func (h *QueueHandler) QueueRoutes(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/queue")
+	route := strings.Join(segs, "/")
+
+	switch {
+	case route == "" && r.Method == http.MethodGet:
+		queue, err := loadQueue(h.db, user.ID, false)
+		if err != nil {
+			log.Printf("Error cargando cola del usuario %d: %v", user.ID, err)
+			http.Error(w, "Error al obtener la cola", http.StatusInternalServerError)
+			return
+		}
+		h.respond(w, queue)
+	case (route == "" && r.Method == http.MethodDelete) || (route == "clear" && r.Method == http.MethodPost):
+		h.mutate(w, user, func(q *models.PlayQueue) error {
+			q.Clear()
+			return nil
+		})
+	case route == "enqueue" && r.Method == http.MethodPost:
+		h.enqueue(w, r, user)
+	case route == "play-next" && r.Method == http.MethodPost:
+		h.playNext(w, r, user)
+	case route == "next" && r.Method == http.MethodPost:
+		// ?auto=1 cuando la canción terminó sola (respeta la repetición de una canción)
+		skipped := r.URL.Query().Get("auto") != "1"
+		h.mutate(w, user, func(q *models.PlayQueue) error {
+			q.Next(skipped)
+			return nil
+		})
+	case route == "previous" && r.Method == http.MethodPost:
+		h.mutate(w, user, func(q *models.PlayQueue) error {
+			q.Previous()
+			return nil
+		})
+	case route == "shuffle" && r.Method == http.MethodPut:
+		h.setShuffle(w, r, user)
+	case route == "repeat" && r.Method == http.MethodPut:
+		h.setRepeat(w, r, user)
+	case len(segs) == 2 && segs[0] == "items":
+		h.itemRoutes(w, r, user, segs[1], "")
+	case len(segs) == 3 && segs[0] == "items":
+		h.itemRoutes(w, r, user, segs[1], segs[2])
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+// itemRoutes atiende /api/queue/items/{id}, /items/{id}/move y /items/{id}/play
+func (h *QueueHandler) itemRoutes(w http.ResponseWriter, r *http.Request, user *UserInfo, rawItemID, action string) {
+	itemID, err := strconv.Atoi(rawItemID)
+	if err != nil {
+		http.Error(w, "ID de entrada inválido", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodDelete:
+		h.mutate(w, user, func(q *models.PlayQueue) error {
+			return q.Remove(itemID)
+		})
+	case action == "move" && r.Method == http.MethodPut:
+		var input struct {
+			ToIndex int `json:"to_index"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+			return
+		}
+		h.mutate(w, user, func(q *models.PlayQueue) error {
+			return q.Move(itemID, input.ToIndex)
+		})
+	case action == "play" && r.Method == http.MethodPost:
+		h.mutate(w, user, func(q *models.PlayQueue) error {
+			return q.JumpTo(itemID)
+		})
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+func (h *QueueHandler) enqueue(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	var input struct {
+		SongIDs []int `json:"song_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || len(input.SongIDs) == 0 {
+		http.Error(w, "Debe indicar al menos una canción", http.StatusBadRequest)
+		return
+	}
+	if err := songsExist(h.db, input.SongIDs); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	h.mutate(w, user, func(q *models.PlayQueue) error {
+		return q.Enqueue(input.SongIDs...)
+	})
+}
+
+func (h *QueueHandler) playNext(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	var input struct {
+		SongID int `json:"song_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	if err := songsExist(h.db, []int{input.SongID}); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	h.mutate(w, user, func(q *models.PlayQueue) error {
+		return q.PlayNextSong(input.SongID)
+	})
+}
+
+func (h *QueueHandler) setShuffle(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	var input struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	h.mutate(w, user, func(q *models.PlayQueue) error {
+		// Si ya estaba activo se conserva el orden para no cambiarlo a mitad de sesión
+		if q.Shuffle != input.Enabled {
+			q.SetShuffle(input.Enabled, time.Now().UnixNano())
+		}
+		return nil
+	})
+}
+
+func (h *QueueHandler) setRepeat(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	var input struct {
+		Mode string `json:"mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	mode, err := models.ParseRepeatMode(input.Mode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.mutate(w, user, func(q *models.PlayQueue) error {
+		q.SetRepeat(mode)
+		return nil
+	})
+}
+
+// mutate carga la cola bloqueando su fila, aplica el cambio y la guarda en la
+// misma transacción, para que dos dispositivos no se pisen los cambios
+func (h *QueueHandler) mutate(w http.ResponseWriter, user *UserInfo, apply func(q *models.PlayQueue) error) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al actualizar la cola", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	queue, err := loadQueue(tx, user.ID, true)
+	if err != nil {
+		log.Printf("Error cargando cola del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al actualizar la cola", http.StatusInternalServerError)
+		return
+	}
+
+	if err := apply(queue); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := dropUnavailable(tx, queue); err != nil {
+		log.Printf("Error revisando canciones de la cola del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al actualizar la cola", http.StatusInternalServerError)
+		return
+	}
+
+	if err := saveQueue(tx, queue); err != nil {
+		log.Printf("Error guardando cola del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al actualizar la cola", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al actualizar la cola", http.StatusInternalServerError)
+		return
+	}
+
+	// Avisar a los demás dispositivos del usuario para que recarguen la cola
+	if h.events != nil {
+		data, _ := json.Marshal(map[string]interface{}{
+			"current_item_id": queue.CurrentItemID,
+			"updated_at":      queue.UpdatedAt,
+		})
+		h.events.Publish(user.ID, models.Event{
+			Type:         models.EventQueueUpdated,
+			SourceDevice: user.DeviceID,
+			Data:         data,
+		})
+	}
+
+	h.respond(w, queue)
+}
+
+// respond envía la cola en orden de reproducción junto con los datos de cada canción
+func (h *QueueHandler) respond(w http.ResponseWriter, queue *models.PlayQueue) {
+	order := queue.PlayOrder()
+	songIDs := make([]int, len(order))
+	for i, item := range order {
+		songIDs[i] = item.SongID
+	}
+
+	songs, err := songsByID(h.db, songIDs)
+	if err != nil {
+		http.Error(w, "Error al obtener las canciones de la cola", http.StatusInternalServerError)
+		return
+	}
+
+	response := QueueResponse{
+		Songs:         make([]QueueSong, 0, len(order)),
+		CurrentItemID: queue.CurrentItemID,
+		CurrentIndex:  queue.CurrentIndex(),
+		Shuffle:       queue.Shuffle,
+		Repeat:        queue.Repeat,
+		UpdatedAt:     queue.UpdatedAt,
+	}
+	for _, item := range order {
+		song, ok := songs[item.SongID]
+		if !ok {
+			response.Songs = append(response.Songs, QueueSong{ItemID: item.ID, SongID: item.SongID, Unavailable: true})
+			continue
+		}
+		response.Songs = append(response.Songs, QueueSong{
+			ItemID:   item.ID,
+			SongID:   item.SongID,
+			Title:    song.Title,
+			Artist:   song.Artist,
+			Genre:    song.Genre,
+			FilePath: song.FilePath,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// dropUnavailable quita de la cola las canciones que se borraron después de
+// encolarlas: la cola se guarda como JSON y no se entera del borrado
+func dropUnavailable(q queryer, queue *models.PlayQueue) error {
+	songIDs := make([]int, len(queue.Items))
+	for i, item := range queue.Items {
+		songIDs[i] = item.SongID
+	}
+	songs, err := songsByID(q, songIDs)
+	if err != nil {
+		return err
+	}
+	for _, item := range slices.Clone(queue.Items) {
+		if _, ok := songs[item.SongID]; !ok {
+			queue.Remove(item.ID)
+		}
+	}
+	return nil
+}
+
+// loadQueue lee la cola guardada del usuario; si no existe devuelve una vacía.
+// Con forUpdate la fila queda bloqueada hasta el fin de la transacción.
+func loadQueue(q queryer, userID int, forUpdate bool) (*models.PlayQueue, error) {
+	query := "SELECT state FROM play_queues WHERE user_id = ?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var state []byte
+	err := q.QueryRow(query, userID).Scan(&state)
+	if err == sql.ErrNoRows {
+		return models.NewPlayQueue(userID), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	queue := models.NewPlayQueue(userID)
+	if err := json.Unmarshal(state, queue); err != nil {
+		return nil, fmt.Errorf("estado de cola corrupto: %v", err)
+	}
+	queue.UserID = userID
+	return queue, nil
+}
+
+// saveQueue guarda el estado completo de la cola
+func saveQueue(q queryer, queue *models.PlayQueue) error {
+	state, err := json.Marshal(queue)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(`
+		INSERT INTO play_queues (user_id, state) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE state = VALUES(state)`,
+		queue.UserID, state,
+	)
+	return err
+}
+
+// songsExist verifica que todas las canciones indicadas estén en el catálogo
+func songsExist(q queryer, songIDs []int) error {
+	songs, err := songsByID(q, songIDs)
+	if err != nil {
+		return errors.New("error al verificar las canciones")
+	}
+	for _, id := range songIDs {
+		if _, ok := songs[id]; !ok {
+			return fmt.Errorf("canción %d no encontrada", id)
+		}
+	}
+	return nil
+}
+
+// songsByID obtiene los datos de varias canciones indexados por su ID
+func songsByID(q queryer, songIDs []int) (map[int]Song, error) {
+	songs := make(map[int]Song)
+	if len(songIDs) == 0 {
+		return songs, nil
+	}
+
+	placeholders := make([]string, len(songIDs))
+	args := make([]interface{}, len(songIDs))
+	for i, id := range songIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := q.Query(
+		"SELECT id, title, artist, genre, file_size, file_path FROM songs WHERE deleted_at IS NULL AND id IN ("+strings.Join(placeholders, ",")+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var song Song
+		if err := rows.Scan(&song.ID, &song.Title, &song.Artist, &song.Genre, &song.FileSize, &song.FilePath); err != nil {
+			return nil, err
+		}
+		songs[song.ID] = song
+	}
+	return songs, rows.Err()
+}
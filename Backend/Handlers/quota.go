@@ -0,0 +1,102 @@
+// Backend/Handlers/quota.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase quota, con sus respectivas
+funciones para consultar y aplicar los limites del plan de cada usuario
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type QuotaHandler struct {
+	db *sql.DB
+}
+
+func NewQuotaHandler(db *sql.DB) *QuotaHandler {
+	return &QuotaHandler{db: db}
+}
+
+// Quota devuelve el plan del usuario, lo que tiene ocupado y lo que le queda
+func (h *QuotaHandler) Quota(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	quota, err := loadQuota(h.db, user, false)
+	if err != nil {
+		log.Printf("Error obteniendo cuota del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al obtener la cuota", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, quota)
+}
+
+// loadQuota calcula la cuota del usuario. Con lock bloquea la fila del
+// usuario hasta el final de la transacción, así dos subidas simultáneas no
+// pueden superar juntas el límite.
+func loadQuota(q queryer, user *UserInfo, lock bool) (models.Quota, error) {
+	query := "SELECT plan FROM users WHERE id = ?"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	var plan string
+	if err := q.QueryRow(query, user.ID).Scan(&plan); err != nil {
+		return models.Quota{}, err
+	}
+
+	// Las canciones en la papelera no ocupan cuota, pero sí cuentan como
+	// subidas del día
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var usage models.QuotaUsage
+	err := q.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM songs WHERE uploaded_by = ? AND deleted_at IS NULL),
+			(SELECT COALESCE(SUM(file_size), 0) FROM songs WHERE uploaded_by = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM playlists WHERE user_id = ?),
+			(SELECT COUNT(*) FROM songs WHERE uploaded_by = ? AND created_at >= ?)`,
+		user.ID, user.ID, user.ID, user.ID, today,
+	).Scan(&usage.Songs, &usage.StorageBytes, &usage.Playlists, &usage.UploadsToday)
+	if err != nil {
+		return models.Quota{}, err
+	}
+
+	effective, _, err := effectivePlan(q, user, plan)
+	if err != nil {
+		return models.Quota{}, err
+	}
+	return models.NewQuota(effective, usage), nil
+}
+
+// writeQuotaError responde el error de cuota con el límite y lo que queda.
+// Devuelve false si err no es un error de cuota.
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	quotaErr, ok := err.(*models.QuotaError)
+	if !ok {
+		return false
+	}
+
+	status := http.StatusForbidden
+	switch quotaErr.Resource {
+	case "song_size":
+		status = http.StatusRequestEntityTooLarge
+	case "daily_uploads":
+		status = http.StatusTooManyRequests
+	}
+	writeJSON(w, status, quotaErr)
+	return true
+}
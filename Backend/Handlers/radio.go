@@ -0,0 +1,465 @@
+// Backend/Handlers/radio.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase radio, con sus respectivas
+funciones para el manejo de rutas de la radio automatica
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type RadioHandler struct {
+	db *sql.DB
+}
+
+type CreateRadioRequest struct {
+	SeedType  string `json:"seed_type"`
+	SeedValue string `json:"seed_value"`
+}
+
+type RadioFeedbackRequest struct {
+	SongID   int    `json:"song_id"`
+	Type     string `json:"type"`     // "skip" o "dislike"
+	Position *int   `json:"position"` // Posición de la canción en la radio (opcional)
+}
+
+// RadioSummary es la información pública de una radio, sin su estado interno
+type RadioSummary struct {
+	ID        int                  `json:"id"`
+	SeedType  models.RadioSeedType `json:"seed_type"`
+	SeedValue string               `json:"seed_value"`
+	Generated int                  `json:"generated"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// RadioPage es una página de canciones de la radio
+type RadioPage struct {
+	StationID  int    `json:"station_id"`
+	Offset     int    `json:"offset"`
+	NextOffset int    `json:"next_offset"`
+	Tracks     []Song `json:"tracks"`
+}
+
+func NewRadioHandler(db *sql.DB) *RadioHandler {
+	return &RadioHandler{db: db}
+}
+
+// RadioRoutes atiende /api/radio y todas las rutas bajo /api/radio/
+func (h *RadioHandler) RadioRoutes(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/radio")
+	if len(segs) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			h.listStations(w, user)
+		case http.MethodPost:
+			h.createStation(w, r, user)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	stationID, err := strconv.Atoi(segs[0])
+	if err != nil {
+		http.Error(w, "ID de radio inválido", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(segs) == 1 && r.Method == http.MethodGet:
+		station, err := loadStation(h.db, stationID, user.ID, false)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Radio no encontrada", http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("Error cargando radio %d: %v", stationID, err)
+			http.Error(w, "Error al obtener la radio", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, summarizeStation(station))
+	case len(segs) == 1 && r.Method == http.MethodDelete:
+		h.deleteStation(w, stationID, user)
+	case len(segs) == 2 && segs[1] == "tracks" && r.Method == http.MethodGet:
+		h.tracks(w, r, stationID, user)
+	case len(segs) == 2 && segs[1] == "feedback" && r.Method == http.MethodPost:
+		h.feedback(w, r, stationID, user)
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+func (h *RadioHandler) listStations(w http.ResponseWriter, user *UserInfo) {
+	rows, err := h.db.Query(
+		"SELECT id, state FROM radio_stations WHERE user_id = ? ORDER BY updated_at DESC LIMIT 50",
+		user.ID,
+	)
+	if err != nil {
+		log.Printf("Error listando radios del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al obtener las radios", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	stations := []RadioSummary{}
+	for rows.Next() {
+		var id int
+		var state []byte
+		if err := rows.Scan(&id, &state); err != nil {
+			http.Error(w, "Error al leer las radios", http.StatusInternalServerError)
+			return
+		}
+		var station models.RadioStation
+		if err := json.Unmarshal(state, &station); err != nil {
+			continue
+		}
+		station.ID = id
+		stations = append(stations, summarizeStation(&station))
+	}
+
+	writeJSON(w, http.StatusOK, stations)
+}
+
+func (h *RadioHandler) createStation(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	var req CreateRadioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	seedType, err := models.ParseRadioSeedType(req.SeedType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.SeedValue = strings.TrimSpace(req.SeedValue)
+	if req.SeedValue == "" {
+		http.Error(w, "Debe indicar la semilla de la radio", http.StatusBadRequest)
+		return
+	}
+
+	station := models.NewRadioStation(user.ID, seedType, req.SeedValue)
+
+	// Validar la semilla antes de guardar la radio
+	if _, err := resolveRadioSeed(h.db, station); err != nil {
+		var radioErr models.RadioError
+		var seedErr models.RadioSeedError
+		if errors.As(err, &radioErr) || errors.As(err, &seedErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error validando la semilla de la radio del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al crear la radio", http.StatusInternalServerError)
+		return
+	}
+
+	state, err := json.Marshal(station)
+	if err != nil {
+		http.Error(w, "Error al crear la radio", http.StatusInternalServerError)
+		return
+	}
+	result, err := h.db.Exec("INSERT INTO radio_stations (user_id, state) VALUES (?, ?)", user.ID, state)
+	if err != nil {
+		log.Printf("Error creando radio del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al crear la radio", http.StatusInternalServerError)
+		return
+	}
+	id, _ := result.LastInsertId()
+	station.ID = int(id)
+
+	writeJSON(w, http.StatusCreated, summarizeStation(station))
+}
+
+func (h *RadioHandler) deleteStation(w http.ResponseWriter, stationID int, user *UserInfo) {
+	result, err := h.db.Exec("DELETE FROM radio_stations WHERE id = ? AND user_id = ?", stationID, user.ID)
+	if err != nil {
+		log.Printf("Error eliminando radio %d: %v", stationID, err)
+		http.Error(w, "Error al eliminar la radio", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "Radio no encontrada", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tracks devuelve las siguientes canciones de la radio. Sin offset entrega
+// las N canciones siguientes a las ya generadas; con offset repite o
+// continúa desde esa posición.
+func (h *RadioHandler) tracks(w http.ResponseWriter, r *http.Request, stationID int, user *UserInfo) {
+	params := r.URL.Query()
+	limit, _ := strconv.Atoi(params.Get("limit"))
+
+	var page []int
+	var offset int
+	err := h.mutate(w, stationID, user.ID, func(tx *sql.Tx, station *models.RadioStation) error {
+		offset = station.End()
+		if raw := params.Get("offset"); raw != "" {
+			var err error
+			if offset, err = strconv.Atoi(raw); err != nil {
+				return models.RadioError("posición inválida")
+			}
+		}
+
+		seed, err := resolveRadioSeed(tx, station)
+		if err != nil {
+			return err
+		}
+		catalog, err := radioCatalog(tx)
+		if err != nil {
+			return err
+		}
+
+		page, err = station.Page(models.RadioPool(seed, catalog), offset, limit)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	songs, err := songsByID(h.db, page)
+	if err != nil {
+		http.Error(w, "Error al obtener las canciones de la radio", http.StatusInternalServerError)
+		return
+	}
+	response := RadioPage{
+		StationID:  stationID,
+		Offset:     offset,
+		NextOffset: offset + len(page),
+		Tracks:     make([]Song, 0, len(page)),
+	}
+	for _, id := range page {
+		if song, ok := songs[id]; ok {
+			response.Tracks = append(response.Tracks, song)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// feedback registra un salto o un rechazo para ajustar las próximas canciones
+func (h *RadioHandler) feedback(w http.ResponseWriter, r *http.Request, stationID int, user *UserInfo) {
+	var req RadioFeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SongID <= 0 {
+		http.Error(w, "Debe indicar la canción", http.StatusBadRequest)
+		return
+	}
+
+	var artist string
+	err := h.db.QueryRow("SELECT artist FROM songs WHERE id = ? AND deleted_at IS NULL", req.SongID).Scan(&artist)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error al registrar la opinión", http.StatusInternalServerError)
+		return
+	}
+
+	position := -1
+	if req.Position != nil {
+		position = *req.Position
+	}
+
+	var nextOffset int
+	err = h.mutate(w, stationID, user.ID, func(tx *sql.Tx, station *models.RadioStation) error {
+		var err error
+		nextOffset, err = station.Feedback(req.SongID, artist, models.RadioFeedback(req.Type), position)
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	// next_offset indica desde dónde pedir las canciones actualizadas
+	writeJSON(w, http.StatusOK, map[string]int{"next_offset": nextOffset})
+}
+
+// mutate carga la radio bloqueando su fila, aplica el cambio y la guarda en
+// la misma transacción. Si falla ya respondió el error al cliente: un
+// models.RadioError es un 400, una semilla que ya no tiene canciones un 410
+// y cualquier otro error un 500.
+func (h *RadioHandler) mutate(w http.ResponseWriter, stationID, userID int, apply func(tx *sql.Tx, station *models.RadioStation) error) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al actualizar la radio", http.StatusInternalServerError)
+		return err
+	}
+	defer tx.Rollback()
+
+	station, err := loadStation(tx, stationID, userID, true)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Radio no encontrada", http.StatusNotFound)
+		return err
+	} else if err != nil {
+		log.Printf("Error cargando radio %d: %v", stationID, err)
+		http.Error(w, "Error al actualizar la radio", http.StatusInternalServerError)
+		return err
+	}
+
+	if err := apply(tx, station); err != nil {
+		var radioErr models.RadioError
+		var seedErr models.RadioSeedError
+		if errors.As(err, &radioErr) {
+			http.Error(w, radioErr.Error(), http.StatusBadRequest)
+			return err
+		}
+		if errors.As(err, &seedErr) {
+			http.Error(w, "La semilla de la radio ya no tiene canciones disponibles", http.StatusGone)
+			return err
+		}
+		log.Printf("Error actualizando radio %d: %v", stationID, err)
+		http.Error(w, "Error al actualizar la radio", http.StatusInternalServerError)
+		return err
+	}
+
+	station.UpdatedAt = time.Now()
+	state, err := json.Marshal(station)
+	if err != nil {
+		http.Error(w, "Error al actualizar la radio", http.StatusInternalServerError)
+		return err
+	}
+	if _, err := tx.Exec("UPDATE radio_stations SET state = ? WHERE id = ?", state, stationID); err != nil {
+		log.Printf("Error guardando radio %d: %v", stationID, err)
+		http.Error(w, "Error al actualizar la radio", http.StatusInternalServerError)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al actualizar la radio", http.StatusInternalServerError)
+		return err
+	}
+	return nil
+}
+
+// loadStation lee una radio del usuario. Con forUpdate la fila queda
+// bloqueada hasta el fin de la transacción.
+func loadStation(q queryer, stationID, userID int, forUpdate bool) (*models.RadioStation, error) {
+	query := "SELECT state FROM radio_stations WHERE id = ? AND user_id = ?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var state []byte
+	if err := q.QueryRow(query, stationID, userID).Scan(&state); err != nil {
+		return nil, err
+	}
+
+	var station models.RadioStation
+	if err := json.Unmarshal(state, &station); err != nil {
+		return nil, fmt.Errorf("estado de radio corrupto: %v", err)
+	}
+	station.ID = stationID
+	station.UserID = userID
+	return &station, nil
+}
+
+func summarizeStation(station *models.RadioStation) RadioSummary {
+	return RadioSummary{
+		ID:        station.ID,
+		SeedType:  station.SeedType,
+		SeedValue: station.SeedValue,
+		Generated: station.End(),
+		CreatedAt: station.CreatedAt,
+		UpdatedAt: station.UpdatedAt,
+	}
+}
+
+// resolveRadioSeed convierte la semilla de la radio en las canciones,
+// artistas y géneros a los que debe parecerse. Una semilla mal escrita es un
+// models.RadioError y una sin canciones un models.RadioSeedError; cualquier
+// otro error viene de la base de datos.
+func resolveRadioSeed(q queryer, station *models.RadioStation) (models.RadioSeed, error) {
+	var query string
+	var args []interface{}
+
+	switch station.SeedType {
+	case models.RadioSeedSong:
+		id, err := strconv.Atoi(station.SeedValue)
+		if err != nil {
+			return models.RadioSeed{}, models.RadioError("ID de canción inválido")
+		}
+		query, args = "SELECT id, title, artist, genre FROM songs WHERE id = ? AND deleted_at IS NULL", []interface{}{id}
+	case models.RadioSeedArtist:
+		query, args = "SELECT id, title, artist, genre FROM songs WHERE artist = ? AND deleted_at IS NULL", []interface{}{station.SeedValue}
+	case models.RadioSeedGenre:
+		// Solo el género: los artistas que también tocan otros géneros no se priorizan
+		var exists bool
+		if err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM songs WHERE genre = ? AND deleted_at IS NULL)", station.SeedValue).Scan(&exists); err != nil {
+			return models.RadioSeed{}, err
+		}
+		if !exists {
+			return models.RadioSeed{}, models.RadioSeedError("no hay canciones de ese género")
+		}
+		seed := models.NewRadioSeed(nil)
+		seed.Genres[station.SeedValue] = true
+		return seed, nil
+	case models.RadioSeedPlaylist:
+		id, err := strconv.Atoi(station.SeedValue)
+		if err != nil {
+			return models.RadioSeed{}, models.RadioError("ID de playlist inválido")
+		}
+		playlist, err := loadPlaylist(q, id, false)
+		if err == sql.ErrNoRows || (err == nil && !playlist.CanView(station.UserID)) {
+			return models.RadioSeed{}, models.RadioSeedError("playlist no encontrada")
+		} else if err != nil {
+			return models.RadioSeed{}, err
+		}
+		query = `
+			SELECT s.id, s.title, s.artist, s.genre
+			FROM playlist_songs ps JOIN songs s ON s.id = ps.song_id
+			WHERE ps.playlist_id = ? AND s.deleted_at IS NULL`
+		args = []interface{}{id}
+	default:
+		return models.RadioSeed{}, models.RadioError(fmt.Sprintf("tipo de semilla inválido: %s", station.SeedType))
+	}
+
+	songs, err := scanRadioSongs(q, query, args...)
+	if err != nil {
+		return models.RadioSeed{}, err
+	}
+	if len(songs) == 0 {
+		return models.RadioSeed{}, models.RadioSeedError("la semilla no tiene canciones")
+	}
+	return models.NewRadioSeed(songs), nil
+}
+
+// radioCatalog devuelve todas las canciones que pueden sonar en una radio
+func radioCatalog(q queryer) ([]models.Song, error) {
+	return scanRadioSongs(q, "SELECT id, title, artist, genre FROM songs WHERE deleted_at IS NULL ORDER BY id")
+}
+
+func scanRadioSongs(q queryer, query string, args ...interface{}) ([]models.Song, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var songs []models.Song
+	for rows.Next() {
+		var s models.Song
+		if err := rows.Scan(&s.ID, &s.Title, &s.Artist, &s.Genre); err != nil {
+			return nil, err
+		}
+		songs = append(songs, s)
+	}
+	return songs, rows.Err()
+}
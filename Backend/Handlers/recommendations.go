@@ -0,0 +1,51 @@
+// Backend/Handlers/recommendations.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase recommendations, con sus respectivas
+funciones para entregar las recomendaciones personalizadas del usuario
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type RecommendationHandler struct {
+	db          *sql.DB
+	recommender *models.Recommender
+}
+
+type RecommendationsResponse struct {
+	Recommendations []models.Recommendation `json:"recommendations"`
+	UpdatedAt       time.Time               `json:"updated_at"`
+}
+
+func NewRecommendationHandler(db *sql.DB, recommender *models.Recommender) *RecommendationHandler {
+	return &RecommendationHandler{db: db, recommender: recommender}
+}
+
+// Recommendations devuelve las canciones recomendadas para el usuario actual.
+// Se leen de la última precomputación, sin recalcular en la petición.
+func (h *RecommendationHandler) Recommendations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	recs, updatedAt := h.recommender.For(user.ID, limit)
+
+	writeJSON(w, http.StatusOK, RecommendationsResponse{Recommendations: recs, UpdatedAt: updatedAt})
+}
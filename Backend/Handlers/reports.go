@@ -0,0 +1,617 @@
+// Backend/Handlers/reports.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase reports, con sus respectivas
+funciones para el envio de reportes de problemas y su gestion
+por parte de los administradores
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// Los adjuntos se guardan aparte de las canciones y solo se entregan con
+// GET /{id}/attachments/{adjunto}
+const reportUploadDir = "./attachments/reports"
+
+type ReportHandler struct {
+	db     *sql.DB
+	events *models.EventHub
+}
+
+type ReportsResponse struct {
+	Reports  []models.Report `json:"reports"`
+	Total    int             `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
+
+func NewReportHandler(db *sql.DB, events *models.EventHub) *ReportHandler {
+	return &ReportHandler{db: db, events: events}
+}
+
+// ReportRoutes atiende las rutas de los usuarios bajo /api/reports:
+// GET y POST /api/reports, GET /{id}, POST /{id}/comments y
+// GET /{id}/attachments/{adjunto}
+func (h *ReportHandler) ReportRoutes(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/reports")
+	if len(segs) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			h.listReports(w, r, "r.user_id = ?", []interface{}{user.ID})
+		case http.MethodPost:
+			h.createReport(w, r, user)
+		default:
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	report, ok := h.loadVisibleReport(w, segs[0], user)
+	if !ok {
+		return
+	}
+
+	switch {
+	case len(segs) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, report)
+	case len(segs) == 2 && segs[1] == "comments" && r.Method == http.MethodPost:
+		h.addComment(w, r, user, report)
+	case len(segs) == 3 && segs[1] == "attachments" && r.Method == http.MethodGet:
+		h.downloadAttachment(w, r, report, segs[2])
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+// AdminReportRoutes atiende las rutas de gestión bajo /api/admin/reports:
+// GET /api/admin/reports (filtros status, assigned_to, song_id, q, page,
+// page_size), GET /{id}, PUT /{id}/status, PUT /{id}/assign y
+// POST /{id}/comments
+func (h *ReportHandler) AdminReportRoutes(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+	if admin.Role != "admin" {
+		http.Error(w, "No tienes permisos de administrador", http.StatusForbidden)
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/admin/reports")
+	if len(segs) == 0 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+			return
+		}
+		where, args, err := reportFilters(r, admin)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.listReports(w, r, where, args)
+		return
+	}
+
+	report, ok := h.loadVisibleReport(w, segs[0], admin)
+	if !ok {
+		return
+	}
+
+	switch {
+	case len(segs) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, report)
+	case len(segs) == 2 && segs[1] == "status" && r.Method == http.MethodPut:
+		h.changeStatus(w, r, admin, report)
+	case len(segs) == 2 && segs[1] == "assign" && r.Method == http.MethodPut:
+		h.assign(w, r, report)
+	case len(segs) == 2 && segs[1] == "comments" && r.Method == http.MethodPost:
+		h.addComment(w, r, admin, report)
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+// createReport registra un reporte. Acepta JSON o un formulario multipart
+// con los campos title, description, song_id y los archivos attachments.
+func (h *ReportHandler) createReport(w http.ResponseWriter, r *http.Request, user *UserInfo) {
+	var input struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		SongID      *int   `json:"song_id"`
+	}
+	var files []*multipart.FileHeader
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		r.Body = http.MaxBytesReader(w, r.Body, models.MaxReportAttachments*models.MaxAttachmentSize+1<<20)
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, "Error al leer el formulario", http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		input.Title = r.FormValue("title")
+		input.Description = r.FormValue("description")
+		if raw := r.FormValue("song_id"); raw != "" {
+			id, err := strconv.Atoi(raw)
+			if err != nil {
+				http.Error(w, "ID de canción inválido", http.StatusBadRequest)
+				return
+			}
+			input.SongID = &id
+		}
+		files = r.MultipartForm.File["attachments"]
+	} else if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	title, description, err := models.ValidateReport(input.Title, input.Description)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(files) > models.MaxReportAttachments {
+		http.Error(w, fmt.Sprintf("Se permiten como máximo %d archivos adjuntos", models.MaxReportAttachments), http.StatusBadRequest)
+		return
+	}
+	contentTypes := make([]string, len(files))
+	for i, f := range files {
+		if contentTypes[i], err = models.AttachmentContentType(f.Filename, f.Size); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if input.SongID != nil {
+		var exists bool
+		err := h.db.QueryRow("SELECT EXISTS(SELECT 1 FROM songs WHERE id = ? AND deleted_at IS NULL)", *input.SongID).Scan(&exists)
+		if err != nil {
+			log.Printf("Error verificando canción %d: %v", *input.SongID, err)
+			http.Error(w, "Error al registrar el reporte", http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			http.Error(w, "Canción no encontrada", http.StatusNotFound)
+			return
+		}
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al registrar el reporte", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"INSERT INTO reports (user_id, song_id, title, description, status) VALUES (?, ?, ?, ?, ?)",
+		user.ID, input.SongID, title, description, models.ReportOpen,
+	)
+	if err != nil {
+		log.Printf("Error registrando reporte del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al registrar el reporte", http.StatusInternalServerError)
+		return
+	}
+	reportID, _ := result.LastInsertId()
+
+	// Si algo falla se borran los archivos ya copiados
+	dir := filepath.Join(reportUploadDir, strconv.FormatInt(reportID, 10))
+	committed := false
+	defer func() {
+		if !committed {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	for i, f := range files {
+		path, err := saveAttachment(dir, f)
+		if err != nil {
+			log.Printf("Error guardando adjunto del reporte %d: %v", reportID, err)
+			http.Error(w, "Error al guardar los archivos adjuntos", http.StatusInternalServerError)
+			return
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO report_attachments (report_id, file_name, content_type, size, file_path) VALUES (?, ?, ?, ?, ?)",
+			reportID, filepath.Base(f.Filename), contentTypes[i], f.Size, path,
+		); err != nil {
+			http.Error(w, "Error al guardar los archivos adjuntos", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al registrar el reporte", http.StatusInternalServerError)
+		return
+	}
+	committed = true
+
+	report, err := loadReport(h.db, int(reportID))
+	if err != nil {
+		http.Error(w, "Error al obtener el reporte", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, report)
+}
+
+// listReports devuelve una página de reportes que cumplen el filtro
+func (h *ReportHandler) listReports(w http.ResponseWriter, r *http.Request, where string, args []interface{}) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	page, pageSize = models.NormalizePage(page, pageSize)
+
+	response := ReportsResponse{Reports: []models.Report{}, Page: page, PageSize: pageSize}
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM reports r WHERE "+where, args...).Scan(&response.Total); err != nil {
+		log.Printf("Error contando reportes: %v", err)
+		http.Error(w, "Error al obtener los reportes", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.db.Query(reportSelect+" WHERE "+where+" ORDER BY r.created_at DESC, r.id DESC LIMIT ? OFFSET ?",
+		append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		log.Printf("Error listando reportes: %v", err)
+		http.Error(w, "Error al obtener los reportes", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			http.Error(w, "Error al leer los reportes", http.StatusInternalServerError)
+			return
+		}
+		response.Reports = append(response.Reports, *report)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// reportFilters arma el filtro del listado de administración
+func reportFilters(r *http.Request, admin *UserInfo) (string, []interface{}, error) {
+	params := r.URL.Query()
+	filters := []string{"1 = 1"}
+	var args []interface{}
+
+	if raw := params.Get("status"); raw != "" {
+		status, err := models.ParseReportStatus(raw)
+		if err != nil {
+			return "", nil, err
+		}
+		filters = append(filters, "r.status = ?")
+		args = append(args, status)
+	}
+	switch raw := params.Get("assigned_to"); raw {
+	case "":
+	case "none":
+		filters = append(filters, "r.assigned_to IS NULL")
+	case "me":
+		filters = append(filters, "r.assigned_to = ?")
+		args = append(args, admin.ID)
+	default:
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			return "", nil, fmt.Errorf("assigned_to inválido: %s", raw)
+		}
+		filters = append(filters, "r.assigned_to = ?")
+		args = append(args, id)
+	}
+	if raw := params.Get("song_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			return "", nil, fmt.Errorf("song_id inválido: %s", raw)
+		}
+		filters = append(filters, "r.song_id = ?")
+		args = append(args, id)
+	}
+	if q := strings.TrimSpace(params.Get("q")); q != "" {
+		filters = append(filters, "(r.title LIKE ? OR r.description LIKE ?)")
+		args = append(args, "%"+q+"%", "%"+q+"%")
+	}
+	return strings.Join(filters, " AND "), args, nil
+}
+
+// changeStatus cambia el estado del reporte y avisa a quien lo envió
+func (h *ReportHandler) changeStatus(w http.ResponseWriter, r *http.Request, admin *UserInfo, report *models.Report) {
+	var input struct {
+		Status  string `json:"status"`
+		Comment string `json:"comment"` // Opcional: se agrega como comentario
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	status, err := models.ParseReportStatus(input.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status == report.Status {
+		writeJSON(w, http.StatusOK, report)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al actualizar el reporte", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Solo cambia si nadie lo modificó desde que se leyó
+	result, err := tx.Exec("UPDATE reports SET status = ? WHERE id = ? AND status = ?", status, report.ID, report.Status)
+	if err != nil {
+		log.Printf("Error actualizando estado del reporte %d: %v", report.ID, err)
+		http.Error(w, "Error al actualizar el reporte", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "El reporte fue modificado por otro administrador, vuelva a cargarlo", http.StatusConflict)
+		return
+	}
+
+	if comment := strings.TrimSpace(input.Comment); comment != "" {
+		body, err := models.ValidateComment(comment)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, err := tx.Exec("INSERT INTO report_comments (report_id, user_id, body) VALUES (?, ?, ?)", report.ID, admin.ID, body); err != nil {
+			http.Error(w, "Error al actualizar el reporte", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	notification, err := createNotification(tx, report.UserID, models.NotificationReportStatus,
+		fmt.Sprintf("Tu reporte \"%s\" ahora está %s", report.Title, status.Label()),
+		map[string]interface{}{"report_id": report.ID, "status": status},
+	)
+	if err != nil {
+		log.Printf("Error creando aviso del reporte %d: %v", report.ID, err)
+		http.Error(w, "Error al actualizar el reporte", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al actualizar el reporte", http.StatusInternalServerError)
+		return
+	}
+	publishNotification(h.events, notification)
+
+	h.respondReport(w, report.ID)
+}
+
+// assign asigna el reporte a un administrador; admin_id null lo deja sin asignar
+func (h *ReportHandler) assign(w http.ResponseWriter, r *http.Request, report *models.Report) {
+	var input struct {
+		AdminID *int `json:"admin_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+
+	if input.AdminID != nil {
+		var role string
+		err := h.db.QueryRow("SELECT role FROM users WHERE id = ?", *input.AdminID).Scan(&role)
+		if err == sql.ErrNoRows || (err == nil && role != "admin") {
+			http.Error(w, "El usuario indicado no es administrador", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, "Error al asignar el reporte", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if _, err := h.db.Exec("UPDATE reports SET assigned_to = ? WHERE id = ?", input.AdminID, report.ID); err != nil {
+		log.Printf("Error asignando reporte %d: %v", report.ID, err)
+		http.Error(w, "Error al asignar el reporte", http.StatusInternalServerError)
+		return
+	}
+
+	h.respondReport(w, report.ID)
+}
+
+func (h *ReportHandler) addComment(w http.ResponseWriter, r *http.Request, user *UserInfo, report *models.Report) {
+	var input struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	body, err := models.ValidateComment(input.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.db.Exec(
+		"INSERT INTO report_comments (report_id, user_id, body) VALUES (?, ?, ?)",
+		report.ID, user.ID, body,
+	); err != nil {
+		log.Printf("Error comentando reporte %d: %v", report.ID, err)
+		http.Error(w, "Error al guardar el comentario", http.StatusInternalServerError)
+		return
+	}
+	h.db.Exec("UPDATE reports SET updated_at = NOW() WHERE id = ?", report.ID)
+
+	report, err = loadReport(h.db, report.ID)
+	if err != nil {
+		http.Error(w, "Error al obtener el reporte", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, report)
+}
+
+func (h *ReportHandler) downloadAttachment(w http.ResponseWriter, r *http.Request, report *models.Report, rawID string) {
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		http.Error(w, "ID de adjunto inválido", http.StatusBadRequest)
+		return
+	}
+
+	for _, a := range report.Attachments {
+		if a.ID != id {
+			continue
+		}
+		w.Header().Set("Content-Type", a.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.FileName))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeFile(w, r, a.FilePath)
+		return
+	}
+	http.Error(w, "Adjunto no encontrado", http.StatusNotFound)
+}
+
+// loadVisibleReport carga el reporte indicado si el usuario puede verlo
+// (quien lo envió o un administrador); si no, responde el error
+func (h *ReportHandler) loadVisibleReport(w http.ResponseWriter, rawID string, user *UserInfo) (*models.Report, bool) {
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		http.Error(w, "ID de reporte inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	report, err := loadReport(h.db, id)
+	if err == sql.ErrNoRows || (err == nil && report.UserID != user.ID && user.Role != "admin") {
+		http.Error(w, "Reporte no encontrado", http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		log.Printf("Error cargando reporte %d: %v", id, err)
+		http.Error(w, "Error al obtener el reporte", http.StatusInternalServerError)
+		return nil, false
+	}
+	return report, true
+}
+
+func (h *ReportHandler) respondReport(w http.ResponseWriter, reportID int) {
+	report, err := loadReport(h.db, reportID)
+	if err != nil {
+		http.Error(w, "Error al obtener el reporte", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+const reportSelect = `
+	SELECT r.id, r.user_id, u.name, r.song_id, COALESCE(s.title, ''), r.title, r.description,
+	       r.status, r.assigned_to, r.created_at, r.updated_at
+	FROM reports r
+	JOIN users u ON u.id = r.user_id
+	LEFT JOIN songs s ON s.id = r.song_id`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReport(row rowScanner) (*models.Report, error) {
+	var report models.Report
+	var songID, assignedTo sql.NullInt64
+	err := row.Scan(&report.ID, &report.UserID, &report.UserName, &songID, &report.SongTitle,
+		&report.Title, &report.Description, &report.Status, &assignedTo, &report.CreatedAt, &report.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if songID.Valid {
+		id := int(songID.Int64)
+		report.SongID = &id
+	}
+	if assignedTo.Valid {
+		id := int(assignedTo.Int64)
+		report.AssignedTo = &id
+	}
+	return &report, nil
+}
+
+// loadReport lee un reporte con sus comentarios y adjuntos
+func loadReport(q queryer, id int) (*models.Report, error) {
+	report, err := scanReport(q.QueryRow(reportSelect+" WHERE r.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(`
+		SELECT c.id, c.user_id, u.name, c.body, c.created_at
+		FROM report_comments c JOIN users u ON u.id = c.user_id
+		WHERE c.report_id = ?
+		ORDER BY c.created_at, c.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	report.Comments = []models.ReportComment{}
+	for rows.Next() {
+		var c models.ReportComment
+		if err := rows.Scan(&c.ID, &c.UserID, &c.UserName, &c.Body, &c.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.Comments = append(report.Comments, c)
+	}
+	rows.Close()
+
+	rows, err = q.Query(`
+		SELECT id, file_name, content_type, size, file_path, created_at
+		FROM report_attachments WHERE report_id = ? ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	report.Attachments = []models.ReportAttachment{}
+	for rows.Next() {
+		var a models.ReportAttachment
+		if err := rows.Scan(&a.ID, &a.FileName, &a.ContentType, &a.Size, &a.FilePath, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		report.Attachments = append(report.Attachments, a)
+	}
+	return report, rows.Err()
+}
+
+// saveAttachment copia un adjunto al directorio del reporte con un nombre
+// único, sin usar la ruta que envió el cliente
+func saveAttachment(dir string, header *multipart.FileHeader) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	name := fmt.Sprintf("%d%s", time.Now().UnixNano(), strings.ToLower(filepath.Ext(header.Filename)))
+	path := filepath.Join(dir, name)
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, io.LimitReader(src, models.MaxAttachmentSize+1)); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,235 @@
+// Backend/Handlers/rules.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Aplicacion de las reglas de escucha (limite de saltos, adelantar
+en la radio y reproducciones simultaneas) a las rutas de reproduccion
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type PlaybackCheckRequest struct {
+	Action string `json:"action"` // start, skip o seek
+	Source string `json:"source"`
+}
+
+// Check permite al reproductor preguntar si una acción está permitida
+// antes de hacerla; no cuenta como salto
+func (h *PlaybackHandler) Check(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	var req PlaybackCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Error al leer el cuerpo de la petición", http.StatusBadRequest)
+		return
+	}
+	switch req.Action {
+	case models.ActionStart, models.ActionSkip, models.ActionSeek:
+	default:
+		http.Error(w, "Acción inválida", http.StatusBadRequest)
+		return
+	}
+
+	deviceID, ok := requireSessionDevice(w, user)
+	if !ok {
+		return
+	}
+	ctx, err := h.playbackContext(user, req.Action, req.Source, deviceID)
+	if err != nil {
+		log.Printf("Error obteniendo reglas del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al evaluar las reglas de reproducción", http.StatusInternalServerError)
+		return
+	}
+	if v := h.rules.Preview(ctx); v != nil {
+		writeRuleViolation(w, v)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]bool{"allowed": true})
+}
+
+// EnforceRules evalúa y anota una acción del usuario; si no está permitida
+// escribe el error y devuelve false. Sirve para rutas que no pasan por Start.
+// Si empezar la canción salta la anterior lo decide el servidor.
+func (h *PlaybackHandler) EnforceRules(w http.ResponseWriter, r *http.Request, action string) bool {
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return false
+	}
+	return h.enforce(w, user, action, r.URL.Query().Get("source"))
+}
+
+// enforce aplica las reglas; si la acción empieza una canción el
+// dispositivo pasa a contar como reproducción activa. Empezar una canción
+// cuenta como salto si la anterior no alcanzó a terminar (ver startAction).
+func (h *PlaybackHandler) enforce(w http.ResponseWriter, user *UserInfo, action, source string) bool {
+	deviceID, ok := requireSessionDevice(w, user)
+	if !ok {
+		return false
+	}
+	var err error
+	if action == models.ActionStart {
+		if action, err = startAction(h.db, user.ID, time.Now()); err != nil {
+			log.Printf("Error obteniendo la reproducción anterior del usuario %d: %v", user.ID, err)
+			http.Error(w, "Error al evaluar las reglas de reproducción", http.StatusInternalServerError)
+			return false
+		}
+	}
+	ctx, err := h.playbackContext(user, action, source, deviceID)
+	if err != nil {
+		log.Printf("Error obteniendo reglas del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al evaluar las reglas de reproducción", http.StatusInternalServerError)
+		return false
+	}
+	if v := h.rules.Check(ctx); v != nil {
+		writeRuleViolation(w, v)
+		return false
+	}
+	if action != models.ActionSeek {
+		if err := touchDevice(h.db, user.ID, deviceID, true); err != nil {
+			log.Printf("Error actualizando dispositivo %s: %v", deviceID, err)
+		}
+	}
+	return true
+}
+
+// startAction decide si empezar una canción en now es un salto: la
+// reproducción anterior del usuario empezó hace menos de lo que dura su
+// canción, así que se cambió antes de que terminara. No depende de lo que
+// informe el cliente; una pausa larga antes de cambiar no cuenta como salto.
+func startAction(q queryer, userID int, now time.Time) (string, error) {
+	var playedAt time.Time
+	var lengthMs sql.NullInt64
+	err := q.QueryRow(`
+		SELECT p.played_at, COALESCE(w.duration_ms, ROUND(l.duration_seconds * 1000))
+		FROM playbacks p
+		LEFT JOIN song_waveforms w ON w.song_id = p.song_id
+		LEFT JOIN song_loudness l ON l.song_id = p.song_id
+		WHERE p.user_id = ? AND p.source <> ?
+		ORDER BY p.played_at DESC, p.id DESC
+		LIMIT 1`, userID, models.SourceImport,
+	).Scan(&playedAt, &lengthMs)
+	if err == sql.ErrNoRows {
+		return models.ActionStart, nil
+	} else if err != nil {
+		return "", err
+	}
+	if models.IsSkip(playedAt, time.Duration(lengthMs.Int64)*time.Millisecond, now) {
+		return models.ActionSkip, nil
+	}
+	return models.ActionStart, nil
+}
+
+// checkSeek compara la posición reportada con el reporte anterior de la
+// misma reproducción (o con su inicio) y, si saltó y la canción viene de la
+// radio, aplica las reglas de adelantar. Devuelve la regla que no lo permite.
+func (h *PlaybackHandler) checkSeek(user *UserInfo, p *models.PlaybackPosition) (*models.RuleViolation, error) {
+	var source string
+	var playedAt time.Time
+	err := h.db.QueryRow(`
+		SELECT source, played_at FROM playbacks
+		WHERE user_id = ? AND song_id = ? AND status = 'playing'
+		ORDER BY played_at DESC, id DESC
+		LIMIT 1`, user.ID, p.SongID,
+	).Scan(&source, &playedAt)
+	if err == sql.ErrNoRows || (err == nil && source != models.SourceRadio) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Sin un reporte de esta reproducción se compara con el inicio, usando
+	// la hora del servidor porque played_at también la usa
+	prevMs, prevAt, at := 0, playedAt, time.Now()
+	var lastMs int
+	var lastAt time.Time
+	var lastDevice string
+	err = h.db.QueryRow(
+		"SELECT position_ms, reported_at, device_id FROM playback_positions WHERE user_id = ? AND song_id = ?",
+		user.ID, p.SongID,
+	).Scan(&lastMs, &lastAt, &lastDevice)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil && lastDevice == p.DeviceID && !lastAt.Before(playedAt) {
+		prevMs, prevAt, at = lastMs, lastAt, p.ReportedAt
+	}
+	if !models.IsSeek(prevMs, prevAt, p.PositionMs, at) {
+		return nil, nil
+	}
+
+	ctx, err := h.playbackContext(user, models.ActionSeek, source, p.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	return h.rules.Check(ctx), nil
+}
+
+// playbackContext reúne el plan del usuario y los dispositivos que están
+// reproduciendo en este momento (sin contar el que hace la petición)
+func (h *PlaybackHandler) playbackContext(user *UserInfo, action, source, deviceID string) (models.PlaybackContext, error) {
+	plan, _, err := userPlan(h.db, user)
+	if err != nil {
+		return models.PlaybackContext{}, err
+	}
+
+	now := time.Now()
+	var active int
+	err = h.db.QueryRow(`
+		SELECT COUNT(*) FROM user_devices
+		WHERE user_id = ? AND revoked_at IS NULL AND streaming_at > ? AND device_id <> ?`,
+		user.ID, now.Add(-models.ActiveStreamWindow), deviceID,
+	).Scan(&active)
+	if err != nil {
+		return models.PlaybackContext{}, err
+	}
+
+	return models.PlaybackContext{
+		UserID:        user.ID,
+		Entitlements:  plan.Entitlements,
+		Action:        action,
+		Source:        source,
+		DeviceID:      deviceID,
+		ActiveStreams: active,
+		Now:           now,
+	}, nil
+}
+
+// writeRuleViolation escribe el error de una regla; devuelve false si err
+// no es una violación de regla
+func writeRuleViolation(w http.ResponseWriter, err error) bool {
+	v, ok := err.(*models.RuleViolation)
+	if !ok {
+		return false
+	}
+
+	status := http.StatusForbidden
+	switch v.Rule {
+	case "skip_limit":
+		status = http.StatusTooManyRequests
+		w.Header().Set("Retry-After", strconv.Itoa(v.RetryAfter))
+	case "concurrent_streams":
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, v)
+	return true
+}
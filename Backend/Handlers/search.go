@@ -0,0 +1,161 @@
+// Backend/Handlers/search.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase search, con sus respectivas
+funciones para la busqueda de canciones en el catalogo
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type SearchHandler struct {
+	db    *sql.DB
+	index *models.FuzzyIndex
+}
+
+// SearchResult es una canción encontrada junto con su puntaje de relevancia
+type SearchResult struct {
+	Song
+	Score float64 `json:"score"`
+}
+
+type SearchResponse struct {
+	Query    string         `json:"query"`
+	Results  []SearchResult `json:"results"`
+	Total    int            `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+func NewSearchHandler(db *sql.DB, index *models.FuzzyIndex) *SearchHandler {
+	return &SearchHandler{db: db, index: index}
+}
+
+// Search busca en el catálogo por título, artista, género y letra.
+// Parámetros: q, genre, artist, page, page_size.
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	query := params.Get("q")
+	tokens := models.Tokenize(query)
+	if len(tokens) == 0 {
+		http.Error(w, "Parámetro de búsqueda requerido", http.StatusBadRequest)
+		return
+	}
+
+	page, _ := strconv.Atoi(params.Get("page"))
+	pageSize, _ := strconv.Atoi(params.Get("page_size"))
+	page, pageSize = models.NormalizePage(page, pageSize)
+
+	matchSQL, args := buildTermMatch(tokens)
+
+	var filters []string
+	if genre := params.Get("genre"); genre != "" {
+		filters = append(filters, "s.genre = ?")
+		args = append(args, genre)
+	}
+	if artist := params.Get("artist"); artist != "" {
+		filters = append(filters, "s.artist = ?")
+		args = append(args, artist)
+	}
+	where := ""
+	if len(filters) > 0 {
+		where = "WHERE " + strings.Join(filters, " AND ")
+	}
+
+	// Solo cuentan las canciones que contienen todas las palabras buscadas
+	base := `
+		FROM (` + matchSQL + `) m
+		JOIN songs s ON s.id = m.song_id AND s.deleted_at IS NULL
+		` + where + `
+		GROUP BY s.id
+		HAVING COUNT(DISTINCT m.tok) = ` + strconv.Itoa(len(tokens))
+
+	var total int
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM (SELECT s.id "+base+") t", args...).Scan(&total); err != nil {
+		log.Printf("Error contando resultados de búsqueda: %v", err)
+		http.Error(w, "Error al buscar canciones", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT s.id, s.title, s.artist, s.genre, s.file_size, s.file_path, SUM(m.score) AS score
+		`+base+`
+		ORDER BY score DESC, s.title
+		LIMIT ? OFFSET ?`,
+		append(args, pageSize, (page-1)*pageSize)...,
+	)
+	if err != nil {
+		log.Printf("Error buscando canciones: %v", err)
+		http.Error(w, "Error al buscar canciones", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	response := SearchResponse{
+		Query:    query,
+		Results:  []SearchResult{},
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}
+	for rows.Next() {
+		var res SearchResult
+		if err := rows.Scan(&res.ID, &res.Title, &res.Artist, &res.Genre, &res.FileSize, &res.FilePath, &res.Score); err != nil {
+			http.Error(w, "Error al leer los resultados", http.StatusInternalServerError)
+			return
+		}
+		response.Results = append(response.Results, res)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// buildTermMatch arma una subconsulta con una fila por canción y palabra
+// buscada. Cada palabra se busca como prefijo; una coincidencia exacta vale
+// el doble que una parcial y se multiplica por el peso del campo.
+func buildTermMatch(tokens []string) (string, []interface{}) {
+	parts := make([]string, len(tokens))
+	args := make([]interface{}, 0, len(tokens)*2)
+	for i, token := range tokens {
+		parts[i] = `
+			SELECT song_id, ` + strconv.Itoa(i) + ` AS tok, MAX(weight * IF(term = ?, 2, 1)) AS score
+			FROM song_search_terms
+			WHERE term LIKE ?
+			GROUP BY song_id`
+		args = append(args, token, token+"%")
+	}
+	return strings.Join(parts, " UNION ALL "), args
+}
+
+// Suggest devuelve sugerencias mientras el usuario escribe, tolerando errores
+// de escritura. Se resuelve con el índice en memoria, sin consultar la base.
+func (h *SearchHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		http.Error(w, "Parámetro de búsqueda requerido", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	writeJSON(w, http.StatusOK, h.index.Suggest(query, limit))
+}
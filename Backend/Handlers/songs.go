@@ -11,19 +11,27 @@ funciones para el manejo de rutas
 package handlers
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
+
+	"PROYECTO_STREAMING/Backend/database"
+	"PROYECTO_STREAMING/Backend/models"
 )
 
 type SongHandler struct {
-	db *sql.DB
+	db    *sql.DB
+	index *models.FuzzyIndex
 }
 
 type Song struct {
@@ -32,37 +40,74 @@ type Song struct {
 	Artist   string `json:"artist"`
 	Genre    string `json:"genre"`
 	FileSize int    `json:"file_size"`
-	FilePath string `json:"file_path"`
+	FilePath string `json:"file_path,omitempty"`
+	Version  int    `json:"version,omitempty"`
+}
+
+// SongListItem es una canción del listado con los datos de favoritos
+type SongListItem struct {
+	Song
+	Album      string               `json:"album"`
+	IsFavorite bool                 `json:"is_favorite"`
+	LikeCount  int                  `json:"like_count"`
+	Loudness   *models.SongLoudness `json:"loudness"`             // nil mientras no se analiza
+	CoverHash  string               `json:"cover_hash,omitempty"` // Versión de la portada para la caché
 }
 
-func NewSongHandler(db *sql.DB) *SongHandler {
-	return &SongHandler{db: db}
+func NewSongHandler(db *sql.DB, index *models.FuzzyIndex) *SongHandler {
+	return &SongHandler{db: db, index: index}
 }
 
+// GetSongs lista las canciones. No incluye la ruta del archivo: el audio se
+// pide a /api/songs/{id}/stream con la calidad que permite el plan.
 func (h *SongHandler) GetSongs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
 		return
 	}
 
-	rows, err := h.db.Query("SELECT id, title, artist, genre, file_size, file_path FROM songs")
+	rows, err := h.db.Query(`
+		SELECT s.id, s.title, s.artist, s.genre, s.album, s.file_size, s.cover_hash, COUNT(f.user_id),
+		       ` + loudnessColumns + `
+		FROM songs s
+		LEFT JOIN user_favorites f ON f.song_id = s.id
+		LEFT JOIN song_loudness l ON l.song_id = s.id
+		WHERE s.deleted_at IS NULL
+		GROUP BY s.id, l.song_id`)
 	if err != nil {
 		http.Error(w, "Error al obtener canciones", http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
 
-	var songs []Song
+	var songs []SongListItem
 	for rows.Next() {
-		var song Song
-		err := rows.Scan(&song.ID, &song.Title, &song.Artist, &song.Genre, &song.FileSize, &song.FilePath)
+		var song SongListItem
+		var loudness loudnessRow
+		var coverHash sql.NullString
+		err := rows.Scan(append([]interface{}{&song.ID, &song.Title, &song.Artist, &song.Genre, &song.Album,
+			&song.FileSize, &coverHash, &song.LikeCount}, loudness.dest()...)...)
 		if err != nil {
 			http.Error(w, "Error al leer canción", http.StatusInternalServerError)
 			return
 		}
+		song.Loudness = loudness.value()
+		song.CoverHash = coverHash.String
 		songs = append(songs, song)
 	}
 
+	// is_favorite depende del usuario que consulta
+	if user, err := UserFromRequest(h.db, r); err == nil {
+		favorites, err := favoriteSet(h.db, user.ID)
+		if err != nil {
+			http.Error(w, "Error al obtener favoritos", http.StatusInternalServerError)
+			return
+		}
+		for i := range songs {
+			songs[i].IsFavorite = favorites[songs[i].ID]
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(songs)
 }
@@ -79,24 +124,50 @@ func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validar tamaño máximo (10MB)
-	if song.FileSize > 10*1024*1024 {
-		http.Error(w, "El tamaño del archivo excede el límite permitido", http.StatusBadRequest)
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
 		return
 	}
 
-	result, err := h.db.Exec(
-		"INSERT INTO songs (title, artist, genre, file_size, file_path) VALUES (?, ?, ?, ?, ?)",
-		song.Title, song.Artist, song.Genre, song.FileSize, song.FilePath,
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al guardar la canción", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Validar los límites del plan (tamaño, canciones y espacio)
+	quota, err := loadQuota(tx, user, true)
+	if err != nil {
+		http.Error(w, "Error al obtener la cuota", http.StatusInternalServerError)
+		return
+	}
+	if err := quota.CheckUpload(int64(song.FileSize)); err != nil {
+		writeQuotaError(w, err)
+		return
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO songs (title, artist, genre, file_size, file_path, uploaded_by) VALUES (?, ?, ?, ?, ?, ?)",
+		song.Title, song.Artist, song.Genre, song.FileSize, song.FilePath, user.ID,
 	)
 	if err != nil {
 		http.Error(w, "Error al guardar la canción", http.StatusInternalServerError)
 		return
 	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al guardar la canción", http.StatusInternalServerError)
+		return
+	}
 
 	id, _ := result.LastInsertId()
 	song.ID = int(id)
 
+	if err := database.IndexSong(song.ID, song.Title, song.Artist, song.Genre); err != nil {
+		log.Printf("Error indexando canción %d: %v", song.ID, err)
+	}
+	h.index.Upsert(song.ID, song.Title, song.Artist)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(song)
@@ -123,7 +194,7 @@ func (h *SongHandler) GetSongByID(w http.ResponseWriter, r *http.Request) {
 
 	var song Song
 	err = h.db.QueryRow(
-		"SELECT id, title, artist, genre, file_size, file_path FROM songs WHERE id = ?",
+		"SELECT id, title, artist, genre, file_size, file_path FROM songs WHERE id = ? AND deleted_at IS NULL",
 		id,
 	).Scan(&song.ID, &song.Title, &song.Artist, &song.Genre, &song.FileSize, &song.FilePath)
 
@@ -145,8 +216,33 @@ func (h *SongHandler) UploadSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Limitar el tamaño del archivo a 10MB
-	r.ParseMultipartForm(10 << 20)
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	// Revisar la cuota antes de recibir el archivo
+	quota, err := loadQuota(h.db, user, false)
+	if err != nil {
+		http.Error(w, "Error al obtener la cuota", http.StatusInternalServerError)
+		return
+	}
+	if err := quota.CheckUpload(0); err != nil {
+		writeQuotaError(w, err)
+		return
+	}
+
+	// Limitar el cuerpo al tamaño máximo por canción del plan (más la
+	// portada opcional)
+	if quota.Plan.MaxSongSize != models.Unlimited {
+		r.Body = http.MaxBytesReader(w, r.Body, quota.Plan.MaxSongSize+models.MaxCoverBytes+1<<20)
+	}
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		if !writeQuotaError(w, quota.CheckSongSize(r.ContentLength)) {
+			http.Error(w, "Error al leer el formulario", http.StatusBadRequest)
+		}
+		return
+	}
 
 	// Obtener el archivo del formulario
 	file, handler, err := r.FormFile("songFile")
@@ -156,9 +252,9 @@ func (h *SongHandler) UploadSong(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	// Validar el tamaño del archivo
-	if handler.Size > 10*1024*1024 {
-		http.Error(w, "El archivo excede el límite de 10MB", http.StatusBadRequest)
+	// Validar el tamaño del archivo y el espacio disponible
+	if err := quota.CheckUpload(handler.Size); err != nil {
+		writeQuotaError(w, err)
 		return
 	}
 
@@ -181,8 +277,9 @@ func (h *SongHandler) UploadSong(w http.ResponseWriter, r *http.Request) {
 	}
 	defer dst.Close()
 
-	// Copiar el contenido del archivo
-	if _, err := io.Copy(dst, file); err != nil {
+	// Copiar el contenido del archivo calculando su checksum (lo usa fsck)
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(dst, hash), file); err != nil {
 		http.Error(w, "Error al guardar el archivo", http.StatusInternalServerError)
 		return
 	}
@@ -191,12 +288,83 @@ func (h *SongHandler) UploadSong(w http.ResponseWriter, r *http.Request) {
 	title := r.FormValue("title")
 	artist := r.FormValue("artist")
 	genre := r.FormValue("genre")
+	album := strings.TrimSpace(r.FormValue("album"))
+
+	// Portada subida junto con la canción (opcional); si no viene se busca
+	// la incluida en el archivo al procesarlo
+	var coverHash, coverSource interface{}
+	if cover, coverHeader, err := r.FormFile("coverFile"); err == nil {
+		data, err := io.ReadAll(io.LimitReader(cover, models.MaxCoverBytes+1))
+		cover.Close()
+		if err != nil || coverHeader.Size > models.MaxCoverBytes || len(data) > models.MaxCoverBytes {
+			os.Remove(filePath)
+			http.Error(w, "La portada supera el tamaño máximo de 10 MB", http.StatusBadRequest)
+			return
+		}
+		hash, err := database.SaveCover(data)
+		if err == models.ErrInvalidCover {
+			os.Remove(filePath)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			os.Remove(filePath)
+			http.Error(w, "Error al guardar la portada", http.StatusInternalServerError)
+			return
+		}
+		coverHash, coverSource = hash, models.CoverUpload
+	}
+
+	// Letra opcional: texto en el campo "lyrics" o un archivo .lrc/.txt en
+	// "lyricsFile"; si no viene se busca la incluida en el archivo
+	lyrics := r.FormValue("lyrics")
+	if lyricsFile, _, err := r.FormFile("lyricsFile"); err == nil {
+		data, err := io.ReadAll(io.LimitReader(lyricsFile, models.MaxLyricsBytes+1))
+		lyricsFile.Close()
+		if err != nil {
+			os.Remove(filePath)
+			http.Error(w, "Error al leer la letra", http.StatusBadRequest)
+			return
+		}
+		lyrics = string(data)
+	}
+	var lyricsSource interface{}
+	if strings.TrimSpace(lyrics) != "" {
+		if lyrics, err = models.NormalizeLyrics(lyrics); err != nil {
+			os.Remove(filePath)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		lyricsSource = models.LyricsUpload
+	}
 
-	// Insertar en la base de datos
-	result, err := h.db.Exec(
-		"INSERT INTO songs (title, artist, genre, file_size, file_path) VALUES (?, ?, ?, ?, ?)",
-		title, artist, genre, handler.Size, filePath,
+	// Insertar en la base de datos, volviendo a revisar la cuota por si
+	// otra subida del mismo usuario terminó mientras se copiaba el archivo
+	tx, err := h.db.Begin()
+	if err != nil {
+		os.Remove(filePath)
+		http.Error(w, "Error al guardar en la base de datos", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if quota, err = loadQuota(tx, user, true); err != nil {
+		os.Remove(filePath)
+		http.Error(w, "Error al obtener la cuota", http.StatusInternalServerError)
+		return
+	}
+	if err := quota.CheckUpload(handler.Size); err != nil {
+		os.Remove(filePath)
+		writeQuotaError(w, err)
+		return
+	}
+
+	result, err := tx.Exec(
+		"INSERT INTO songs (title, artist, genre, album, file_size, file_path, checksum, cover_hash, cover_source, lyrics_source, uploaded_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		title, artist, genre, album, handler.Size, filePath, hex.EncodeToString(hash.Sum(nil)), coverHash, coverSource, lyricsSource, user.ID,
 	)
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
 		os.Remove(filePath) // Limpiar el archivo si hay error en la BD
 		http.Error(w, "Error al guardar en la base de datos", http.StatusInternalServerError)
@@ -205,6 +373,27 @@ func (h *SongHandler) UploadSong(w http.ResponseWriter, r *http.Request) {
 
 	id, _ := result.LastInsertId()
 
+	if lyricsSource != nil {
+		err := database.SaveLyrics(int(id), lyrics, models.DetectLyricsFormat(lyrics), "", models.LyricsUpload, user.ID)
+		if err != nil {
+			log.Printf("Error guardando letra de la canción %d: %v", id, err)
+		}
+	}
+
+	if err := database.IndexSong(int(id), title, artist, genre); err != nil {
+		log.Printf("Error indexando canción %d: %v", id, err)
+	}
+	h.index.Upsert(int(id), title, artist)
+
+	// La portada y la letra incluidas, la sonoridad, la forma de onda y las
+	// versiones de menor calidad se calculan en segundo plano: decodificar y codificar
+	// toman unos segundos
+	go func() {
+		if err := database.ProcessSong(int(id)); err != nil {
+			log.Printf("Error procesando la canción %d: %v", id, err)
+		}
+	}()
+
 	// Responder con éxito
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -213,3 +402,36 @@ func (h *SongHandler) UploadSong(w http.ResponseWriter, r *http.Request) {
 		"message": "Canción subida exitosamente",
 	})
 }
+
+// Download entrega el archivo de la canción para escucharla sin conexión.
+// Se registra con RequireEntitlement(FeatureOfflineDownloads).
+func (h *SongHandler) Download(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/offline/songs")
+	if len(segs) != 1 {
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+		return
+	}
+	id, err := strconv.Atoi(segs[0])
+	if err != nil {
+		http.Error(w, "ID de canción inválido", http.StatusBadRequest)
+		return
+	}
+
+	song, err := loadSongMetadata(h.db, id, false)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error al obtener la canción", http.StatusInternalServerError)
+		return
+	}
+
+	name := fmt.Sprintf("%s - %s%s", song.Artist, song.Title, filepath.Ext(song.FilePath))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeFile(w, r, database.SongFilePath(song.FilePath))
+}
@@ -0,0 +1,279 @@
+// Backend/Handlers/stats.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase stats, con sus respectivas
+funciones para las estadisticas de escucha y el resumen anual
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type StatsHandler struct {
+	db *sql.DB
+}
+
+func NewStatsHandler(db *sql.DB) *StatsHandler {
+	return &StatsHandler{db: db}
+}
+
+// Stats devuelve las estadísticas de escucha del usuario.
+// Parámetros: period (week, month o year) y date (AAAA-MM-DD, por defecto hoy).
+func (h *StatsHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	period, err := models.ParseStatsPeriod(r.URL.Query().Get("period"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ref := time.Now()
+	if raw := r.URL.Query().Get("date"); raw != "" {
+		if ref, err = time.ParseInLocation("2006-01-02", raw, time.Local); err != nil {
+			http.Error(w, "Fecha inválida, use AAAA-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	stats, err := listeningStats(h.db, user.ID, period, ref)
+	if err != nil {
+		log.Printf("Error calculando estadísticas del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al obtener las estadísticas", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// YearInReview devuelve el resumen anual del usuario.
+// Parámetros: year (por defecto el actual) y format (json o txt para descargarlo).
+func (h *StatsHandler) YearInReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+
+	year := time.Now().Year()
+	if raw := r.URL.Query().Get("year"); raw != "" {
+		var err error
+		if year, err = strconv.Atoi(raw); err != nil || year < 2000 || year > 9999 {
+			http.Error(w, "Año inválido", http.StatusBadRequest)
+			return
+		}
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "txt" {
+		http.Error(w, "Formato inválido, use json o txt", http.StatusBadRequest)
+		return
+	}
+
+	review, err := yearInReview(h.db, user, year)
+	if err != nil {
+		log.Printf("Error calculando resumen anual del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error al obtener el resumen anual", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "txt" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="resumen-%d.txt"`, year))
+		w.Write([]byte(review.Text()))
+		return
+	}
+	writeJSON(w, http.StatusOK, review)
+}
+
+// listeningStats calcula las estadísticas del periodo que contiene ref
+func listeningStats(q queryer, userID int, period models.StatsPeriod, ref time.Time) (*models.ListeningStats, error) {
+	from, to := period.Range(ref)
+	stats := &models.ListeningStats{Period: period, From: from, To: to}
+	fromDay, toDay := from.Format("2006-01-02"), to.Format("2006-01-02")
+
+	var seconds int
+	err := q.QueryRow(`
+		SELECT COALESCE(SUM(plays), 0), COALESCE(SUM(seconds), 0), COUNT(DISTINCT day)
+		FROM listening_daily
+		WHERE user_id = ? AND day >= ? AND day < ?`,
+		userID, fromDay, toDay,
+	).Scan(&stats.TotalPlays, &seconds, &stats.DaysListened)
+	if err != nil {
+		return nil, err
+	}
+	stats.TotalMinutes = seconds / 60
+
+	rankings := []struct {
+		target *[]models.RankedItem
+		query  string
+	}{
+		{&stats.TopSongs, `
+			SELECT d.song_id, s.title, s.artist, SUM(d.plays) AS p, SUM(d.seconds) AS sec
+			FROM listening_daily d JOIN songs s ON s.id = d.song_id
+			WHERE d.user_id = ? AND d.day >= ? AND d.day < ?
+			GROUP BY d.song_id, s.title, s.artist
+			ORDER BY p DESC, sec DESC, d.song_id
+			LIMIT ?`},
+		{&stats.TopArtists, `
+			SELECT 0, s.artist, '', SUM(d.plays) AS p, SUM(d.seconds) AS sec
+			FROM listening_daily d JOIN songs s ON s.id = d.song_id
+			WHERE d.user_id = ? AND d.day >= ? AND d.day < ?
+			GROUP BY s.artist
+			ORDER BY sec DESC, p DESC, s.artist
+			LIMIT ?`},
+		{&stats.TopGenres, `
+			SELECT 0, s.genre, '', SUM(d.plays) AS p, SUM(d.seconds) AS sec
+			FROM listening_daily d JOIN songs s ON s.id = d.song_id
+			WHERE d.user_id = ? AND d.day >= ? AND d.day < ?
+			GROUP BY s.genre
+			ORDER BY sec DESC, p DESC, s.genre
+			LIMIT ?`},
+	}
+	for _, ranking := range rankings {
+		items, err := rankedItems(q, ranking.query, userID, fromDay, toDay, models.StatsTopLimit)
+		if err != nil {
+			return nil, err
+		}
+		*ranking.target = items
+	}
+
+	rows, err := q.Query(`
+		SELECT hour, SUM(seconds) FROM listening_hours
+		WHERE user_id = ? AND day >= ? AND day < ?
+		GROUP BY hour`,
+		userID, fromDay, toDay,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var hour, sec int
+		if err := rows.Scan(&hour, &sec); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if hour >= 0 && hour < 24 {
+			stats.HourMinutes[hour] = sec / 60
+		}
+	}
+	rows.Close()
+
+	// Las rachas se miden hasta el fin del periodo (o hasta hoy si no terminó)
+	today := time.Now()
+	if last := to.AddDate(0, 0, -1); last.Before(today) {
+		today = last
+	}
+	rows, err = q.Query(
+		"SELECT DISTINCT day FROM listening_daily WHERE user_id = ? AND day < ? ORDER BY day",
+		userID, toDay,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var days []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	stats.Streaks = models.ComputeStreaks(days, today)
+
+	return stats, nil
+}
+
+func rankedItems(q queryer, query string, userID int, fromDay, toDay string, limit int) ([]models.RankedItem, error) {
+	rows, err := q.Query(query, userID, fromDay, toDay, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.RankedItem{}
+	for rows.Next() {
+		var item models.RankedItem
+		var seconds int
+		if err := rows.Scan(&item.SongID, &item.Name, &item.Artist, &item.Plays, &seconds); err != nil {
+			return nil, err
+		}
+		item.Minutes = seconds / 60
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// yearInReview arma el resumen anual con el detalle mensual
+func yearInReview(q queryer, user *UserInfo, year int) (*models.YearInReview, error) {
+	ref := time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
+	stats, err := listeningStats(q, user.ID, models.StatsYear, ref)
+	if err != nil {
+		return nil, err
+	}
+	review := &models.YearInReview{Year: year, UserName: user.Name, Stats: *stats}
+	fromDay, toDay := stats.From.Format("2006-01-02"), stats.To.Format("2006-01-02")
+
+	rows, err := q.Query(`
+		SELECT MONTH(day), SUM(seconds) FROM listening_daily
+		WHERE user_id = ? AND day >= ? AND day < ?
+		GROUP BY MONTH(day)`,
+		user.ID, fromDay, toDay,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var month, seconds int
+		if err := rows.Scan(&month, &seconds); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if month >= 1 && month <= 12 {
+			review.MonthlyMinutes[month-1] = seconds / 60
+		}
+	}
+	rows.Close()
+
+	// Artistas escuchados por primera vez durante el año
+	err = q.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT s.artist, MIN(d.day) AS first_day
+			FROM listening_daily d JOIN songs s ON s.id = d.song_id
+			WHERE d.user_id = ?
+			GROUP BY s.artist
+		) t
+		WHERE t.first_day >= ? AND t.first_day < ?`,
+		user.ID, fromDay, toDay,
+	).Scan(&review.NewArtistsFound)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Finalize()
+	return review, nil
+}
@@ -0,0 +1,123 @@
+// Backend/Handlers/stream.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Entrega del audio de una cancion en la calidad pedida o en la
+maxima que permite el plan del usuario
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"PROYECTO_STREAMING/Backend/database"
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// Stream atiende GET /api/songs/{id}/stream?quality=low|normal|high|original.
+// Sin quality se usa la máxima calidad del plan; el reproductor pide una
+// menor cuando la conexión es lenta. El token puede ir en ?token= porque
+// <audio> no manda la cabecera Authorization.
+// Si el original es igual o más liviano que la calidad pedida se entrega el
+// original; si la versión todavía no se generó responde 503 y si no se pudo
+// generar 409, para no entregar un original más pesado del que permite el
+// plan. Soporta Range para adelantar.
+func (h *SongHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+		return
+	}
+
+	segs := pathSegments(r.URL.Path, "/api/songs")
+	if len(segs) != 2 || segs[1] != "stream" {
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+		return
+	}
+	songID, err := strconv.Atoi(segs[0])
+	if err != nil {
+		http.Error(w, "ID de canción inválido", http.StatusBadRequest)
+		return
+	}
+
+	user, ok := requireUser(h.db, w, r)
+	if !ok {
+		return
+	}
+	plan, _, err := userPlan(h.db, user)
+	if err != nil {
+		log.Printf("Error obteniendo plan del usuario %d: %v", user.ID, err)
+		http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
+		return
+	}
+
+	quality := r.URL.Query().Get("quality")
+	if quality == "" {
+		quality = plan.Entitlements.MaxAudioQuality
+	}
+	if quality != models.QualityOriginal && !slices.Contains(models.Qualities, quality) {
+		http.Error(w, "Calidad inválida", http.StatusBadRequest)
+		return
+	}
+
+	// El original puede tener cualquier tasa, así que requiere la calidad alta
+	required := quality
+	if quality == models.QualityOriginal {
+		required = models.QualityHigh
+	}
+	if !plan.Entitlements.AllowsQuality(required) {
+		writeJSON(w, http.StatusPaymentRequired, map[string]interface{}{
+			"error":       "Tu plan no incluye esta calidad de audio",
+			"feature":     models.FeatureHighQuality,
+			"plan":        plan.Name,
+			"plans":       models.PlansWith(models.FeatureHighQuality),
+			"max_quality": plan.Entitlements.MaxAudioQuality,
+		})
+		return
+	}
+
+	var filePath string
+	var renditionPath sql.NullString
+	var bitrate sql.NullInt64
+	var serveOriginal sql.NullBool
+	err = h.db.QueryRow(`
+		SELECT s.file_path, r.file_path, r.bitrate, r.serve_original
+		FROM songs s
+		LEFT JOIN song_renditions r ON r.song_id = s.id AND r.quality = ?
+		WHERE s.id = ? AND s.deleted_at IS NULL`, quality, songID,
+	).Scan(&filePath, &renditionPath, &bitrate, &serveOriginal)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error obteniendo audio de la canción %d: %v", songID, err)
+		http.Error(w, "Error al obtener la canción", http.StatusInternalServerError)
+		return
+	}
+
+	// Sin fila la canción todavía se está procesando
+	if quality != models.QualityOriginal && !renditionPath.Valid && !serveOriginal.Bool {
+		if !bitrate.Valid {
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, "La canción todavía se está procesando, intente más tarde", http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, "La canción no está disponible en esta calidad", http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	if renditionPath.Valid {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Header().Set("X-Audio-Quality", quality)
+		w.Header().Set("X-Audio-Bitrate", strconv.FormatInt(bitrate.Int64, 10))
+		http.ServeFile(w, r, renditionPath.String)
+		return
+	}
+	w.Header().Set("X-Audio-Quality", models.QualityOriginal)
+	http.ServeFile(w, r, database.SongFilePath(filePath))
+}
@@ -0,0 +1,186 @@
+// Backend/Handlers/trash.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Asignacion de la clase trash, con sus respectivas
+funciones para eliminar canciones, restaurarlas desde la papelera
+y borrarlas definitivamente
+*/
+
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/database"
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+type TrashHandler struct {
+	db    *sql.DB
+	index *models.FuzzyIndex
+}
+
+func NewTrashHandler(db *sql.DB, index *models.FuzzyIndex) *TrashHandler {
+	return &TrashHandler{db: db, index: index}
+}
+
+// deleteSong envía la canción a la papelera. Deja de aparecer en el
+// catálogo, la búsqueda, las playlists y los favoritos, pero se puede
+// restaurar hasta que pase models.TrashRetention.
+func (h *SongHandler) deleteSong(w http.ResponseWriter, user *UserInfo, songID int) {
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Error al eliminar la canción", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(
+		"UPDATE songs SET deleted_at = ?, deleted_by = ? WHERE id = ? AND deleted_at IS NULL",
+		now, user.ID, songID,
+	)
+	if err != nil {
+		log.Printf("Error eliminando canción %d: %v", songID, err)
+		http.Error(w, "Error al eliminar la canción", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "Canción no encontrada", http.StatusNotFound)
+		return
+	}
+	if _, err := tx.Exec("DELETE FROM song_search_terms WHERE song_id = ?", songID); err != nil {
+		http.Error(w, "Error al eliminar la canción", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error al eliminar la canción", http.StatusInternalServerError)
+		return
+	}
+	h.index.Remove(songID)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":       songID,
+		"purge_at": models.PurgeTime(now),
+		"message":  "Canción enviada a la papelera",
+	})
+}
+
+// TrashRoutes atiende las rutas de la papelera bajo /api/admin/trash:
+// GET /api/admin/trash, POST /{id}/restore y DELETE /{id} (borrado definitivo)
+func (h *TrashHandler) TrashRoutes(w http.ResponseWriter, r *http.Request) {
+	segs := pathSegments(r.URL.Path, "/api/admin/trash")
+	if len(segs) == 0 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
+			return
+		}
+		h.list(w, r)
+		return
+	}
+
+	songID, err := strconv.Atoi(segs[0])
+	if err != nil {
+		http.Error(w, "ID de canción inválido", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(segs) == 2 && segs[1] == "restore" && r.Method == http.MethodPost:
+		h.restore(w, songID)
+	case len(segs) == 1 && r.Method == http.MethodDelete:
+		h.purge(w, songID)
+	default:
+		http.Error(w, "Ruta no encontrada", http.StatusNotFound)
+	}
+}
+
+// list devuelve las canciones en la papelera, las eliminadas más recientemente primero
+func (h *TrashHandler) list(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	page, pageSize = models.NormalizePage(page, pageSize)
+
+	rows, err := h.db.Query(`
+		SELECT id, title, artist, genre, file_size, deleted_at, deleted_by
+		FROM songs
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT ? OFFSET ?`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		log.Printf("Error listando la papelera: %v", err)
+		http.Error(w, "Error al obtener la papelera", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	songs := []models.TrashedSong{}
+	for rows.Next() {
+		var s models.TrashedSong
+		var deletedBy sql.NullInt64
+		if err := rows.Scan(&s.ID, &s.Title, &s.Artist, &s.Genre, &s.FileSize, &s.DeletedAt, &deletedBy); err != nil {
+			http.Error(w, "Error al leer la papelera", http.StatusInternalServerError)
+			return
+		}
+		if deletedBy.Valid {
+			id := int(deletedBy.Int64)
+			s.DeletedBy = &id
+		}
+		s.PurgeAt = models.PurgeTime(s.DeletedAt)
+		songs = append(songs, s)
+	}
+
+	writeJSON(w, http.StatusOK, songs)
+}
+
+// restore saca la canción de la papelera y la vuelve a indexar
+func (h *TrashHandler) restore(w http.ResponseWriter, songID int) {
+	result, err := h.db.Exec(
+		"UPDATE songs SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND deleted_at IS NOT NULL",
+		songID,
+	)
+	if err != nil {
+		log.Printf("Error restaurando canción %d: %v", songID, err)
+		http.Error(w, "Error al restaurar la canción", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "La canción no está en la papelera", http.StatusNotFound)
+		return
+	}
+
+	song, err := loadSongMetadata(h.db, songID, false)
+	if err != nil {
+		http.Error(w, "Error al obtener la canción", http.StatusInternalServerError)
+		return
+	}
+	if err := database.IndexSong(song.ID, song.Title, song.Artist, song.Genre); err != nil {
+		log.Printf("Error indexando canción %d: %v", song.ID, err)
+	}
+	h.index.Upsert(song.ID, song.Title, song.Artist)
+
+	writeJSON(w, http.StatusOK, song)
+}
+
+// purge borra la canción definitivamente sin esperar a que venza el plazo
+func (h *TrashHandler) purge(w http.ResponseWriter, songID int) {
+	if err := database.PurgeSong(songID); err == database.ErrNotInTrash {
+		http.Error(w, "La canción no está en la papelera", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error borrando definitivamente la canción %d: %v", songID, err)
+		http.Error(w, "Error al borrar la canción", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := database.CollectFileGarbage(); err != nil {
+		log.Printf("Error borrando archivos de canciones: %v", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
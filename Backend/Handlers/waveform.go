@@ -0,0 +1,91 @@
+// Backend/Handlers/waveform.go
+/*Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descipcion: Entrega de la forma de onda de una cancion para dibujar la
+barra de progreso del reproductor
+*/
+
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"PROYECTO_STREAMING/Backend/models"
+)
+
+// waveform atiende GET /api/songs/{id}/waveform. Por defecto entrega el
+// archivo binario (ver models.Waveform.MarshalBinary); con ?format=json lo
+// entrega en JSON y ?peaks=N deja solo la resolución más cercana a N.
+// La forma de onda no cambia mientras no se regenere, así que se puede
+// guardar en caché y se valida con su ETag.
+func (h *SongHandler) waveform(w http.ResponseWriter, r *http.Request, songID int) {
+	var filePath string
+	var generatedAt time.Time
+	err := h.db.QueryRow(`
+		SELECT wf.file_path, wf.generated_at
+		FROM song_waveforms wf
+		JOIN songs s ON s.id = wf.song_id AND s.deleted_at IS NULL
+		WHERE wf.song_id = ?`, songID,
+	).Scan(&filePath, &generatedAt)
+	if err == sql.ErrNoRows {
+		http.Error(w, "La forma de onda no está disponible", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error obteniendo forma de onda de la canción %d: %v", songID, err)
+		http.Error(w, "Error al obtener la forma de onda", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		http.Error(w, "La forma de onda no está disponible", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error leyendo forma de onda %s: %v", filePath, err)
+		http.Error(w, "Error al obtener la forma de onda", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	peaks, _ := strconv.Atoi(query.Get("peaks"))
+
+	etag := fmt.Sprintf(`"waveform-%d-%d`, songID, generatedAt.UnixMilli())
+	if format == "json" {
+		etag += "-json-" + strconv.Itoa(peaks)
+	}
+	etag += `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+
+	if format != "json" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, "", generatedAt, bytes.NewReader(data))
+		return
+	}
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	var waveform models.Waveform
+	if err := waveform.UnmarshalBinary(data); err != nil {
+		log.Printf("Forma de onda inválida %s: %v", filePath, err)
+		http.Error(w, "Error al obtener la forma de onda", http.StatusInternalServerError)
+		return
+	}
+	if peaks > 0 {
+		if level := waveform.Level(peaks); level != nil {
+			waveform.Levels = []models.WaveformLevel{*level}
+		}
+	}
+	writeJSON(w, http.StatusOK, waveform)
+}
@@ -8,7 +8,8 @@ CREATE TABLE users (
     name VARCHAR(100) NOT NULL,
     email VARCHAR(100) UNIQUE NOT NULL,
     password VARCHAR(255) NOT NULL,
-    role ENUM('admin', 'user') NOT NULL DEFAULT 'user',
+    role ENUM('admin', 'curator', 'user') NOT NULL DEFAULT 'user',
+    plan VARCHAR(20) NOT NULL DEFAULT 'free',   -- free, premium (los admin usan el plan admin)
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -18,9 +19,20 @@ CREATE TABLE songs (
     title VARCHAR(255) NOT NULL,
     artist VARCHAR(255) NOT NULL,
     genre VARCHAR(100) NOT NULL,
+    album VARCHAR(255) NOT NULL DEFAULT '',  -- Agrupa pistas para la ganancia de álbum
     file_size INT NOT NULL,
     file_path VARCHAR(255) NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    checksum CHAR(64) NULL,               -- SHA-256 del archivo al subirlo
+    cover_hash CHAR(64) NULL,             -- Portada en ./covers/<hash>/
+    cover_source ENUM('embedded', 'upload', 'none') NULL, -- NULL = todavía no se buscó
+    lyrics_source ENUM('embedded', 'upload', 'edit', 'none') NULL, -- NULL = todavía no se buscó
+    uploaded_by INT NULL,                 -- Usuario al que se le descuenta de la cuota
+    version INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,            -- En la papelera desde esta fecha
+    deleted_by INT NULL,
+    INDEX idx_songs_deleted (deleted_at),
+    INDEX idx_songs_uploaded_by (uploaded_by, created_at)
 );
 
 -- Tabla de bibliotecas de usuario
@@ -50,6 +62,8 @@ CREATE TABLE playbacks (
     played_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     status ENUM('playing', 'paused', 'completed') NOT NULL,
     duration INT NOT NULL DEFAULT 0,
+    source VARCHAR(16) NOT NULL DEFAULT '', -- radio, queue, ... (para las reglas de escucha)
+    INDEX idx_playbacks_user (user_id, played_at),
     FOREIGN KEY (user_id) REFERENCES users(id),
     FOREIGN KEY (song_id) REFERENCES songs(id)
 );
@@ -65,3 +79,356 @@ INSERT INTO users (name, email, password, role) VALUES
 ('Ana Gomez', 'ana.gomez@example.com', 'securepass456', 'user'),
 ('Carlos Lopez', 'carlos.lopez@example.com', 'qwerty789', 'user'); 
 
+
+-- Tabla de playlists de usuario
+CREATE TABLE playlists (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    is_public BOOLEAN NOT NULL DEFAULT FALSE,
+    collaborative BOOLEAN NOT NULL DEFAULT FALSE,
+    share_token VARCHAR(64) UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Canciones de cada playlist (position deja huecos para reordenar sin renumerar)
+CREATE TABLE playlist_songs (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    playlist_id INT NOT NULL,
+    song_id INT NOT NULL,
+    position BIGINT NOT NULL,
+    added_by INT NOT NULL,
+    added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_playlist_position (playlist_id, position),
+    FOREIGN KEY (playlist_id) REFERENCES playlists(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id),
+    FOREIGN KEY (added_by) REFERENCES users(id)
+);
+
+-- Editores invitados a playlists colaborativas
+CREATE TABLE playlist_collaborators (
+    playlist_id INT NOT NULL,
+    user_id INT NOT NULL,
+    added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (playlist_id, user_id),
+    FOREIGN KEY (playlist_id) REFERENCES playlists(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Cola de reproducción por usuario (estado completo serializado en JSON)
+CREATE TABLE play_queues (
+    user_id INT PRIMARY KEY,
+    state JSON NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Última posición reportada por usuario y canción (para continuar en otro dispositivo)
+CREATE TABLE playback_positions (
+    user_id INT NOT NULL,
+    song_id INT NOT NULL,
+    position_ms INT NOT NULL DEFAULT 0,
+    duration_ms INT NOT NULL DEFAULT 0,
+    device_id VARCHAR(64) NOT NULL DEFAULT '',
+    reported_at TIMESTAMP(3) NOT NULL,
+    PRIMARY KEY (user_id, song_id),
+    INDEX idx_user_reported (user_id, reported_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id)
+);
+
+-- Índice de búsqueda: palabras normalizadas (minúsculas, sin acentos) por canción
+CREATE TABLE song_search_terms (
+    song_id INT NOT NULL,
+    term VARCHAR(100) NOT NULL,
+    field ENUM('title', 'artist', 'genre', 'lyrics') NOT NULL,
+    weight INT NOT NULL,
+    PRIMARY KEY (song_id, term),
+    INDEX idx_term (term),
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+-- Canciones favoritas de cada usuario (una fila por usuario y canción)
+CREATE TABLE IF NOT EXISTS user_favorites (
+    user_id INT NOT NULL,
+    song_id INT NOT NULL,
+    liked_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    PRIMARY KEY (user_id, song_id),
+    INDEX idx_user_liked (user_id, liked_at),
+    INDEX idx_song (song_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+-- Índice para leer el historial reciente al calcular recomendaciones
+CREATE INDEX idx_playbacks_played_at ON playbacks (played_at, user_id, song_id);
+
+-- Radios automáticas (semilla, canciones generadas y opiniones en JSON)
+CREATE TABLE radio_stations (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    state JSON NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_user_updated (user_id, updated_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Acumulados de escucha por usuario, día y canción (estadísticas).
+-- imported separa el historial importado, que no cuenta en las analíticas.
+CREATE TABLE listening_daily (
+    user_id INT NOT NULL,
+    day DATE NOT NULL,
+    song_id INT NOT NULL,
+    imported BOOLEAN NOT NULL DEFAULT FALSE,
+    plays INT NOT NULL DEFAULT 0,
+    seconds INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day, song_id, imported),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+-- Segundos escuchados por usuario, día y hora (histograma por hora del día)
+CREATE TABLE listening_hours (
+    user_id INT NOT NULL,
+    day DATE NOT NULL,
+    hour TINYINT NOT NULL,
+    seconds INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, day, hour),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Analíticas de administrador: acumulados recalculados periódicamente
+CREATE TABLE analytics_daily (
+    day DATE PRIMARY KEY,
+    active_users INT NOT NULL DEFAULT 0,
+    plays INT NOT NULL DEFAULT 0,
+    listen_seconds BIGINT NOT NULL DEFAULT 0,
+    uploads INT NOT NULL DEFAULT 0,
+    upload_bytes BIGINT NOT NULL DEFAULT 0,
+    signups INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE analytics_monthly (
+    month DATE PRIMARY KEY,
+    active_users INT NOT NULL DEFAULT 0,
+    plays INT NOT NULL DEFAULT 0,
+    listen_seconds BIGINT NOT NULL DEFAULT 0,
+    uploads INT NOT NULL DEFAULT 0,
+    upload_bytes BIGINT NOT NULL DEFAULT 0,
+    signups INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE analytics_song_plays (
+    song_id INT PRIMARY KEY,
+    plays INT NOT NULL DEFAULT 0,
+    last_played DATE,
+    INDEX idx_plays (plays),
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+CREATE TABLE analytics_genre_storage (
+    genre VARCHAR(100) PRIMARY KEY,
+    songs INT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE analytics_funnel (
+    month DATE PRIMARY KEY,
+    signups INT NOT NULL DEFAULT 0,
+    activated INT NOT NULL DEFAULT 0,
+    engaged INT NOT NULL DEFAULT 0,
+    retained INT NOT NULL DEFAULT 0
+);
+
+-- Fecha del último recálculo de las analíticas (una sola fila)
+CREATE TABLE analytics_refreshes (
+    id TINYINT PRIMARY KEY,
+    refreshed_at TIMESTAMP NOT NULL
+);
+
+-- Reportes de problemas enviados por los usuarios
+CREATE TABLE reports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    song_id INT NULL,
+    title VARCHAR(150) NOT NULL,
+    description TEXT NOT NULL,
+    status ENUM('open', 'in_progress', 'resolved') NOT NULL DEFAULT 'open',
+    assigned_to INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_reports_status (status, created_at),
+    INDEX idx_reports_user (user_id, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE SET NULL,
+    FOREIGN KEY (assigned_to) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE report_comments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    report_id INT NOT NULL,
+    user_id INT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE report_attachments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    report_id INT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    file_path VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE
+);
+
+-- Avisos para el usuario (por ejemplo, cambios de estado de sus reportes)
+CREATE TABLE notifications (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    message VARCHAR(500) NOT NULL,
+    data JSON NULL,
+    read_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_notifications_user (user_id, read_at, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Historial de cambios de los datos de cada canción. Cada fila guarda los
+-- valores completos de una versión y los campos que cambiaron.
+CREATE TABLE song_revisions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    song_id INT NOT NULL,
+    version INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    artist VARCHAR(255) NOT NULL,
+    genre VARCHAR(100) NOT NULL,
+    changes JSON NULL,
+    reverted_from INT NULL,
+    changed_by INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_song_version (song_id, version),
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Archivos de canciones borradas definitivamente que falta eliminar del disco
+CREATE TABLE file_deletions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    file_path VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Suscripciones a planes pagos. Un plan cancelado sigue vigente hasta
+-- current_period_end.
+CREATE TABLE user_subscriptions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    plan VARCHAR(20) NOT NULL,
+    status ENUM('active', 'canceled', 'past_due', 'expired') NOT NULL,
+    provider VARCHAR(30) NOT NULL,
+    provider_ref VARCHAR(100) NOT NULL,
+    auto_renew BOOLEAN NOT NULL DEFAULT TRUE,
+    started_at TIMESTAMP NOT NULL,
+    current_period_end TIMESTAMP NOT NULL,
+    canceled_at TIMESTAMP NULL,
+    ended_at TIMESTAMP NULL,
+    UNIQUE KEY uq_provider_ref (provider, provider_ref),
+    INDEX idx_subscriptions_user (user_id, id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Eventos recibidos del proveedor de pagos (para no aplicarlos dos veces)
+CREATE TABLE billing_events (
+    event_id VARCHAR(100) PRIMARY KEY,
+    provider VARCHAR(30) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    subscription_ref VARCHAR(100) NOT NULL,
+    payload JSON NOT NULL,
+    received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Dispositivos con sesión del usuario. session_token liga el token de acceso
+-- al dispositivo y se borra al revocarlo; streaming_at es el último heartbeat
+-- mientras sonaba música.
+CREATE TABLE user_devices (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    device_id VARCHAR(64) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    type ENUM('web', 'mobile', 'desktop', 'tv', 'speaker') NOT NULL DEFAULT 'web',
+    session_token CHAR(48) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    streaming_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    UNIQUE KEY uq_user_device (user_id, device_id),
+    UNIQUE KEY uq_device_session (session_token),
+    INDEX idx_devices_streaming (user_id, streaming_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Sonoridad de cada canción (EBU R128). histogram guarda los bloques de
+-- 400 ms para calcular la ganancia del álbum sin volver a decodificar;
+-- error se completa si el archivo no se pudo analizar.
+CREATE TABLE song_loudness (
+    song_id INT PRIMARY KEY,
+    integrated_lufs DOUBLE NULL,          -- NULL si la pista es silencio
+    true_peak_dbtp DOUBLE NULL,
+    track_gain_db DOUBLE NULL,
+    track_peak DOUBLE NULL,
+    album_gain_db DOUBLE NULL,
+    album_peak DOUBLE NULL,
+    histogram JSON NULL,
+    duration_seconds DOUBLE NULL,
+    error VARCHAR(255) NULL,
+    analyzed_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+-- Forma de onda de cada canción (picos en varias resoluciones). El archivo
+-- binario vive en ./waveforms; generated_at se usa para los ETag.
+CREATE TABLE song_waveforms (
+    song_id INT PRIMARY KEY,
+    file_path VARCHAR(255) NOT NULL,
+    duration_ms INT NOT NULL,
+    size INT NOT NULL,
+    generated_at TIMESTAMP(3) NOT NULL,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
+
+-- Letra de cada canción en texto plano o LRC ([mm:ss.xx] por línea). El
+-- origen (archivo, subida o edición) queda en songs.lyrics_source.
+CREATE TABLE song_lyrics (
+    song_id INT PRIMARY KEY,
+    format ENUM('plain', 'lrc') NOT NULL,
+    body MEDIUMTEXT NOT NULL,
+    language CHAR(3) NULL,                -- ISO 639-2
+    updated_by INT NULL,
+    updated_at TIMESTAMP(3) DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE,
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Versiones de menor tasa de bits de cada canción (./uploads/songs/renditions).
+-- file_path NULL: no hay versión; si serve_original es verdadero el original
+-- es igual o más liviano y se sirve en su lugar, si no la calidad no está
+-- disponible (por ejemplo un formato que MP3 no admite).
+CREATE TABLE song_renditions (
+    song_id INT NOT NULL,
+    quality ENUM('low', 'normal', 'high') NOT NULL,
+    bitrate SMALLINT NOT NULL,            -- kbps
+    file_path VARCHAR(255) NULL,
+    serve_original BOOLEAN NOT NULL DEFAULT FALSE,
+    size BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (song_id, quality),
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE
+);
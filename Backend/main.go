@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bytes"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"PROYECTO_STREAMING/Backend/database"
 	"PROYECTO_STREAMING/Backend/handlers"
@@ -33,6 +38,11 @@ type StreamingSystem struct {
 	library       *models.Library
 	currentUser   *models.Usuario
 	currentPlayer *models.Playback
+	events        *models.EventHub
+	searchIndex   *models.FuzzyIndex
+	recommender   *models.Recommender
+	payments      models.PaymentProvider
+	rules         *models.RulesEngine
 	mu            sync.RWMutex
 }
 
@@ -40,11 +50,50 @@ type StreamingSystem struct {
 func NewStreamingSystem(db *sql.DB) (*StreamingSystem, error) {
 	library := models.NewLibrary(1) // ID por defecto para pruebas
 	return &StreamingSystem{
-		db:      db,
-		library: library,
+		db:          db,
+		library:     library,
+		events:      models.NewEventHub(),
+		searchIndex: models.NewFuzzyIndex(),
+		recommender: models.NewRecommender(),
+		payments:    models.NewFakePaymentProvider(billingWebhookSecret()),
+		rules:       models.NewRulesEngine(models.DefaultRules()...),
 	}, nil
 }
 
+// configureStreamLimits permite cambiar el límite de reproducciones
+// simultáneas de cada plan con variables MAX_STREAMS_<PLAN> (por ejemplo
+// MAX_STREAMS_FREE=2; -1 es ilimitado)
+func configureStreamLimits() {
+	for name, plan := range models.Plans {
+		value := os.Getenv("MAX_STREAMS_" + strings.ToUpper(name))
+		if value == "" {
+			continue
+		}
+		limit, err := strconv.Atoi(value)
+		if err != nil || (limit < 1 && limit != models.Unlimited) {
+			log.Printf("Valor inválido para MAX_STREAMS_%s: %q", strings.ToUpper(name), value)
+			continue
+		}
+		plan.Entitlements.MaxStreams = limit
+		models.Plans[name] = plan
+	}
+}
+
+// billingWebhookSecret es la clave con la que se firman los webhooks de
+// pagos. Sin BILLING_WEBHOOK_SECRET se genera una aleatoria al iniciar, que
+// no sobrevive a un reinicio.
+func billingWebhookSecret() string {
+	if secret := os.Getenv("BILLING_WEBHOOK_SECRET"); secret != "" {
+		return secret
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("Error generando la clave de los webhooks de pagos: %v", err)
+	}
+	log.Println("Advertencia: BILLING_WEBHOOK_SECRET no está definida, se usa una clave aleatoria para los webhooks de pagos")
+	return hex.EncodeToString(key)
+}
+
 // Implementación de métodos de la interfaz MusicManager
 func (s *StreamingSystem) AddSong(song *models.Song) error {
 	s.mu.Lock()
@@ -100,18 +149,6 @@ func (s *StreamingSystem) GetLibrary() ([]*models.Song, error) {
 }
 
 // NUEVAS FUNCIONES INTEGRADAS
-func (s *StreamingSystem) AddFavorite(userID, songID int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.library.AddFavorite(userID, songID)
-}
-
-func (s *StreamingSystem) RemoveFavorite(userID, songID int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	return s.library.RemoveFavorite(userID, songID)
-}
-
 func (s *StreamingSystem) SearchSongs(query string) ([]*models.Song, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -194,7 +231,12 @@ func initializeDatabase(db *sql.DB) error {
 
 		// Verificar si la canción ya existe en la base de datos
 		var exists bool
-		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM songs WHERE file_path = ?)", file.Name()).Scan(&exists)
+		// También se saltan los archivos de canciones borradas que aún no se eliminaron del disco
+		err := db.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM songs WHERE file_path = ?)
+			    OR EXISTS(SELECT 1 FROM file_deletions WHERE file_path = ? OR file_path LIKE ?)`,
+			file.Name(), file.Name(), "%/"+file.Name(),
+		).Scan(&exists)
 		if err != nil {
 			log.Printf("Error verificando canción %s: %v", file.Name(), err)
 			continue
@@ -283,17 +325,18 @@ func adminMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// Extraer token
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			http.Error(w, "Formato de token inválido", http.StatusUnauthorized)
+		// El token debe ser de una sesión válida de un administrador; el
+		// prefijo admin-token solo no alcanza porque cualquiera puede armarlo
+		user, err := handlers.UserFromRequest(database.GetDB(), r)
+		if err == handlers.ErrInvalidToken {
+			http.Error(w, "No autorizado", http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			log.Printf("Error validando token de administrador: %v", err)
+			http.Error(w, "Error interno del servidor", http.StatusInternalServerError)
 			return
 		}
-
-		token := tokenParts[1]
-
-		// Validar token de admin (simplificado para el ejemplo)
-		if !strings.HasPrefix(token, "admin-token") {
+		if user.Role != "admin" {
 			http.Error(w, "No tienes permisos de administrador", http.StatusForbidden)
 			return
 		}
@@ -307,15 +350,12 @@ func setupRoutes(sys *StreamingSystem) {
 	// Configurar manejadores
 	userHandler := handlers.NewUserHandler(sys.db)
 	authHandler := handlers.NewAuthHandler(sys.db)
-	songHandler := handlers.NewSongHandler(sys.db)
+	songHandler := handlers.NewSongHandler(sys.db, sys.searchIndex)
 
 	// Servir archivos estáticos del frontend
 	fs := http.FileServer(http.Dir("../Frontend"))
 	http.Handle("/", http.StripPrefix("/", fs))
 
-	// Manejo de archivos de música
-	http.Handle("/uploads/", http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
-
 	// Rutas de autenticación
 	http.HandleFunc("/api/login", authHandler.Login)
 	http.HandleFunc("/api/logout", authHandler.Logout)
@@ -341,12 +381,8 @@ func setupRoutes(sys *StreamingSystem) {
 			return
 		}
 
-		// Verificar rol de administrador
-		token := r.Header.Get("Authorization")
-		if !strings.HasPrefix(token, "Bearer admin-token") {
-			http.Error(w, "No autorizado", http.StatusUnauthorized)
-			return
-		}
+		// Cualquier usuario autenticado puede subir canciones dentro de la
+		// cuota de su plan; UploadSong valida el token y los límites
 
 		songHandler.UploadSong(w, r)
 	})
@@ -354,42 +390,114 @@ func setupRoutes(sys *StreamingSystem) {
 
 	http.HandleFunc("/api/songs/list", authMiddleware(songHandler.GetSongs))
 
-	// Rutas de FAVORITOS
-	http.HandleFunc("/api/favorites/add", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		var req struct {
-			UserID int `json:"user_id"`
-			SongID int `json:"song_id"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Petición inválida", http.StatusBadRequest)
-			return
-		}
-
-		if err := sys.AddFavorite(req.UserID, req.SongID); err != nil {
-			http.Error(w, "Error añadiendo favorito", http.StatusInternalServerError)
-			return
-		}
-
-		w.WriteHeader(http.StatusOK)
-	}))
-
-	http.HandleFunc("/api/favorites/remove", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		var req struct {
-			UserID int `json:"user_id"`
-			SongID int `json:"song_id"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Petición inválida", http.StatusBadRequest)
-			return
-		}
-
-		if err := sys.RemoveFavorite(req.UserID, req.SongID); err != nil {
-			http.Error(w, "Error eliminando favorito", http.StatusInternalServerError)
-			return
+	// Ruta de una canción: datos, edición (curadores) e historial de cambios.
+	// La portada es pública porque <img> no manda el token, y el audio
+	// acepta el token en ?token= porque <audio> tampoco lo manda. Los
+	// archivos de música no se sirven directamente: solo con /stream, que
+	// aplica la calidad del plan, y con /download.
+	songRoutes := authMiddleware(songHandler.SongRoutes)
+	http.HandleFunc("/api/songs/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/cover"):
+			songHandler.Cover(w, r)
+		case strings.HasSuffix(r.URL.Path, "/stream"):
+			songHandler.Stream(w, r)
+		default:
+			songRoutes(w, r)
 		}
+	})
 
-		w.WriteHeader(http.StatusOK)
-	}))
+	// Rutas de FAVORITOS
+	favoriteHandler := handlers.NewFavoriteHandler(sys.db)
+	http.HandleFunc("/api/favorites", authMiddleware(favoriteHandler.Favorites))
+	http.HandleFunc("/api/favorites/", authMiddleware(favoriteHandler.FavoriteRoutes))
+
+	// Rutas de PLAYLISTS
+	playlistHandler := handlers.NewPlaylistHandler(sys.db)
+	http.HandleFunc("/api/playlists", authMiddleware(playlistHandler.Playlists))
+	http.HandleFunc("/api/playlists/shared/", playlistHandler.PlaylistRoutes)
+	http.HandleFunc("/api/playlists/", authMiddleware(playlistHandler.PlaylistRoutes))
+
+	// Rutas de la COLA de reproducción
+	queueHandler := handlers.NewQueueHandler(sys.db, sys.events)
+	http.HandleFunc("/api/queue", authMiddleware(queueHandler.QueueRoutes))
+	http.HandleFunc("/api/queue/", authMiddleware(queueHandler.QueueRoutes))
+
+	// Rutas de posición de reproducción (continuar donde lo dejaste)
+	playbackHandler := handlers.NewPlaybackHandler(sys.db, sys.rules)
+	http.HandleFunc("/api/playback/heartbeat", authMiddleware(playbackHandler.Heartbeat))
+	http.HandleFunc("/api/playback/resume", authMiddleware(playbackHandler.Resume))
+	http.HandleFunc("/api/playback/start", authMiddleware(playbackHandler.Start))
+	http.HandleFunc("/api/playback/finish", authMiddleware(playbackHandler.Finish))
+	http.HandleFunc("/api/playback/check", authMiddleware(playbackHandler.Check))
+
+	// Rutas de la radio automática
+	radioHandler := handlers.NewRadioHandler(sys.db)
+	http.HandleFunc("/api/radio", authMiddleware(radioHandler.RadioRoutes))
+	http.HandleFunc("/api/radio/", authMiddleware(radioHandler.RadioRoutes))
+
+	// Rutas de estadísticas de escucha
+	statsHandler := handlers.NewStatsHandler(sys.db)
+	http.HandleFunc("/api/me/stats", authMiddleware(statsHandler.Stats))
+	http.HandleFunc("/api/me/stats/year-in-review", authMiddleware(statsHandler.YearInReview))
+
+	// Rutas de la papelera de canciones
+	trashHandler := handlers.NewTrashHandler(sys.db, sys.searchIndex)
+	http.HandleFunc("/api/admin/trash", adminMiddleware(trashHandler.TrashRoutes))
+	http.HandleFunc("/api/admin/trash/", adminMiddleware(trashHandler.TrashRoutes))
+
+	// Rutas de analíticas del administrador
+	analyticsHandler := handlers.NewAnalyticsHandler(sys.db)
+	http.HandleFunc("/api/admin/analytics", adminMiddleware(analyticsHandler.AnalyticsRoutes))
+	http.HandleFunc("/api/admin/analytics/", adminMiddleware(analyticsHandler.AnalyticsRoutes))
+
+	// Rutas de reportes de problemas y avisos al usuario
+	reportHandler := handlers.NewReportHandler(sys.db, sys.events)
+	http.HandleFunc("/api/reports", authMiddleware(reportHandler.ReportRoutes))
+	http.HandleFunc("/api/reports/", authMiddleware(reportHandler.ReportRoutes))
+	http.HandleFunc("/api/admin/reports", adminMiddleware(reportHandler.AdminReportRoutes))
+	http.HandleFunc("/api/admin/reports/", adminMiddleware(reportHandler.AdminReportRoutes))
+
+	notificationHandler := handlers.NewNotificationHandler(sys.db)
+	http.HandleFunc("/api/notifications", authMiddleware(notificationHandler.NotificationRoutes))
+	http.HandleFunc("/api/notifications/", authMiddleware(notificationHandler.NotificationRoutes))
+
+	// Rutas de planes, suscripciones y webhooks del proveedor de pagos
+	billingHandler := handlers.NewBillingHandler(sys.db, sys.payments, sys.events)
+	http.HandleFunc("/api/plans", billingHandler.Plans)
+	http.HandleFunc("/api/me/subscription", authMiddleware(billingHandler.Subscription))
+	http.HandleFunc("/api/billing/webhook", billingHandler.Webhook)
+	http.HandleFunc("/api/admin/billing/simulate", adminMiddleware(billingHandler.Simulate))
+
+	// Descargas sin conexión (solo planes que las incluyen)
+	http.HandleFunc("/api/offline/songs/", authMiddleware(
+		handlers.RequireEntitlement(sys.db, models.FeatureOfflineDownloads, songHandler.Download)))
+
+	// Ruta de cuota del plan del usuario
+	quotaHandler := handlers.NewQuotaHandler(sys.db)
+	http.HandleFunc("/api/me/quota", authMiddleware(quotaHandler.Quota))
+
+	// Ruta de recomendaciones personalizadas
+	recommendationHandler := handlers.NewRecommendationHandler(sys.db, sys.recommender)
+	http.HandleFunc("/api/me/recommendations", authMiddleware(recommendationHandler.Recommendations))
+
+	// Rutas de exportación e importación (catálogo, favoritos, playlists e historial)
+	exportHandler := handlers.NewExportHandler(sys.db)
+	http.HandleFunc("/api/me/export/", authMiddleware(exportHandler.ExportRoutes))
+	http.HandleFunc("/api/me/import/", authMiddleware(exportHandler.ImportRoutes))
+	http.HandleFunc("/api/admin/catalog", adminMiddleware(exportHandler.Catalog))
+
+	// Rutas de sincronización en tiempo real (SSE). /api/events valida el token
+	// por su cuenta porque EventSource no puede enviar la cabecera Authorization.
+	eventsHandler := handlers.NewEventsHandler(sys.db, sys.events)
+	http.HandleFunc("/api/events", eventsHandler.Stream)
+	http.HandleFunc("/api/events/publish", authMiddleware(eventsHandler.Publish))
+	http.HandleFunc("/api/events/devices", authMiddleware(eventsHandler.Devices))
+
+	// Registro de dispositivos del usuario
+	deviceHandler := handlers.NewDeviceHandler(sys.db, sys.events)
+	http.HandleFunc("/api/me/devices", authMiddleware(deviceHandler.DeviceRoutes))
+	http.HandleFunc("/api/me/devices/", authMiddleware(deviceHandler.DeviceRoutes))
 
 	/* Rutas de BUSQUEDA
 	http.HandleFunc("/api/songs/search", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
@@ -423,6 +531,10 @@ func setupRoutes(sys *StreamingSystem) {
 			return
 		}
 
+		if !playbackHandler.EnforceRules(w, r, models.ActionStart) {
+			return
+		}
+
 		if err := sys.PlaySong(songID); err != nil {
 			http.Error(w, "Error reproduciendo canción", http.StatusInternalServerError)
 			return
@@ -457,23 +569,10 @@ func setupRoutes(sys *StreamingSystem) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
-	// Ruta para búsqueda de canciones
-	http.HandleFunc("/api/songs/search", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Método no permitido", http.StatusMethodNotAllowed)
-			return
-		}
-
-		query := r.URL.Query().Get("q")
-		if query == "" {
-			http.Error(w, "Parámetro de búsqueda requerido", http.StatusBadRequest)
-			return
-		}
-
-		results := sys.library.SearchSongs(query)
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(results)
-	}))
+	// Ruta para búsqueda de canciones en el catálogo
+	searchHandler := handlers.NewSearchHandler(sys.db, sys.searchIndex)
+	http.HandleFunc("/api/songs/search", authMiddleware(searchHandler.Search))
+	http.HandleFunc("/api/search/suggest", authMiddleware(searchHandler.Suggest))
 
 	// Rutas para las interfaces de administrador y usuario
 	http.HandleFunc("/admin", adminMiddleware(func(w http.ResponseWriter, r *http.Request) {
@@ -486,6 +585,323 @@ func setupRoutes(sys *StreamingSystem) {
 
 }
 
+// Cada cuánto se recalculan las recomendaciones de todos los usuarios
+const recommendationRefreshInterval = 30 * time.Minute
+
+// refreshRecommendations recalcula las recomendaciones al iniciar y luego en
+// cada intervalo; si falla la lectura se conservan las anteriores
+func refreshRecommendations(rec *models.Recommender, interval time.Duration) {
+	for {
+		data, err := database.LoadRecommendationData()
+		if err != nil {
+			log.Printf("Error cargando datos de recomendaciones: %v", err)
+		} else {
+			rec.Rebuild(data)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Cada cuánto se recalculan los acumulados de analíticas
+const analyticsRefreshInterval = 15 * time.Minute
+
+// refreshAnalytics recalcula las analíticas del administrador al iniciar y
+// luego en cada intervalo
+func refreshAnalytics(interval time.Duration) {
+	for {
+		if err := database.RefreshAnalytics(); err != nil {
+			log.Printf("Error recalculando analíticas: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Cada cuánto se vacía la papelera y se borran los archivos sin uso
+const trashCollectInterval = time.Hour
+
+// collectTrash borra definitivamente las canciones vencidas de la papelera y
+// luego sus archivos, al iniciar y en cada intervalo
+func collectTrash(interval time.Duration) {
+	for {
+		if purged, err := database.PurgeExpiredTrash(models.TrashRetention); err != nil {
+			log.Printf("Error vaciando la papelera: %v", err)
+		} else if purged > 0 {
+			log.Printf("Canciones borradas definitivamente de la papelera: %d", purged)
+		}
+		if _, err := database.CollectFileGarbage(); err != nil {
+			log.Printf("Error borrando archivos de canciones: %v", err)
+		}
+		if _, err := database.CollectCoverGarbage(); err != nil {
+			log.Printf("Error borrando portadas sin uso: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Cada cuánto se revisa la integridad de los archivos de música
+const reconcileInterval = 24 * time.Hour
+
+// reconcileStorage revisa en cada intervalo que la tabla songs y los
+// archivos coincidan. Solo informa; para reparar se usa "streaming fsck".
+func reconcileStorage(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		report, err := database.Reconcile(models.FsckOptions{
+			VerifyChecksums: true,
+			OrphanGrace:     models.DefaultOrphanGrace,
+		})
+		if err != nil {
+			log.Printf("Error revisando archivos de música: %v", err)
+			continue
+		}
+		log.Printf("Revisión de archivos de música: %s", report.Summary())
+		for _, issue := range report.Issues {
+			log.Printf("  %s canción=%d archivo=%s esperado=%s actual=%s",
+				issue.Kind, issue.SongID, issue.FilePath, issue.Expected, issue.Actual)
+		}
+	}
+}
+
+// runFsck atiende "streaming fsck [opciones]": revisa los archivos de música
+// contra la tabla songs, repara según las opciones y devuelve el código de
+// salida (0 sin problemas pendientes, 1 con problemas, 2 si falló)
+func runFsck(args []string) int {
+	fs := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	checksums := fs.Bool("checksums", true, "leer los archivos completos y comparar el checksum")
+	deleteOrphans := fs.Bool("delete-orphans", false, "borrar los archivos que no pertenecen a ninguna canción")
+	trashMissing := fs.Bool("trash-missing", false, "enviar a la papelera las canciones cuyo archivo no existe")
+	fixSizes := fs.Bool("fix-sizes", false, "actualizar file_size con el tamaño real del archivo")
+	fillChecksums := fs.Bool("fill-checksums", false, "guardar el checksum de las canciones que no lo tienen")
+	trashCorrupt := fs.Bool("trash-corrupt", false, "enviar a la papelera las canciones con checksum distinto")
+	repairAll := fs.Bool("repair", false, "activar todas las reparaciones")
+	grace := fs.Duration("orphan-grace", models.DefaultOrphanGrace, "antigüedad mínima de un archivo huérfano")
+	asJSON := fs.Bool("json", false, "mostrar el reporte en JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	opts := models.FsckOptions{
+		VerifyChecksums: *checksums,
+		DeleteOrphans:   *deleteOrphans || *repairAll,
+		TrashMissing:    *trashMissing || *repairAll,
+		FixSizes:        *fixSizes || *repairAll,
+		FillChecksums:   *fillChecksums || *repairAll,
+		TrashCorrupt:    *trashCorrupt || *repairAll,
+		OrphanGrace:     *grace,
+	}
+	report, err := database.Reconcile(opts)
+	if err != nil {
+		log.Printf("Error revisando archivos de música: %v", err)
+		return 2
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		for _, issue := range report.Issues {
+			status := "pendiente"
+			if issue.Repaired {
+				status = "reparado"
+			} else if issue.Error != "" {
+				status = "error: " + issue.Error
+			}
+			fmt.Printf("%-16s canción=%-6d %s esperado=%s actual=%s [%s]\n",
+				issue.Kind, issue.SongID, issue.FilePath, issue.Expected, issue.Actual, status)
+		}
+		fmt.Println(report.Summary())
+		if !opts.Repairs() && len(report.Issues) > 0 {
+			fmt.Println("Modo solo lectura: use -repair o las opciones de reparación para corregir")
+		}
+	}
+
+	if report.Unrepaired() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runExport atiende "streaming export <catalog|favorites|playlist|history>
+// [opciones]": escribe el archivo en -o o en la salida estándar
+func runExport(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Uso: streaming export <catalog|favorites|playlist|history> [opciones]")
+		return 2
+	}
+	kind := args[0]
+	fs := flag.NewFlagSet("export "+kind, flag.ContinueOnError)
+	format := fs.String("format", "", "formato: csv o json (catalog), m3u8 o xspf (favorites, playlist)")
+	email := fs.String("user", "", "correo del usuario (favorites, history)")
+	playlistID := fs.Int("playlist", 0, "ID de la playlist (playlist)")
+	baseURL := fs.String("base-url", "", "prefijo de las rutas de las canciones, por ejemplo http://servidor:8080")
+	from := fs.String("from", "", "primer día del historial (AAAA-MM-DD)")
+	to := fs.String("to", "", "último día del historial (AAAA-MM-DD)")
+	output := fs.String("o", "", "archivo de salida (por defecto la salida estándar)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Printf("Error creando %s: %v", *output, err)
+			return 2
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var err error
+	switch kind {
+	case "catalog":
+		if *format == "" {
+			*format = models.FormatCSV
+		}
+		var entries []models.CatalogEntry
+		if entries, err = database.CatalogEntries(); err == nil {
+			err = models.WriteCatalog(out, *format, entries)
+		}
+	case "favorites", "playlist":
+		if *format == "" {
+			*format = models.FormatM3U8
+		}
+		name, tracks := "Favoritos", []models.PlaylistTrack(nil)
+		if kind == "favorites" {
+			var userID int
+			if userID, err = database.UserIDByEmail(*email); err == nil {
+				tracks, err = database.FavoriteTracks(userID, *baseURL)
+			}
+		} else {
+			name, tracks, err = database.PlaylistTracks(*playlistID, *baseURL)
+		}
+		if err == nil {
+			err = models.WritePlaylist(out, *format, name, "", tracks)
+		}
+	case "history":
+		var userID int
+		var fromDay, toDay time.Time
+		if *from != "" {
+			fromDay, err = time.ParseInLocation("2006-01-02", *from, time.Local)
+		}
+		if err == nil && *to != "" {
+			toDay, err = time.ParseInLocation("2006-01-02", *to, time.Local)
+			toDay = toDay.AddDate(0, 0, 1)
+		}
+		if err == nil {
+			userID, err = database.UserIDByEmail(*email)
+		}
+		if err == nil {
+			enc := json.NewEncoder(out)
+			enc.SetEscapeHTML(false)
+			err = database.ExportHistory(userID, fromDay, toDay, func(e models.HistoryEntry) error {
+				return enc.Encode(e)
+			})
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Exportación desconocida: %s\n", kind)
+		return 2
+	}
+	if err == sql.ErrNoRows {
+		log.Printf("Error exportando %s: usuario o playlist no encontrados", kind)
+		return 2
+	} else if err != nil {
+		log.Printf("Error exportando %s: %v", kind, err)
+		return 2
+	}
+	return 0
+}
+
+// runImport atiende "streaming import <catalog|favorites|playlist|history>
+// [opciones] archivo" y muestra las líneas que no se encontraron. Devuelve
+// 0 si se encontraron todas, 1 si quedaron líneas sin importar y 2 si falló.
+// Como la usa un administrador, no revisa la cuota de playlists.
+func runImport(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Uso: streaming import <catalog|favorites|playlist|history> [opciones] archivo")
+		return 2
+	}
+	kind := args[0]
+	fs := flag.NewFlagSet("import "+kind, flag.ContinueOnError)
+	format := fs.String("format", "", "formato del archivo (por defecto se detecta por el contenido)")
+	email := fs.String("user", "", "correo del usuario (favorites, playlist, history)")
+	name := fs.String("name", "", "nombre de la playlist (por defecto el del archivo)")
+	asJSON := fs.Bool("json", false, "mostrar el reporte en JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Indique el archivo a importar")
+		return 2
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		log.Printf("Error leyendo %s: %v", fs.Arg(0), err)
+		return 2
+	}
+	if *format == "" {
+		*format = models.DetectImportFormat(data)
+	}
+
+	var userID int
+	if kind != "catalog" {
+		if userID, err = database.UserIDByEmail(*email); err != nil {
+			log.Printf("Usuario %q no encontrado: %v", *email, err)
+			return 2
+		}
+	}
+
+	var list *models.ImportList
+	var report *models.ImportReport
+	switch kind {
+	case "catalog":
+		if list, err = models.ParseCatalog(bytes.NewReader(data), *format); err == nil {
+			report, err = database.ImportCatalog(*format, list)
+		}
+	case "favorites", "playlist":
+		if list, err = models.ParsePlaylist(bytes.NewReader(data), *format); err != nil {
+			break
+		}
+		if kind == "favorites" {
+			report, err = database.ImportFavorites(userID, *format, list)
+		} else {
+			report, err = database.ImportPlaylist(userID, *name, *format, list)
+		}
+	case "history":
+		if list, err = models.ParseHistory(bytes.NewReader(data)); err == nil {
+			report, err = database.ImportHistory(userID, list)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Importación desconocida: %s\n", kind)
+		return 2
+	}
+	if err != nil {
+		log.Printf("Error importando %s: %v", fs.Arg(0), err)
+		return 2
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		for _, u := range report.Unmatched {
+			fmt.Printf("línea %-6d %s [%s]\n", u.Line, u.Text, u.Reason)
+		}
+		if report.PlaylistID != 0 {
+			fmt.Printf("Playlist creada: %d\n", report.PlaylistID)
+		}
+		fmt.Println(report.Summary())
+	}
+
+	if len(report.Unmatched) > 0 {
+		return 1
+	}
+	return 0
+}
+
 func main() {
 	// Inicializar la base de datos
 	config := database.GetDefaultConfig()
@@ -495,6 +911,25 @@ func main() {
 	}
 	defer database.CloseDB()
 
+	// "streaming fsck" revisa los archivos de música y termina sin levantar el servidor
+	if len(os.Args) > 1 && os.Args[1] == "fsck" {
+		code := runFsck(os.Args[2:])
+		database.CloseDB()
+		os.Exit(code)
+	}
+
+	// "streaming export" e "import" pasan el catálogo, favoritos, playlists e
+	// historial a archivos y de vuelta, sin levantar el servidor
+	if len(os.Args) > 1 && (os.Args[1] == "export" || os.Args[1] == "import") {
+		run := runExport
+		if os.Args[1] == "import" {
+			run = runImport
+		}
+		code := run(os.Args[2:])
+		database.CloseDB()
+		os.Exit(code)
+	}
+
 	// Obtener la conexión a la base de datos
 	db := database.GetDB()
 
@@ -505,16 +940,45 @@ func main() {
 	}
 	log.Println("Base de datos inicializada correctamente")
 
+	if err := database.IndexMissingSongs(); err != nil {
+		log.Printf("Error indexando canciones para búsqueda: %v", err)
+	}
+	if err := database.BackfillListeningStats(); err != nil {
+		log.Printf("Error generando estadísticas de escucha: %v", err)
+	}
+
 	if err := os.MkdirAll("./uploads/songs", 0755); err != nil {
 		log.Printf("Error creando directorio de uploads: %v", err)
 	}
 
+	configureStreamLimits()
+
 	// Crear instancia del sistema
 	sys, err := NewStreamingSystem(db)
 	if err != nil {
 		log.Fatalf("Error creando sistema: %v", err)
 	}
 
+	// Construir el índice de sugerencias antes de aceptar peticiones
+	if err := database.LoadFuzzyIndex(sys.searchIndex); err != nil {
+		log.Printf("Error construyendo índice de sugerencias: %v", err)
+	}
+
+	// Calcular las recomendaciones y recalcularlas periódicamente
+	go refreshRecommendations(sys.recommender, recommendationRefreshInterval)
+	go refreshAnalytics(analyticsRefreshInterval)
+	go collectTrash(trashCollectInterval)
+	go reconcileStorage(reconcileInterval)
+
+	// Procesar el audio (sonoridad y forma de onda) de las canciones que aún
+	// no lo tienen; decodificar toma unos segundos por canción, por eso no
+	// bloquea el inicio
+	go func() {
+		if err := database.ProcessPendingSongs(); err != nil {
+			log.Printf("Error procesando canciones: %v", err)
+		}
+	}()
+
 	// Configurar rutas
 	setupRoutes(sys)
 
@@ -0,0 +1,117 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Estructuras de las analiticas de administrador (usuarios
+activos, reproducciones, subidas, almacenamiento y embudo de registro)
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+const (
+	DefaultAnalyticsDays = 30  // Días que se muestran si no se indica rango
+	MaxAnalyticsDays     = 730 // Rango máximo que se puede consultar
+)
+
+// DailyMetrics es el acumulado de un día (o de un mes) de uso del sistema
+type DailyMetrics struct {
+	Day           time.Time `json:"day"`
+	ActiveUsers   int       `json:"active_users"`
+	Plays         int       `json:"plays"`
+	ListenMinutes int       `json:"listen_minutes"`
+	Uploads       int       `json:"uploads"`
+	UploadBytes   int64     `json:"upload_bytes"`
+	Signups       int       `json:"signups"`
+}
+
+// GenreStorage es el espacio ocupado por las canciones de un género
+type GenreStorage struct {
+	Genre string `json:"genre"`
+	Songs int    `json:"songs"`
+	Bytes int64  `json:"bytes"`
+}
+
+// SongPlays es una canción con su cantidad total de reproducciones
+type SongPlays struct {
+	SongID     int        `json:"song_id"`
+	Title      string     `json:"title"`
+	Artist     string     `json:"artist"`
+	Genre      string     `json:"genre"`
+	Plays      int        `json:"plays"`
+	LastPlayed *time.Time `json:"last_played,omitempty"`
+	AddedAt    time.Time  `json:"added_at"`
+}
+
+// FunnelCohort es el embudo de los usuarios registrados en un mes:
+// se registraron, reprodujeron algo, guardaron un favorito o una playlist,
+// y volvieron a escuchar una semana después de registrarse
+type FunnelCohort struct {
+	Month     time.Time `json:"month"`
+	Signups   int       `json:"signups"`
+	Activated int       `json:"activated"`
+	Engaged   int       `json:"engaged"`
+	Retained  int       `json:"retained"`
+}
+
+// StorageByGenre agrupa el tamaño de las canciones por género, ordenado de
+// mayor a menor espacio ocupado
+func StorageByGenre(songs []Song) []GenreStorage {
+	byGenre := make(map[string]*GenreStorage)
+	for _, s := range songs {
+		g, ok := byGenre[s.Genre]
+		if !ok {
+			g = &GenreStorage{Genre: s.Genre}
+			byGenre[s.Genre] = g
+		}
+		g.Songs++
+		g.Bytes += int64(s.FileSize)
+	}
+
+	result := make([]GenreStorage, 0, len(byGenre))
+	for _, g := range byGenre {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes != result[j].Bytes {
+			return result[i].Bytes > result[j].Bytes
+		}
+		return result[i].Genre < result[j].Genre
+	})
+	return result
+}
+
+// ParseDateRange interpreta un rango de fechas AAAA-MM-DD (ambas incluidas).
+// Sin fechas devuelve los últimos DefaultAnalyticsDays días.
+func ParseDateRange(from, to string, now time.Time) (time.Time, time.Time, error) {
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("fecha final inválida, use AAAA-MM-DD")
+		}
+		end = t
+	}
+
+	start := end.AddDate(0, 0, -(DefaultAnalyticsDays - 1))
+	if from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("fecha inicial inválida, use AAAA-MM-DD")
+		}
+		start = t
+	}
+
+	if start.After(end) {
+		return time.Time{}, time.Time{}, errors.New("la fecha inicial es posterior a la final")
+	}
+	if end.Sub(start) > MaxAnalyticsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, errors.New("el rango de fechas es demasiado amplio")
+	}
+	return start, end, nil
+}
@@ -0,0 +1,230 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Decodificacion de archivos de audio (MP3 y WAV) a muestras
+PCM para analizarlas
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// ErrUnsupportedAudio indica un formato que no se sabe decodificar
+var ErrUnsupportedAudio = errors.New("formato de audio no soportado")
+
+// AudioStream entrega las muestras decodificadas intercaladas por canal y
+// normalizadas a [-1, 1]
+type AudioStream interface {
+	Channels() int
+	SampleRate() int
+	// ReadSamples llena buf con muestras completas (múltiplo de Channels)
+	// y devuelve io.EOF al terminar
+	ReadSamples(buf []float64) (int, error)
+}
+
+// AudioLength la implementan los decodificadores que conocen de antemano
+// la cantidad de cuadros (muestras por canal) de la pista; -1 si no se sabe
+type AudioLength interface {
+	Frames() int64
+}
+
+// AudioSink recibe las muestras intercaladas a medida que se decodifican
+// (LoudnessMeter, WaveformBuilder)
+type AudioSink interface {
+	Write(samples []float64)
+}
+
+// DecodeAll lee la pista completa una sola vez entregando las muestras a
+// todos los sinks
+func DecodeAll(src AudioStream, sinks ...AudioSink) error {
+	if src.Channels() <= 0 || src.SampleRate() <= 0 {
+		return ErrUnsupportedAudio
+	}
+	buf := make([]float64, 4096*src.Channels())
+	total := 0
+	for {
+		n, err := src.ReadSamples(buf)
+		total += n
+		for _, sink := range sinks {
+			sink.Write(buf[:n])
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	if total == 0 {
+		return ErrNoAudio
+	}
+	return nil
+}
+
+// OpenAudio elige el decodificador según la extensión del archivo
+func OpenAudio(r io.Reader, name string) (AudioStream, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".mp3":
+		dec, err := mp3.NewDecoder(r)
+		if err != nil {
+			return nil, err
+		}
+		frames := dec.Length() // -1 si r no permite moverse por el archivo
+		if frames > 0 {
+			frames /= 4
+		}
+		return &pcm16Stream{r: dec, channels: 2, rate: dec.SampleRate(), frames: frames}, nil
+	case ".wav":
+		return openWAV(r)
+	}
+	return nil, ErrUnsupportedAudio
+}
+
+// pcm16Stream lee PCM de 16 bits little-endian (la salida de go-mp3 es
+// siempre estéreo de 16 bits)
+type pcm16Stream struct {
+	r        io.Reader
+	channels int
+	rate     int
+	frames   int64
+	raw      []byte
+}
+
+func (s *pcm16Stream) Channels() int   { return s.channels }
+func (s *pcm16Stream) SampleRate() int { return s.rate }
+func (s *pcm16Stream) Frames() int64   { return s.frames }
+
+func (s *pcm16Stream) ReadSamples(buf []float64) (int, error) {
+	frames := len(buf) / s.channels
+	if cap(s.raw) < frames*s.channels*2 {
+		s.raw = make([]byte, frames*s.channels*2)
+	}
+	raw := s.raw[:frames*s.channels*2]
+	n, err := io.ReadFull(s.r, raw)
+	n -= n % (s.channels * 2)
+	for i := 0; i < n/2; i++ {
+		buf[i] = float64(int16(binary.LittleEndian.Uint16(raw[2*i:]))) / 32768
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n / 2, err
+}
+
+// wavStream lee el bloque de datos de un WAV PCM (8, 16, 24 o 32 bits) o
+// de punto flotante (32 o 64 bits)
+type wavStream struct {
+	r        io.Reader
+	channels int
+	rate     int
+	bits     int
+	float    bool
+	frames   int64
+	raw      []byte
+}
+
+func (s *wavStream) Channels() int   { return s.channels }
+func (s *wavStream) SampleRate() int { return s.rate }
+func (s *wavStream) Frames() int64   { return s.frames }
+
+func (s *wavStream) ReadSamples(buf []float64) (int, error) {
+	size := s.bits / 8
+	frames := len(buf) / s.channels
+	if cap(s.raw) < frames*s.channels*size {
+		s.raw = make([]byte, frames*s.channels*size)
+	}
+	raw := s.raw[:frames*s.channels*size]
+	n, err := io.ReadFull(s.r, raw)
+	n -= n % (s.channels * size)
+	count := n / size
+	for i := 0; i < count; i++ {
+		b := raw[i*size:]
+		switch {
+		case s.float && size == 4:
+			buf[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		case s.float:
+			buf[i] = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case size == 1:
+			buf[i] = (float64(b[0]) - 128) / 128
+		case size == 2:
+			buf[i] = float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+		case size == 3:
+			v := int32(b[0])<<8 | int32(b[1])<<16 | int32(b[2])<<24
+			buf[i] = float64(v>>8) / (1 << 23)
+		default:
+			buf[i] = float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+		}
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return count, err
+}
+
+func openWAV(r io.Reader) (AudioStream, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("el archivo no es un WAV válido")
+	}
+
+	var s *wavStream
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, errors.New("WAV sin bloque de datos")
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, errors.New("bloque fmt del WAV inválido")
+			}
+			data := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, err
+			}
+			format := binary.LittleEndian.Uint16(data[0:])
+			if format == 0xFFFE && size >= 26 { // WAVE_FORMAT_EXTENSIBLE: el formato real va en el subformato
+				format = binary.LittleEndian.Uint16(data[24:])
+			}
+			s = &wavStream{
+				channels: int(binary.LittleEndian.Uint16(data[2:])),
+				rate:     int(binary.LittleEndian.Uint32(data[4:])),
+				bits:     int(binary.LittleEndian.Uint16(data[14:])),
+				float:    format == 3,
+			}
+			validBits := s.bits == 8 || s.bits == 16 || s.bits == 24 || s.bits == 32
+			if s.float {
+				validBits = s.bits == 32 || s.bits == 64
+			}
+			if (format != 1 && format != 3) || !validBits || s.channels == 0 {
+				return nil, ErrUnsupportedAudio
+			}
+		case "data":
+			if s == nil {
+				return nil, errors.New("WAV sin bloque fmt")
+			}
+			s.r = io.LimitReader(r, size)
+			s.frames = size / int64(s.channels*s.bits/8)
+			return s, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
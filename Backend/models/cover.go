@@ -0,0 +1,204 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Extraccion de la portada incluida en los archivos de audio
+(marcos APIC/PIC de ID3v2 y bloques PICTURE de FLAC)
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// MaxCoverBytes es el tamaño máximo de una imagen de portada
+const MaxCoverBytes = 10 << 20
+
+// Origen de la portada de una canción
+const (
+	CoverEmbedded = "embedded" // Extraída de las etiquetas del archivo
+	CoverUpload   = "upload"   // Subida junto con la canción
+	CoverNone     = "none"     // El archivo no trae portada
+)
+
+// ErrNoCover indica que el archivo no trae portada
+var ErrNoCover = errors.New("el archivo no tiene portada")
+
+// pictureFrontCover es el tipo de imagen "portada" en ID3v2 y FLAC
+const pictureFrontCover = 3
+
+// ExtractCover busca la portada en las etiquetas del archivo. Si hay varias
+// imágenes se prefiere la de tipo portada.
+func ExtractCover(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, ErrNoCover
+	}
+
+	if string(magic[:3]) == "ID3" {
+		frames, err := readID3Frames(br)
+		if err != nil {
+			return nil, ErrNoCover
+		}
+		if picture := id3Picture(frames); picture != nil {
+			return picture, nil
+		}
+		// Un FLAC puede empezar con una etiqueta ID3 sin imagen
+		if magic, err = br.Peek(4); err != nil {
+			return nil, ErrNoCover
+		}
+	}
+	if string(magic) == "fLaC" {
+		return flacPicture(br)
+	}
+	return nil, ErrNoCover
+}
+
+// id3Picture busca entre los marcos APIC (o PIC en ID3v2.2) la portada, o
+// la primera imagen si ninguna es de tipo portada
+func id3Picture(frames []id3Frame) []byte {
+	var found []byte
+	for _, frame := range frames {
+		if frame.ID != "APIC" && frame.ID != "PIC" {
+			continue
+		}
+		picType, data, ok := parseAPIC(frame.Body, frame.ID == "PIC")
+		if !ok {
+			continue
+		}
+		if picType == pictureFrontCover {
+			return data
+		}
+		if found == nil {
+			found = data
+		}
+	}
+	return found
+}
+
+// parseAPIC separa los campos de un marco APIC (o PIC en ID3v2.2):
+// codificación, tipo MIME (o formato de 3 letras), tipo de imagen,
+// descripción terminada en cero y los datos de la imagen
+func parseAPIC(body []byte, v22 bool) (picType byte, data []byte, ok bool) {
+	if len(body) < 2 {
+		return 0, nil, false
+	}
+	encoding := body[0]
+	rest := body[1:]
+
+	if v22 {
+		if len(rest) < 3 {
+			return 0, nil, false
+		}
+		rest = rest[3:]
+	} else {
+		i := bytes.IndexByte(rest, 0)
+		if i < 0 {
+			return 0, nil, false
+		}
+		rest = rest[i+1:]
+	}
+	if len(rest) < 1 {
+		return 0, nil, false
+	}
+	picType = rest[0]
+
+	if _, rest, ok = splitID3String(encoding, rest[1:]); !ok {
+		return 0, nil, false
+	}
+	if len(rest) == 0 || len(rest) > MaxCoverBytes {
+		return 0, nil, false
+	}
+	return picType, rest, true
+}
+
+// flacPicture recorre los bloques de metadatos de un FLAC buscando PICTURE (tipo 6)
+func flacPicture(r io.Reader) ([]byte, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil || string(magic[:]) != "fLaC" {
+		return nil, ErrNoCover
+	}
+
+	var found []byte
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			break
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if blockType != 6 || length > MaxCoverBytes+4096 {
+			if _, err := io.CopyN(io.Discard, r, length); err != nil {
+				break
+			}
+		} else {
+			block := make([]byte, length)
+			if _, err := io.ReadFull(r, block); err != nil {
+				break
+			}
+			picType, data, ok := parseFLACPicture(block)
+			if ok && picType == pictureFrontCover {
+				return data, nil
+			}
+			if ok && found == nil {
+				found = data
+			}
+		}
+		if last {
+			break
+		}
+	}
+	if found == nil {
+		return nil, ErrNoCover
+	}
+	return found, nil
+}
+
+// parseFLACPicture lee un bloque PICTURE: tipo, MIME, descripción,
+// dimensiones y los datos de la imagen (todos los enteros de 32 bits big-endian)
+func parseFLACPicture(block []byte) (picType uint32, data []byte, ok bool) {
+	next := func(n int) ([]byte, bool) {
+		if n < 0 || n > len(block) {
+			return nil, false
+		}
+		b := block[:n]
+		block = block[n:]
+		return b, true
+	}
+	u32 := func() (uint32, bool) {
+		b, ok := next(4)
+		if !ok {
+			return 0, false
+		}
+		return binary.BigEndian.Uint32(b), true
+	}
+
+	var n uint32
+	if picType, ok = u32(); !ok {
+		return 0, nil, false
+	}
+	for i := 0; i < 2; i++ { // MIME y descripción
+		if n, ok = u32(); !ok {
+			return 0, nil, false
+		}
+		if _, ok = next(int(n)); !ok {
+			return 0, nil, false
+		}
+	}
+	if _, ok = next(16); !ok { // Ancho, alto, profundidad y colores
+		return 0, nil, false
+	}
+	if n, ok = u32(); !ok || n == 0 {
+		return 0, nil, false
+	}
+	data, ok = next(int(n))
+	return picType, data, ok
+}
@@ -0,0 +1,174 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Redimensionado de portadas a los tamanos estandar y portada
+generada para las canciones que no tienen una
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"bytes"
+	"errors"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // Formatos que puede traer una portada
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+)
+
+// CoverSizes son los lados (en píxeles) en que se guarda cada portada
+var CoverSizes = []int{64, 256, 512}
+
+// DefaultCoverSize es el tamaño que se entrega si no se pide uno
+const DefaultCoverSize = 256
+
+// MaxCoverPixels limita la resolución de la imagen original (evita que una
+// imagen enorme agote la memoria al decodificarla)
+const MaxCoverPixels = 6000 * 6000
+
+var ErrInvalidCover = errors.New("la portada no es una imagen JPEG, PNG o GIF válida")
+
+// CoverSize devuelve el tamaño estándar más chico que cubre el pedido
+// (o el mayor si el pedido lo supera)
+func CoverSize(requested int) int {
+	if requested <= 0 {
+		return DefaultCoverSize
+	}
+	for _, size := range CoverSizes {
+		if size >= requested {
+			return size
+		}
+	}
+	return CoverSizes[len(CoverSizes)-1]
+}
+
+// DecodeCover decodifica la imagen de una portada revisando antes sus dimensiones
+func DecodeCover(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxCoverPixels {
+		return nil, ErrInvalidCover
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidCover
+	}
+	return img, nil
+}
+
+// ResizeCover recorta el centro cuadrado de la imagen y lo lleva a size×size
+// promediando el área de cada píxel de destino. Las transparencias se
+// componen sobre fondo oscuro porque las portadas se guardan en JPEG.
+func ResizeCover(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	crop := image.Rect(0, 0, side, side)
+	src := image.NewRGBA(crop)
+	draw.Draw(src, crop, &image.Uniform{color.RGBA{18, 18, 18, 255}}, image.Point{}, draw.Src)
+	offset := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+	draw.Draw(src, crop, img, offset, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	scale := float64(side) / float64(size)
+	for y := 0; y < size; y++ {
+		y0, y1 := float64(y)*scale, float64(y+1)*scale
+		for x := 0; x < size; x++ {
+			x0, x1 := float64(x)*scale, float64(x+1)*scale
+
+			var r, g, bl, total float64
+			for sy := int(y0); sy < int(math.Ceil(y1)) && sy < side; sy++ {
+				wy := math.Min(y1, float64(sy+1)) - math.Max(y0, float64(sy))
+				for sx := int(x0); sx < int(math.Ceil(x1)) && sx < side; sx++ {
+					wx := math.Min(x1, float64(sx+1)) - math.Max(x0, float64(sx))
+					w := wx * wy
+					i := src.PixOffset(sx, sy)
+					r += float64(src.Pix[i]) * w
+					g += float64(src.Pix[i+1]) * w
+					bl += float64(src.Pix[i+2]) * w
+					total += w
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r/total + 0.5)
+			dst.Pix[i+1] = uint8(g/total + 0.5)
+			dst.Pix[i+2] = uint8(bl/total + 0.5)
+			dst.Pix[i+3] = 255
+		}
+	}
+	return dst
+}
+
+// PlaceholderCover genera una portada para canciones sin imagen: un
+// degradado con un disco, con colores que dependen de seed (artista y
+// título) para que cada canción se distinga
+func PlaceholderCover(seed string, size int) *image.RGBA {
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	sum := h.Sum32()
+	hue := float64(sum%360) / 360
+	from := hslColor(hue, 0.55, 0.45)
+	to := hslColor(math.Mod(hue+0.12, 1), 0.60, 0.20)
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	center := float64(size) / 2
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			t := float64(x+y) / float64(2*size)
+			c := color.RGBA{
+				R: lerp(from.R, to.R, t),
+				G: lerp(from.G, to.G, t),
+				B: lerp(from.B, to.B, t),
+				A: 255,
+			}
+
+			// Disco: surcos oscuros y etiqueta central con el color base
+			d := math.Hypot(float64(x)+0.5-center, float64(y)+0.5-center) / center
+			switch {
+			case d < 0.12:
+				c = color.RGBA{20, 20, 20, 255}
+			case d < 0.30:
+				c = from
+			case d < 0.78:
+				groove := uint8(28)
+				if int(d*60)%2 == 0 {
+					groove = 38
+				}
+				c = color.RGBA{groove, groove, groove, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func lerp(a, b uint8, t float64) uint8 {
+	return uint8(float64(a) + (float64(b)-float64(a))*t)
+}
+
+func hslColor(h, s, l float64) color.RGBA {
+	q := l + s - l*s
+	if l < 0.5 {
+		q = l * (1 + s)
+	}
+	p := 2*l - q
+	channel := func(t float64) uint8 {
+		t = math.Mod(t+1, 1)
+		var v float64
+		switch {
+		case t < 1.0/6:
+			v = p + (q-p)*6*t
+		case t < 0.5:
+			v = q
+		case t < 2.0/3:
+			v = p + (q-p)*(2.0/3-t)*6
+		default:
+			v = p
+		}
+		return uint8(v*255 + 0.5)
+	}
+	return color.RGBA{channel(h + 1.0/3), channel(h), channel(h - 1.0/3), 255}
+}
@@ -0,0 +1,99 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase Device, registro de los dispositivos
+con sesion abierta de un usuario y su estado de reproduccion
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Tipos de dispositivo
+const (
+	DeviceWeb     = "web"
+	DeviceMobile  = "mobile"
+	DeviceDesktop = "desktop"
+	DeviceTV      = "tv"
+	DeviceSpeaker = "speaker"
+)
+
+const (
+	MaxDeviceID   = 64
+	MaxDeviceName = 100
+
+	// ActiveStreamWindow es cuánto tiempo sin heartbeat se considera que un
+	// dispositivo sigue reproduciendo
+	ActiveStreamWindow = 90 * time.Second
+)
+
+// Device es un dispositivo del usuario. StreamingAt es el último heartbeat
+// mientras sonaba música (nil si está en pausa) y RevokedAt se marca cuando
+// el usuario cierra la sesión de ese dispositivo.
+type Device struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	DeviceID    string     `json:"device_id"`
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at"`
+	StreamingAt *time.Time `json:"streaming_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	Streaming   bool       `json:"streaming"`
+	Current     bool       `json:"current"` // Es el dispositivo que hace la petición
+}
+
+// IsStreaming indica si el dispositivo reprodujo algo dentro de la ventana activa
+func (d *Device) IsStreaming(now time.Time) bool {
+	return d.RevokedAt == nil && d.StreamingAt != nil && now.Sub(*d.StreamingAt) < ActiveStreamWindow
+}
+
+// Normalize completa el nombre y el tipo y valida los campos
+func (d *Device) Normalize() error {
+	d.DeviceID = strings.TrimSpace(d.DeviceID)
+	d.Name = strings.TrimSpace(d.Name)
+	d.Type = strings.ToLower(strings.TrimSpace(d.Type))
+
+	if d.DeviceID == "" {
+		d.DeviceID = NewDeviceID()
+	}
+	if len(d.DeviceID) > MaxDeviceID {
+		return errors.New("el ID del dispositivo es demasiado largo")
+	}
+	switch d.Type {
+	case DeviceWeb, DeviceMobile, DeviceDesktop, DeviceTV, DeviceSpeaker:
+	case "":
+		d.Type = DeviceWeb
+	default:
+		return errors.New("tipo de dispositivo inválido")
+	}
+	if d.Name == "" {
+		d.Name = "Dispositivo " + d.Type
+	}
+	if len([]rune(d.Name)) > MaxDeviceName {
+		d.Name = string([]rune(d.Name)[:MaxDeviceName])
+	}
+	return nil
+}
+
+// NewDeviceID genera un ID para clientes que no envían el suyo
+func NewDeviceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "dev-" + hex.EncodeToString(b)
+}
+
+// NewSessionToken genera el secreto que liga un token de acceso a un dispositivo
+func NewSessionToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,193 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase events (canal de eventos en tiempo
+real por usuario), con sus respectivas funciones para el manejo de datos
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EventBufferSize     = 100 // Eventos guardados por usuario para reenviar al reconectar
+	subscriberQueueSize = 32  // Eventos pendientes por conexión antes de descartarla
+)
+
+// Tipos de evento de la sesión de reproducción
+const (
+	EventPlaybackPlay  = "playback.play"
+	EventPlaybackPause = "playback.pause"
+	EventPlaybackTrack = "playback.track"
+	EventPlaybackSeek  = "playback.seek"
+	EventQueueUpdated  = "queue.updated"
+	EventSyncReset     = "sync.reset"   // El cliente debe volver a pedir el estado completo
+	EventCommandPrefix = "command."     // Control remoto: command.play, command.pause, ...
+	EventNotification  = "notification" // Aviso nuevo para el usuario (solo lo publica el servidor)
+	EventCommandPause  = "command.pause"
+	EventDeviceRevoked = "device.revoked" // Se cerró la sesión del dispositivo (solo lo publica el servidor)
+)
+
+// Event es un cambio en la sesión de reproducción de un usuario
+type Event struct {
+	ID           int64           `json:"id"`
+	Type         string          `json:"type"`
+	SourceDevice string          `json:"source_device,omitempty"`
+	TargetDevice string          `json:"target_device,omitempty"` // Vacío = todos los dispositivos
+	Data         json.RawMessage `json:"data,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// IsClientEvent indica si un cliente puede publicar este tipo de evento
+func IsClientEvent(eventType string) bool {
+	switch eventType {
+	case EventPlaybackPlay, EventPlaybackPause, EventPlaybackTrack, EventPlaybackSeek:
+		return true
+	}
+	return strings.HasPrefix(eventType, EventCommandPrefix) && len(eventType) > len(EventCommandPrefix)
+}
+
+// Subscription es una conexión abierta de un dispositivo
+type Subscription struct {
+	UserID   int
+	DeviceID string
+	Events   chan Event
+	closed   bool
+}
+
+type userStream struct {
+	lastID      int64
+	buffer      []Event
+	subscribers map[*Subscription]struct{}
+}
+
+// EventHub reparte los eventos de cada usuario entre todas sus conexiones
+// abiertas y guarda los últimos para reenviarlos al reconectar
+type EventHub struct {
+	mu      sync.Mutex
+	streams map[int]*userStream
+}
+
+// NewEventHub crea un repartidor de eventos vacío
+func NewEventHub() *EventHub {
+	return &EventHub{streams: make(map[int]*userStream)}
+}
+
+func (h *EventHub) stream(userID int) *userStream {
+	s, ok := h.streams[userID]
+	if !ok {
+		// Los IDs parten de la hora actual para que un Last-Event-ID emitido
+		// antes de reiniciar el servidor no se confunda con uno nuevo
+		s = &userStream{
+			lastID:      time.Now().UnixMilli() * 1000,
+			subscribers: make(map[*Subscription]struct{}),
+		}
+		h.streams[userID] = s
+	}
+	return s
+}
+
+// Publish asigna un ID al evento, lo guarda y lo envía a las conexiones del usuario
+func (h *EventHub) Publish(userID int, event Event) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.stream(userID)
+	s.lastID++
+	event.ID = s.lastID
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	s.buffer = append(s.buffer, event)
+	if len(s.buffer) > EventBufferSize {
+		s.buffer = s.buffer[len(s.buffer)-EventBufferSize:]
+	}
+
+	for sub := range s.subscribers {
+		select {
+		case sub.Events <- event:
+		default:
+			// La conexión no da abasto: se cierra y el cliente se recupera
+			// reconectando con Last-Event-ID
+			h.closeLocked(s, sub)
+		}
+	}
+	return event
+}
+
+// Subscribe abre una conexión para el dispositivo y devuelve los eventos
+// posteriores a lastEventID. Si ya no es posible reenviarlos todos (el
+// servidor se reinició o se perdieron del buffer) devuelve un sync.reset.
+func (h *EventHub) Subscribe(userID int, deviceID string, lastEventID int64) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.stream(userID)
+	sub := &Subscription{
+		UserID:   userID,
+		DeviceID: deviceID,
+		Events:   make(chan Event, subscriberQueueSize),
+	}
+	s.subscribers[sub] = struct{}{}
+
+	if lastEventID <= 0 {
+		return sub, nil
+	}
+
+	oldest := s.lastID - int64(len(s.buffer)) + 1
+	if lastEventID > s.lastID || lastEventID < oldest-1 {
+		return sub, []Event{{ID: s.lastID, Type: EventSyncReset, CreatedAt: time.Now()}}
+	}
+
+	var replay []Event
+	for _, e := range s.buffer {
+		if e.ID > lastEventID {
+			replay = append(replay, e)
+		}
+	}
+	return sub, replay
+}
+
+// Unsubscribe cierra la conexión del dispositivo
+func (h *EventHub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.streams[sub.UserID]; ok {
+		h.closeLocked(s, sub)
+	}
+}
+
+// Devices devuelve los dispositivos conectados del usuario
+func (h *EventHub) Devices(userID int) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var devices []string
+	seen := make(map[string]bool)
+	if s, ok := h.streams[userID]; ok {
+		for sub := range s.subscribers {
+			if !seen[sub.DeviceID] {
+				seen[sub.DeviceID] = true
+				devices = append(devices, sub.DeviceID)
+			}
+		}
+	}
+	return devices
+}
+
+func (h *EventHub) closeLocked(s *userStream, sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(s.subscribers, sub)
+	close(sub.Events)
+}
@@ -0,0 +1,658 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Exportacion e importacion del catalogo (CSV/JSON), de favoritos
+y playlists (M3U8/XSPF) y del historial de escucha (JSON Lines), junto con
+la busqueda de la cancion que corresponde a cada linea importada
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formatos de exportación e importación
+const (
+	FormatCSV   = "csv"   // Catálogo
+	FormatJSON  = "json"  // Catálogo
+	FormatM3U8  = "m3u8"  // Favoritos y playlists
+	FormatXSPF  = "xspf"  // Favoritos y playlists
+	FormatJSONL = "jsonl" // Historial, un objeto JSON por línea
+)
+
+// ExportContentTypes es el Content-Type con que se entrega cada formato
+var ExportContentTypes = map[string]string{
+	FormatCSV:   "text/csv; charset=utf-8",
+	FormatJSON:  "application/json",
+	FormatM3U8:  "application/vnd.apple.mpegurl",
+	FormatXSPF:  "application/xspf+xml",
+	FormatJSONL: "application/x-ndjson",
+}
+
+// Cómo se encontró la canción de una línea importada
+const (
+	MatchChecksum    = "checksum"
+	MatchPath        = "path"
+	MatchTitleArtist = "title_artist"
+)
+
+// MaxImportBytes es el tamaño máximo de un archivo a importar
+const MaxImportBytes = 10 << 20
+
+// DefaultImportPlaylistName se usa cuando el archivo no trae nombre
+const DefaultImportPlaylistName = "Playlist importada"
+
+var (
+	ErrUnsupportedFormat = errors.New("formato no soportado")
+	ErrEmptyImport       = errors.New("el archivo no tiene entradas")
+)
+
+// catalogColumns son las columnas del CSV del catálogo, en orden
+var catalogColumns = []string{
+	"id", "title", "artist", "album", "genre", "duration_seconds",
+	"file_size", "file_path", "checksum", "created_at",
+}
+
+// CatalogEntry es una canción del catálogo exportado
+type CatalogEntry struct {
+	ID              int       `json:"id"`
+	Title           string    `json:"title"`
+	Artist          string    `json:"artist"`
+	Album           string    `json:"album"`
+	Genre           string    `json:"genre"`
+	DurationSeconds int       `json:"duration_seconds"` // 0 si todavía no se procesó
+	FileSize        int64     `json:"file_size"`
+	FilePath        string    `json:"file_path"`
+	Checksum        string    `json:"checksum,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// PlaylistTrack es una canción de una playlist o de los favoritos exportados.
+// Location es la URL del audio en el servidor (no la del archivo).
+type PlaylistTrack struct {
+	SongID          int
+	Title           string
+	Artist          string
+	Album           string
+	DurationSeconds int
+	Location        string
+	Checksum        string
+}
+
+// HistoryEntry es una reproducción del historial exportado (una por línea)
+type HistoryEntry struct {
+	SongID   int       `json:"song_id,omitempty"`
+	Title    string    `json:"title"`
+	Artist   string    `json:"artist"`
+	Album    string    `json:"album,omitempty"`
+	FilePath string    `json:"file_path,omitempty"`
+	Checksum string    `json:"checksum,omitempty"`
+	PlayedAt time.Time `json:"played_at"`
+	Seconds  int       `json:"seconds"`
+	Status   string    `json:"status"`
+}
+
+// ImportItem es una entrada leída de un archivo importado. Line es la línea
+// del archivo (en JSON, la posición en el arreglo) y Text lo que se muestra
+// en el reporte si no se encuentra la canción.
+type ImportItem struct {
+	Line     int
+	Text     string
+	Title    string
+	Artist   string
+	Location string
+	Checksum string
+	PlayedAt time.Time
+	Seconds  int
+	Status   string
+}
+
+// ImportList es el contenido de un archivo importado. Invalid son las
+// líneas que no se pudieron leer.
+type ImportList struct {
+	Name    string
+	Items   []ImportItem
+	Invalid []ImportUnmatched
+}
+
+// ImportUnmatched es una línea que no se importó y el motivo
+type ImportUnmatched struct {
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport resume una importación. Imported cuenta solo lo que se
+// agregó: un favorito o una reproducción que ya existían no se repiten.
+type ImportReport struct {
+	Format     string            `json:"format"`
+	Total      int               `json:"total"`
+	Matched    int               `json:"matched"`
+	Imported   int               `json:"imported"`
+	MatchedBy  map[string]int    `json:"matched_by"`
+	Unmatched  []ImportUnmatched `json:"unmatched"`
+	PlaylistID int               `json:"playlist_id,omitempty"`
+}
+
+// NewImportReport arma el reporte con las líneas inválidas del archivo
+func NewImportReport(format string, list *ImportList) *ImportReport {
+	report := &ImportReport{
+		Format:    format,
+		Total:     len(list.Items) + len(list.Invalid),
+		MatchedBy: map[string]int{},
+		Unmatched: append([]ImportUnmatched{}, list.Invalid...),
+	}
+	return report
+}
+
+// Summary resume el reporte en una línea
+func (r *ImportReport) Summary() string {
+	return fmt.Sprintf("%d entradas, %d encontradas (%d checksum, %d ruta, %d título y artista), %d importadas, %d sin importar",
+		r.Total, r.Matched, r.MatchedBy[MatchChecksum], r.MatchedBy[MatchPath], r.MatchedBy[MatchTitleArtist],
+		r.Imported, len(r.Unmatched))
+}
+
+// DetectImportFormat adivina el formato por el contenido cuando no se indica:
+// XML es XSPF, "#EXTM3U" o rutas sueltas son M3U8, "[" es JSON y una
+// primera línea con "{" es JSON Lines
+func DetectImportFormat(data []byte) string {
+	text := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\uFEFF")), " \t\r\n")
+	switch {
+	case bytes.HasPrefix(text, []byte("<")):
+		return FormatXSPF
+	case bytes.HasPrefix(text, []byte("[")):
+		return FormatJSON
+	case bytes.HasPrefix(text, []byte("{")):
+		return FormatJSONL
+	case bytes.HasPrefix(text, []byte("#")):
+		return FormatM3U8
+	}
+	if first, _, _ := bytes.Cut(text, []byte("\n")); bytes.Contains(first, []byte(",")) {
+		return FormatCSV
+	}
+	return FormatM3U8
+}
+
+// WriteCatalog escribe el catálogo en CSV (con encabezado) o JSON
+func WriteCatalog(w io.Writer, format string, entries []CatalogEntry) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(catalogColumns)
+		for _, e := range entries {
+			cw.Write([]string{
+				strconv.Itoa(e.ID), e.Title, e.Artist, e.Album, e.Genre,
+				strconv.Itoa(e.DurationSeconds), strconv.FormatInt(e.FileSize, 10),
+				e.FilePath, e.Checksum, e.CreatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return ErrUnsupportedFormat
+}
+
+// ParseCatalog lee un catálogo exportado por WriteCatalog (o uno armado a
+// mano con al menos las columnas title y artist, file_path o checksum)
+func ParseCatalog(r io.Reader, format string) (*ImportList, error) {
+	switch format {
+	case FormatJSON:
+		var entries []CatalogEntry
+		if err := json.NewDecoder(r).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("JSON inválido: %v", err)
+		}
+		list := &ImportList{}
+		for i, e := range entries {
+			list.Items = append(list.Items, ImportItem{
+				Line: i + 1, Text: trackText(e.Artist, e.Title, e.FilePath),
+				Title: e.Title, Artist: e.Artist, Location: e.FilePath, Checksum: e.Checksum,
+			})
+		}
+		return list, nil
+	case FormatCSV:
+		return parseCatalogCSV(r)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func parseCatalogCSV(r io.Reader) (*ImportList, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("CSV sin encabezado: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))] = i
+	}
+	_, hasTitle := columns["title"]
+	_, hasPath := columns["file_path"]
+	_, hasChecksum := columns["checksum"]
+	if !hasTitle && !hasPath && !hasChecksum {
+		return nil, errors.New("el CSV debe tener las columnas title y artist, file_path o checksum")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	list := &ImportList{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			list.Invalid = append(list.Invalid, ImportUnmatched{Line: parseErr.Line, Reason: "línea inválida"})
+			continue
+		}
+		item := ImportItem{
+			Line:     line,
+			Title:    field(record, "title"),
+			Artist:   field(record, "artist"),
+			Location: field(record, "file_path"),
+			Checksum: field(record, "checksum"),
+		}
+		item.Text = trackText(item.Artist, item.Title, item.Location)
+		list.Items = append(list.Items, item)
+	}
+	return list, nil
+}
+
+// WritePlaylist escribe las canciones en M3U8 o XSPF
+func WritePlaylist(w io.Writer, format, name, creator string, tracks []PlaylistTrack) error {
+	switch format {
+	case FormatM3U8:
+		return writeM3U8(w, name, tracks)
+	case FormatXSPF:
+		return writeXSPF(w, name, creator, tracks)
+	}
+	return ErrUnsupportedFormat
+}
+
+// ParsePlaylist lee una playlist en M3U8 o XSPF
+func ParsePlaylist(r io.Reader, format string) (*ImportList, error) {
+	switch format {
+	case FormatM3U8:
+		return parseM3U8(r)
+	case FormatXSPF:
+		return parseXSPF(r)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// writeM3U8 escribe la playlist en M3U extendido: #EXTINF con la duración
+// (-1 si no se conoce) y "Artista - Título" antes de cada ruta
+func writeM3U8(w io.Writer, name string, tracks []PlaylistTrack) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("#EXTM3U\n")
+	if name != "" {
+		fmt.Fprintf(bw, "#PLAYLIST:%s\n", oneLine(name))
+	}
+	for _, t := range tracks {
+		duration := t.DurationSeconds
+		if duration <= 0 {
+			duration = -1
+		}
+		fmt.Fprintf(bw, "#EXTINF:%d,%s - %s\n", duration, oneLine(t.Artist), oneLine(t.Title))
+		if t.Album != "" {
+			fmt.Fprintf(bw, "#EXTALB:%s\n", oneLine(t.Album))
+		}
+		if t.Location != "" {
+			fmt.Fprintf(bw, "%s\n", oneLine(t.Location))
+		}
+	}
+	return bw.Flush()
+}
+
+// parseM3U8 lee un M3U o M3U8. Las líneas que empiezan con # son
+// directivas; la de #EXTINF describe la ruta que le sigue. Un #EXTINF sin
+// ruta se busca igual por título y artista.
+func parseM3U8(r io.Reader) (*ImportList, error) {
+	list := &ImportList{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+	var pending *ImportItem
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\uFEFF")
+		}
+		switch {
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "#EXTINF:"):
+			if pending != nil {
+				list.Items = append(list.Items, *pending)
+			}
+			duration, info, _ := strings.Cut(strings.TrimPrefix(text, "#EXTINF:"), ",")
+			item := ImportItem{Line: line, Text: text}
+			// La duración puede venir seguida de atributos (tvg-id="...")
+			if fields := strings.Fields(duration); len(fields) > 0 {
+				seconds, _ := strconv.Atoi(fields[0])
+				item.Seconds = max(0, seconds)
+			}
+			if artist, title, ok := strings.Cut(info, " - "); ok {
+				item.Artist, item.Title = strings.TrimSpace(artist), strings.TrimSpace(title)
+			} else {
+				item.Title = strings.TrimSpace(info)
+			}
+			item.Text = trackText(item.Artist, item.Title, text)
+			pending = &item
+		case strings.HasPrefix(text, "#PLAYLIST:"):
+			list.Name = strings.TrimSpace(strings.TrimPrefix(text, "#PLAYLIST:"))
+		case strings.HasPrefix(text, "#"):
+			continue
+		default:
+			item := ImportItem{Line: line, Text: text}
+			if pending != nil {
+				item = *pending
+				item.Text = trackText(item.Artist, item.Title, text)
+			}
+			item.Location = text
+			list.Items = append(list.Items, item)
+			pending = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		list.Items = append(list.Items, *pending)
+	}
+	return list, nil
+}
+
+// xspfNamespace es el espacio de nombres de XSPF versión 1
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Version int         `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Creator string      `xml:"creator,omitempty"`
+	Date    string      `xml:"date,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location   string `xml:"location,omitempty"`
+	Identifier string `xml:"identifier,omitempty"`
+	Title      string `xml:"title,omitempty"`
+	Creator    string `xml:"creator,omitempty"`
+	Album      string `xml:"album,omitempty"`
+	Duration   int    `xml:"duration,omitempty"` // Milisegundos
+}
+
+// writeXSPF escribe la playlist en XSPF. El checksum va como identificador
+// urn:sha256 para poder encontrar la canción aunque cambie la ruta.
+func writeXSPF(w io.Writer, name, creator string, tracks []PlaylistTrack) error {
+	playlist := xspfPlaylist{
+		Xmlns:   xspfNamespace,
+		Version: 1,
+		Title:   name,
+		Creator: creator,
+		Date:    time.Now().UTC().Format(time.RFC3339),
+		Tracks:  make([]xspfTrack, 0, len(tracks)),
+	}
+	for _, t := range tracks {
+		track := xspfTrack{
+			Location: t.Location,
+			Title:    t.Title,
+			Creator:  t.Artist,
+			Album:    t.Album,
+			Duration: t.DurationSeconds * 1000,
+		}
+		if t.Checksum != "" {
+			track.Identifier = "urn:sha256:" + t.Checksum
+		}
+		playlist.Tracks = append(playlist.Tracks, track)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(playlist); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// parseXSPF lee un XSPF. Line es la línea donde empieza cada <track>.
+func parseXSPF(r io.Reader) (*ImportList, error) {
+	list := &ImportList{}
+	dec := xml.NewDecoder(r)
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("XSPF inválido: %v", err)
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case depth == 0 && el.Name.Local != "playlist":
+				return nil, errors.New("XSPF inválido: falta el elemento playlist")
+			case depth == 1 && el.Name.Local == "title":
+				if err := dec.DecodeElement(&list.Name, &el); err != nil {
+					return nil, fmt.Errorf("XSPF inválido: %v", err)
+				}
+				list.Name = strings.TrimSpace(list.Name)
+				continue
+			case el.Name.Local == "track":
+				line, _ := dec.InputPos()
+				var track xspfTrack
+				if err := dec.DecodeElement(&track, &el); err != nil {
+					return nil, fmt.Errorf("XSPF inválido: %v", err)
+				}
+				item := ImportItem{
+					Line:     line,
+					Title:    strings.TrimSpace(track.Title),
+					Artist:   strings.TrimSpace(track.Creator),
+					Location: strings.TrimSpace(track.Location),
+					Seconds:  track.Duration / 1000,
+				}
+				if checksum, ok := strings.CutPrefix(strings.TrimSpace(track.Identifier), "urn:sha256:"); ok {
+					item.Checksum = checksum
+				}
+				item.Text = trackText(item.Artist, item.Title, item.Location)
+				list.Items = append(list.Items, item)
+				continue
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return list, nil
+}
+
+// ParseHistory lee un historial en JSON Lines. Las líneas sin fecha, con
+// fecha futura o con un estado desconocido se informan como inválidas.
+func ParseHistory(r io.Reader) (*ImportList, error) {
+	list := &ImportList{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	now := time.Now()
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\uFEFF")
+		}
+		if text == "" {
+			continue
+		}
+		invalid := func(reason string) {
+			list.Invalid = append(list.Invalid, ImportUnmatched{Line: line, Text: text, Reason: reason})
+		}
+
+		var entry HistoryEntry
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			invalid("línea inválida")
+			continue
+		}
+		if entry.Status == "" {
+			entry.Status = "completed"
+		}
+		switch {
+		case entry.PlayedAt.IsZero():
+			invalid("falta played_at")
+			continue
+		case entry.PlayedAt.After(now):
+			invalid("played_at está en el futuro")
+			continue
+		case entry.Status != "playing" && entry.Status != "paused" && entry.Status != "completed":
+			invalid("estado desconocido")
+			continue
+		}
+		list.Items = append(list.Items, ImportItem{
+			Line:     line,
+			Text:     trackText(entry.Artist, entry.Title, entry.FilePath),
+			Title:    entry.Title,
+			Artist:   entry.Artist,
+			Location: entry.FilePath,
+			Checksum: entry.Checksum,
+			PlayedAt: entry.PlayedAt,
+			Seconds:  max(0, entry.Seconds),
+			Status:   entry.Status,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// MatchCandidate es una canción del catálogo contra la que se buscan las
+// entradas importadas
+type MatchCandidate struct {
+	ID       int
+	Title    string
+	Artist   string
+	FilePath string
+	Checksum string
+}
+
+// SongMatcher encuentra la canción de una entrada importada: primero por
+// checksum, luego por nombre de archivo y por último por título y artista
+// (sin mayúsculas ni acentos). Si varias canciones coinciden gana la de
+// menor ID, la primera que se subió.
+type SongMatcher struct {
+	byChecksum    map[string]int
+	byPath        map[string]int
+	byTitleArtist map[string]int
+}
+
+// NewSongMatcher indexa las canciones; deben venir ordenadas por ID
+func NewSongMatcher(songs []MatchCandidate) *SongMatcher {
+	m := &SongMatcher{
+		byChecksum:    make(map[string]int, len(songs)),
+		byPath:        make(map[string]int, len(songs)),
+		byTitleArtist: make(map[string]int, len(songs)),
+	}
+	add := func(index map[string]int, key string, id int) {
+		if _, taken := index[key]; key != "" && !taken {
+			index[key] = id
+		}
+	}
+	for _, s := range songs {
+		add(m.byChecksum, strings.ToLower(s.Checksum), s.ID)
+		add(m.byPath, pathKey(s.FilePath), s.ID)
+		add(m.byTitleArtist, titleArtistKey(s.Title, s.Artist), s.ID)
+	}
+	return m
+}
+
+// Match devuelve el ID de la canción y cómo se encontró, o 0 si no hay
+func (m *SongMatcher) Match(item ImportItem) (int, string) {
+	if id, ok := m.byChecksum[strings.ToLower(strings.TrimSpace(item.Checksum))]; ok {
+		return id, MatchChecksum
+	}
+	if id, ok := m.byPath[pathKey(item.Location)]; ok {
+		return id, MatchPath
+	}
+	if id, ok := m.byTitleArtist[titleArtistKey(item.Title, item.Artist)]; ok {
+		return id, MatchTitleArtist
+	}
+	return 0, ""
+}
+
+// pathKey reduce una ruta, URL file:// o http(s) a su nombre de archivo
+// en minúsculas, porque el directorio cambia entre servidores y equipos
+func pathKey(location string) string {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return ""
+	}
+	if u, err := url.Parse(location); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		location = u.Path
+	} else if unescaped, err := url.PathUnescape(location); err == nil {
+		location = unescaped
+	}
+	name := path.Base(strings.ReplaceAll(location, `\`, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return strings.ToLower(name)
+}
+
+// titleArtistKey une título y artista normalizados; vacío si falta alguno
+func titleArtistKey(title, artist string) string {
+	title = strings.Join(strings.Fields(NormalizeText(title)), " ")
+	artist = strings.Join(strings.Fields(NormalizeText(artist)), " ")
+	if title == "" || artist == "" {
+		return ""
+	}
+	return artist + "\x00" + title
+}
+
+// trackText describe una entrada para el reporte: "Artista - Título" o la ruta
+func trackText(artist, title, location string) string {
+	switch {
+	case artist != "" && title != "":
+		return artist + " - " + title
+	case title != "":
+		return title
+	}
+	return location
+}
+
+// oneLine quita los saltos de línea, que cortarían una entrada del M3U8
+func oneLine(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return r == '\n' || r == '\r' }), " ")
+}
@@ -0,0 +1,55 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase Favorite, canciones marcadas como
+favoritas por un usuario con la fecha en que se agregaron
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	FavoriteSortDate  = "date"
+	FavoriteSortTitle = "title"
+)
+
+// Favorite es una canción favorita de un usuario
+type Favorite struct {
+	SongID    int       `json:"song_id"`
+	Title     string    `json:"title"`
+	Artist    string    `json:"artist"`
+	Genre     string    `json:"genre"`
+	FilePath  string    `json:"file_path"`
+	LikedAt   time.Time `json:"liked_at"`
+	LikeCount int       `json:"like_count"`
+}
+
+// FavoriteOrder traduce el orden pedido a la cláusula ORDER BY del listado.
+// Por defecto las más recientes primero y por título de la A a la Z.
+func FavoriteOrder(sort, order string) (string, error) {
+	if sort == "" {
+		sort = FavoriteSortDate
+	}
+	if order == "" {
+		order = "desc"
+		if sort == FavoriteSortTitle {
+			order = "asc"
+		}
+	}
+	if order != "asc" && order != "desc" {
+		return "", fmt.Errorf("orden inválido: %s", order)
+	}
+
+	switch sort {
+	case FavoriteSortDate:
+		return "f.liked_at " + order + ", f.song_id " + order, nil
+	case FavoriteSortTitle:
+		return "s.title " + order + ", f.song_id " + order, nil
+	}
+	return "", fmt.Errorf("criterio de orden inválido: %s", sort)
+}
@@ -0,0 +1,124 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase FsckReport, resultado de comparar la
+tabla songs con los archivos de ./uploads/songs
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type FsckIssueKind string
+
+const (
+	FsckOrphanFile      FsckIssueKind = "orphan_file"      // Archivo sin canción
+	FsckMissingFile     FsckIssueKind = "missing_file"     // Canción sin archivo
+	FsckSizeMismatch    FsckIssueKind = "size_mismatch"    // file_size no coincide con el archivo
+	FsckChecksumError   FsckIssueKind = "checksum_error"   // El contenido cambió desde que se subió
+	FsckMissingChecksum FsckIssueKind = "missing_checksum" // La canción nunca tuvo checksum
+)
+
+// DefaultOrphanGrace es la antigüedad mínima de un archivo sin canción para
+// considerarlo huérfano; los más nuevos pueden ser subidas en curso
+const DefaultOrphanGrace = time.Hour
+
+// FsckOptions indica qué revisar y qué reparar. Sin opciones de reparación
+// solo se informa.
+type FsckOptions struct {
+	VerifyChecksums bool          // Leer los archivos completos para comparar el checksum
+	DeleteOrphans   bool          // Borrar los archivos huérfanos
+	TrashMissing    bool          // Enviar a la papelera las canciones sin archivo
+	FixSizes        bool          // Actualizar file_size con el tamaño real
+	FillChecksums   bool          // Guardar el checksum de las canciones que no lo tienen
+	TrashCorrupt    bool          // Enviar a la papelera las canciones con checksum distinto
+	OrphanGrace     time.Duration // Antigüedad mínima de un huérfano
+}
+
+// Repairs indica si alguna opción de reparación está activa
+func (o FsckOptions) Repairs() bool {
+	return o.DeleteOrphans || o.TrashMissing || o.FixSizes || o.FillChecksums || o.TrashCorrupt
+}
+
+// FsckIssue es un problema encontrado
+type FsckIssue struct {
+	Kind     FsckIssueKind `json:"kind"`
+	SongID   int           `json:"song_id,omitempty"`
+	FilePath string        `json:"file_path"`
+	Expected string        `json:"expected,omitempty"`
+	Actual   string        `json:"actual,omitempty"`
+	Repaired bool          `json:"repaired"`
+	Error    string        `json:"error,omitempty"` // Si la reparación falló
+}
+
+// FsckReport es el resultado de una revisión
+type FsckReport struct {
+	StartedAt    time.Time   `json:"started_at"`
+	FinishedAt   time.Time   `json:"finished_at"`
+	SongsChecked int         `json:"songs_checked"`
+	FilesScanned int         `json:"files_scanned"`
+	Issues       []FsckIssue `json:"issues"`
+}
+
+// Add agrega un problema al reporte
+func (r *FsckReport) Add(issue FsckIssue) *FsckIssue {
+	r.Issues = append(r.Issues, issue)
+	return &r.Issues[len(r.Issues)-1]
+}
+
+// Counts devuelve cuántos problemas hay de cada tipo
+func (r *FsckReport) Counts() map[FsckIssueKind]int {
+	counts := make(map[FsckIssueKind]int)
+	for _, issue := range r.Issues {
+		counts[issue.Kind]++
+	}
+	return counts
+}
+
+// Unrepaired cuenta los problemas que siguen pendientes
+func (r *FsckReport) Unrepaired() int {
+	n := 0
+	for _, issue := range r.Issues {
+		if !issue.Repaired {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary resume el reporte en una línea, por ejemplo
+// "120 canciones, 118 archivos: 2 missing_file (1 reparados)"
+func (r *FsckReport) Summary() string {
+	head := fmt.Sprintf("%d canciones, %d archivos", r.SongsChecked, r.FilesScanned)
+	if len(r.Issues) == 0 {
+		return head + ": sin problemas"
+	}
+
+	repaired := make(map[FsckIssueKind]int)
+	for _, issue := range r.Issues {
+		if issue.Repaired {
+			repaired[issue.Kind]++
+		}
+	}
+	counts := r.Counts()
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, string(kind))
+	}
+	sort.Strings(kinds)
+
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%d %s", counts[FsckIssueKind(kind)], kind)
+		if n := repaired[FsckIssueKind(kind)]; n > 0 {
+			parts[i] += fmt.Sprintf(" (%d reparados)", n)
+		}
+	}
+	return head + ": " + strings.Join(parts, ", ")
+}
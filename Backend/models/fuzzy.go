@@ -0,0 +1,220 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase FuzzyIndex, indice en memoria por
+trigramas para sugerencias tolerantes a errores de escritura
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	DefaultSuggestLimit = 8
+	MaxSuggestLimit     = 25
+	minSuggestScore     = 0.3 // Similitud mínima para sugerir una canción
+)
+
+// Suggestion es una canción sugerida mientras el usuario escribe
+type Suggestion struct {
+	SongID int     `json:"song_id"`
+	Title  string  `json:"title"`
+	Artist string  `json:"artist"`
+	Field  string  `json:"matched_field"` // "title" o "artist"
+	Score  float64 `json:"score"`
+}
+
+type fuzzyDoc struct {
+	title, artist string
+	normTitle     string
+	normArtist    string
+	titleGrams    map[string]bool
+	artistGrams   map[string]bool
+	titleWords    []string
+	artistWords   []string
+}
+
+// FuzzyIndex indexa títulos y artistas por trigramas. Las lecturas pueden
+// ser concurrentes mientras las subidas de canciones lo modifican.
+type FuzzyIndex struct {
+	mu       sync.RWMutex
+	docs     map[int]*fuzzyDoc
+	trigrams map[string]map[int]bool // trigrama -> canciones que lo contienen
+}
+
+// NewFuzzyIndex crea un índice vacío
+func NewFuzzyIndex() *FuzzyIndex {
+	return &FuzzyIndex{
+		docs:     make(map[int]*fuzzyDoc),
+		trigrams: make(map[string]map[int]bool),
+	}
+}
+
+// Upsert agrega o actualiza una canción en el índice
+func (idx *FuzzyIndex) Upsert(songID int, title, artist string) {
+	doc := &fuzzyDoc{
+		title:      title,
+		artist:     artist,
+		normTitle:  NormalizeText(title),
+		normArtist: NormalizeText(artist),
+	}
+	doc.titleGrams = trigrams(doc.normTitle)
+	doc.artistGrams = trigrams(doc.normArtist)
+	doc.titleWords = strings.Fields(doc.normTitle)
+	doc.artistWords = strings.Fields(doc.normArtist)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.removeLocked(songID)
+	idx.docs[songID] = doc
+	for _, grams := range []map[string]bool{doc.titleGrams, doc.artistGrams} {
+		for g := range grams {
+			if idx.trigrams[g] == nil {
+				idx.trigrams[g] = make(map[int]bool)
+			}
+			idx.trigrams[g][songID] = true
+		}
+	}
+}
+
+// Remove quita una canción del índice
+func (idx *FuzzyIndex) Remove(songID int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.removeLocked(songID)
+}
+
+// Len devuelve la cantidad de canciones indexadas
+func (idx *FuzzyIndex) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+func (idx *FuzzyIndex) removeLocked(songID int) {
+	doc, ok := idx.docs[songID]
+	if !ok {
+		return
+	}
+	for _, grams := range []map[string]bool{doc.titleGrams, doc.artistGrams} {
+		for g := range grams {
+			delete(idx.trigrams[g], songID)
+			if len(idx.trigrams[g]) == 0 {
+				delete(idx.trigrams, g)
+			}
+		}
+	}
+	delete(idx.docs, songID)
+}
+
+// Suggest devuelve las canciones más parecidas a lo escrito. Tolera errores
+// de escritura ("metalica", "bohemian rapsody") comparando trigramas, y
+// favorece las coincidencias por prefijo para sugerir mientras se escribe.
+func (idx *FuzzyIndex) Suggest(query string, limit int) []Suggestion {
+	if limit < 1 {
+		limit = DefaultSuggestLimit
+	}
+	if limit > MaxSuggestLimit {
+		limit = MaxSuggestLimit
+	}
+
+	normQuery := strings.Join(strings.Fields(NormalizeText(query)), " ")
+	if normQuery == "" {
+		return []Suggestion{}
+	}
+	queryGrams := trigrams(normQuery)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Candidatas: canciones que comparten al menos un tercio de los trigramas
+	hits := make(map[int]int)
+	for g := range queryGrams {
+		for songID := range idx.trigrams[g] {
+			hits[songID]++
+		}
+	}
+	minHits := (len(queryGrams) + 2) / 3
+
+	results := make([]Suggestion, 0)
+	for songID, n := range hits {
+		if n < minHits {
+			continue
+		}
+		doc := idx.docs[songID]
+		titleScore := fieldScore(normQuery, queryGrams, doc.normTitle, doc.titleGrams, doc.titleWords)
+		artistScore := fieldScore(normQuery, queryGrams, doc.normArtist, doc.artistGrams, doc.artistWords)
+
+		s := Suggestion{SongID: songID, Title: doc.title, Artist: doc.artist, Field: "title", Score: titleScore}
+		if artistScore > titleScore {
+			s.Field = "artist"
+			s.Score = artistScore
+		}
+		if s.Score >= minSuggestScore {
+			results = append(results, s)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Title < results[j].Title
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// fieldScore calcula la similitud (0 a 1) entre la búsqueda y un campo
+func fieldScore(query string, queryGrams map[string]bool, field string, fieldGrams map[string]bool, words []string) float64 {
+	if field == "" {
+		return 0
+	}
+	if strings.HasPrefix(field, query) {
+		return 1
+	}
+	for _, w := range words {
+		if strings.HasPrefix(w, query) {
+			return 0.9
+		}
+	}
+
+	// Coeficiente de Dice entre los trigramas de la búsqueda y del campo
+	common := 0
+	for g := range queryGrams {
+		if fieldGrams[g] {
+			common++
+		}
+	}
+	dice := 2 * float64(common) / float64(len(queryGrams)+len(fieldGrams))
+
+	// Mientras se escribe, la búsqueda suele ser más corta que el campo: se
+	// mide también qué parte de la búsqueda aparece en él
+	coverage := float64(common) / float64(len(queryGrams))
+	score := (dice + coverage) / 2
+	if score > 0.85 {
+		score = 0.85
+	}
+	return score
+}
+
+// trigrams devuelve los trigramas de cada palabra, con relleno en los bordes
+// para que el inicio de las palabras tenga más peso
+func trigrams(text string) map[string]bool {
+	grams := make(map[string]bool)
+	for _, word := range strings.Fields(text) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			grams[string(runes[i:i+3])] = true
+		}
+	}
+	return grams
+}
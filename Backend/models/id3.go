@@ -0,0 +1,177 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Lectura de los marcos de la etiqueta ID3v2 (versiones 2.2, 2.3
+y 2.4) del inicio de un archivo MP3, para extraer portada y letra
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf16"
+)
+
+// maxID3Size limita el tamaño de la etiqueta que se carga en memoria
+const maxID3Size = 2 * MaxCoverBytes
+
+var errNoID3 = errors.New("el archivo no tiene una etiqueta ID3v2 legible")
+
+// id3Frame es un marco de la etiqueta ya sin desincronización. En ID3v2.2
+// los identificadores tienen 3 letras (PIC, ULT, SLT).
+type id3Frame struct {
+	ID   string
+	Body []byte
+}
+
+// readID3Frames lee la etiqueta del inicio del archivo y deja el lector
+// justo después de ella. Los marcos comprimidos o cifrados se omiten.
+func readID3Frames(r io.Reader) ([]id3Frame, error) {
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil || string(header[:3]) != "ID3" {
+		return nil, errNoID3
+	}
+	version, flags := header[3], header[5]
+	size := syncsafe(header[6:10])
+	if version < 2 || version > 4 || size > maxID3Size {
+		return nil, errNoID3
+	}
+
+	tag := make([]byte, size)
+	if _, err := io.ReadFull(r, tag); err != nil {
+		return nil, errNoID3
+	}
+	if flags&0x80 != 0 && version < 4 {
+		tag = removeUnsync(tag)
+	}
+	if flags&0x40 != 0 && version > 2 { // Cabecera extendida
+		if len(tag) < 4 {
+			return nil, errNoID3
+		}
+		ext := int(binary.BigEndian.Uint32(tag))
+		if version == 4 {
+			ext = syncsafe(tag[:4])
+		} else {
+			ext += 4
+		}
+		if ext > len(tag) {
+			return nil, errNoID3
+		}
+		tag = tag[ext:]
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	var frames []id3Frame
+	for len(tag) >= headerLen && tag[0] != 0 {
+		id := string(tag[:idLen])
+		var frameSize int
+		var frameFlags byte
+		switch version {
+		case 2:
+			frameSize = int(tag[3])<<16 | int(tag[4])<<8 | int(tag[5])
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(tag[4:8]))
+			frameFlags = tag[9]
+		case 4:
+			frameSize = syncsafe(tag[4:8])
+			frameFlags = tag[9]
+		}
+		if frameSize < 0 || headerLen+frameSize > len(tag) {
+			break
+		}
+		body := tag[headerLen : headerLen+frameSize]
+		tag = tag[headerLen+frameSize:]
+
+		if version == 3 && frameFlags&0xC0 != 0 { // Comprimido o cifrado
+			continue
+		}
+		if version == 4 {
+			if frameFlags&0x0C != 0 {
+				continue
+			}
+			if frameFlags&0x01 != 0 { // Indicador de longitud de datos
+				if len(body) < 4 {
+					continue
+				}
+				body = body[4:]
+			}
+			if frameFlags&0x02 != 0 || flags&0x80 != 0 {
+				body = removeUnsync(body)
+			}
+		}
+		frames = append(frames, id3Frame{ID: id, Body: body})
+	}
+	return frames, nil
+}
+
+// splitID3String separa una cadena terminada en cero del resto del marco.
+// UTF-16 (codificaciones 1 y 2) termina con dos ceros alineados.
+func splitID3String(encoding byte, b []byte) (str, rest []byte, ok bool) {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				return b[:i], b[i+2:], true
+			}
+		}
+		return nil, nil, false
+	}
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return nil, nil, false
+	}
+	return b[:i], b[i+1:], true
+}
+
+// decodeID3Text convierte el texto de un marco a UTF-8 según su
+// codificación: 0 ISO-8859-1, 1 UTF-16 con BOM, 2 UTF-16BE, 3 UTF-8
+func decodeID3Text(encoding byte, b []byte) string {
+	switch encoding {
+	case 0:
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return string(runes)
+	case 1, 2:
+		bigEndian := encoding == 2
+		if len(b) >= 2 && b[0] == 0xFF && b[1] == 0xFE {
+			bigEndian, b = false, b[2:]
+		} else if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+			bigEndian, b = true, b[2:]
+		}
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			if bigEndian {
+				units[i] = binary.BigEndian.Uint16(b[2*i:])
+			} else {
+				units[i] = binary.LittleEndian.Uint16(b[2*i:])
+			}
+		}
+		return string(utf16.Decode(units))
+	}
+	return string(b)
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+// removeUnsync deshace la "desincronización" de ID3: 0xFF 0x00 -> 0xFF
+func removeUnsync(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		out = append(out, b[i])
+		if b[i] == 0xFF && i+1 < len(b) && b[i+1] == 0 {
+			i++
+		}
+	}
+	return out
+}
@@ -10,7 +10,6 @@ package models
 
 import (
 	"errors"
-	"fmt"
 	"strings"
 	"time"
 )
@@ -21,37 +20,27 @@ type Library struct {
 	UserID      int               `json:"user_id"`
 	Songs       []Song            `json:"songs"`
 	SongMap     map[string][]Song `json:"song_map"`
-	Favorites   map[int][]int     `json:"favorites"` // Mapa de favoritos por usuario
 	CreatedAt   time.Time         `json:"created_at"`
 	LastUpdated time.Time         `json:"last_updated"`
 	TotalSize   int64             `json:"total_size"` // Tamaño total en bytes
+	Plan        Plan              `json:"plan"`       // Límites de canciones y espacio
 }
 
-const (
-	MaxSongs    = 60               // Límite máximo de canciones
-	MaxSongSize = 10 * 1024 * 1024 // 10 MB en bytes
-)
-
 // NewLibrary crea una nueva instancia de Library
 func NewLibrary(userID int) *Library {
 	return &Library{
 		UserID:      userID,
 		Songs:       make([]Song, 0),
 		SongMap:     make(map[string][]Song),
-		Favorites:   make(map[int][]int),
 		CreatedAt:   time.Now(),
 		LastUpdated: time.Now(),
 		TotalSize:   0,
+		Plan:        DefaultPlan,
 	}
 }
 
 // AddSong añade una nueva canción a la biblioteca
 func (l *Library) AddSong(song Song) error {
-	// Verificar límite de canciones
-	if len(l.Songs) >= MaxSongs {
-		return fmt.Errorf("límite de canciones alcanzado (máximo %d)", MaxSongs)
-	}
-
 	// Verificar si la canción ya existe
 	for _, s := range l.Songs {
 		if s.ID == song.ID {
@@ -59,10 +48,9 @@ func (l *Library) AddSong(song Song) error {
 		}
 	}
 
-	// Verificar si el nuevo tamaño total excedería el límite
-	newTotalSize := l.TotalSize + int64(song.FileSize)
-	if newTotalSize > int64(MaxSongs*MaxSongSize) {
-		return errors.New("el tamaño total de la biblioteca excedería el límite")
+	// Verificar los límites del plan (canciones, tamaño y espacio total)
+	if err := l.Quota().CheckUpload(int64(song.FileSize)); err != nil {
+		return err
 	}
 
 	// Añadir la canción
@@ -73,43 +61,9 @@ func (l *Library) AddSong(song Song) error {
 	return nil
 }
 
-// AddFavorite agrega una canción a los favoritos de un usuario
-func (l *Library) AddFavorite(userID, songID int) error {
-	// Verificar si la canción está en la biblioteca
-	_, err := l.GetSongByID(songID)
-	if err != nil {
-		return fmt.Errorf("la canción no se encuentra en la biblioteca")
-	}
-
-	// Agregar la canción a la lista de favoritos del usuario
-	if _, exists := l.Favorites[userID]; !exists {
-		l.Favorites[userID] = []int{}
-	}
-	l.Favorites[userID] = append(l.Favorites[userID], songID)
-	l.LastUpdated = time.Now()
-	return nil
-}
-
-// RemoveFavorite elimina una canción de los favoritos de un usuario
-func (l *Library) RemoveFavorite(userID, songID int) error {
-	// Verificar si la canción está en la biblioteca
-	_, err := l.GetSongByID(songID)
-	if err != nil {
-		return fmt.Errorf("la canción no se encuentra en la biblioteca")
-	}
-
-	// Eliminar la canción de la lista de favoritos
-	if _, exists := l.Favorites[userID]; exists {
-		for i, id := range l.Favorites[userID] {
-			if id == songID {
-				l.Favorites[userID] = append(l.Favorites[userID][:i], l.Favorites[userID][i+1:]...)
-				break
-			}
-		}
-	}
-
-	l.LastUpdated = time.Now()
-	return nil
+// Quota devuelve los límites de la biblioteca y lo que queda disponible
+func (l *Library) Quota() Quota {
+	return NewQuota(l.Plan, QuotaUsage{Songs: len(l.Songs), StorageBytes: l.TotalSize})
 }
 
 // GetSongByID busca una canción por su ID
@@ -0,0 +1,414 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase LoudnessMeter, medicion de sonoridad
+integrada (EBU R128 / ITU-R BS.1770) y pico real de una cancion, y calculo
+de la ganancia de pista y de album para normalizar el volumen
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	// ReferenceLoudness es el nivel al que se normaliza (ReplayGain 2.0)
+	ReferenceLoudness = -18.0
+	// SilenceDB es el nivel que se informa para una señal en silencio
+	SilenceDB = -150.0
+
+	absoluteGate   = -70.0 // LUFS
+	relativeGate   = -10.0 // LU respecto de la sonoridad sin compuerta relativa
+	histogramStep  = 0.1   // LU por casilla del histograma de bloques
+	truePeakTaps   = 12    // Coeficientes por fase del filtro de sobremuestreo
+	subBlocksCount = 4     // Un bloque de 400 ms son 4 sub-bloques de 100 ms
+)
+
+// ErrNoAudio indica que el archivo no tiene muestras que medir
+var ErrNoAudio = errors.New("el archivo no tiene audio")
+
+// LoudnessHistogram cuenta los bloques de 400 ms que pasaron la compuerta
+// absoluta, agrupados en casillas de 0.1 LU. Guardarlo permite calcular la
+// sonoridad de un álbum sin volver a decodificar sus canciones.
+type LoudnessHistogram map[int]int
+
+// MarshalJSON guarda las casillas como objeto {"-231": 12, ...}
+func (h LoudnessHistogram) MarshalJSON() ([]byte, error) {
+	m := make(map[string]int, len(h))
+	for bin, n := range h {
+		m[strconv.Itoa(bin)] = n
+	}
+	return json.Marshal(m)
+}
+
+func (h *LoudnessHistogram) UnmarshalJSON(data []byte) error {
+	var m map[string]int
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*h = make(LoudnessHistogram, len(m))
+	for key, n := range m {
+		bin, err := strconv.Atoi(key)
+		if err != nil {
+			return err
+		}
+		(*h)[bin] = n
+	}
+	return nil
+}
+
+// Merge suma los bloques de otro histograma (por ejemplo otra pista del álbum)
+func (h LoudnessHistogram) Merge(other LoudnessHistogram) {
+	for bin, n := range other {
+		h[bin] += n
+	}
+}
+
+// Integrated calcula la sonoridad integrada con las dos compuertas de
+// BS.1770; ok es false si no hay bloques por encima de la compuerta absoluta
+func (h LoudnessHistogram) Integrated() (lufs float64, ok bool) {
+	bins := make([]int, 0, len(h))
+	for bin := range h {
+		bins = append(bins, bin)
+	}
+	sort.Ints(bins)
+
+	energy := func(minLoudness float64) (float64, int) {
+		var sum float64
+		var count int
+		for _, bin := range bins {
+			l := float64(bin)*histogramStep + histogramStep/2
+			if l < minLoudness {
+				continue
+			}
+			sum += float64(h[bin]) * loudnessToEnergy(l)
+			count += h[bin]
+		}
+		return sum, count
+	}
+
+	sum, count := energy(absoluteGate)
+	if count == 0 {
+		return 0, false
+	}
+	gate := energyToLoudness(sum/float64(count)) + relativeGate
+	sum, count = energy(gate)
+	if count == 0 {
+		return 0, false
+	}
+	return energyToLoudness(sum / float64(count)), true
+}
+
+// LoudnessAnalysis es el resultado de medir una pista
+type LoudnessAnalysis struct {
+	IntegratedLUFS float64           // Sonoridad integrada; -Inf si la pista es silencio
+	TruePeak       float64           // Pico real lineal (1.0 = 0 dBTP)
+	Histogram      LoudnessHistogram // Bloques con compuerta absoluta
+	Duration       float64           // Segundos medidos
+}
+
+// Silent indica que ningún bloque superó la compuerta absoluta
+func (a *LoudnessAnalysis) Silent() bool {
+	return math.IsInf(a.IntegratedLUFS, -1)
+}
+
+// TruePeakDBTP devuelve el pico real en dBTP
+func (a *LoudnessAnalysis) TruePeakDBTP() float64 {
+	return LinearToDB(a.TruePeak)
+}
+
+// SongLoudness son los datos de normalización que se guardan con la canción
+// y que usa el reproductor para ajustar el volumen
+type SongLoudness struct {
+	IntegratedLUFS *float64 `json:"integrated_lufs"` // nil si la pista es silencio
+	TruePeakDBTP   float64  `json:"true_peak_dbtp"`
+	TrackGainDB    float64  `json:"track_gain_db"`
+	TrackPeak      float64  `json:"track_peak"`
+	AlbumGainDB    float64  `json:"album_gain_db"`
+	AlbumPeak      float64  `json:"album_peak"`
+	ReferenceLUFS  float64  `json:"reference_lufs"`
+}
+
+// NewSongLoudness arma los datos de la pista; la ganancia de álbum empieza
+// igual a la de la pista hasta que se calcule la del álbum
+func NewSongLoudness(a *LoudnessAnalysis) SongLoudness {
+	s := SongLoudness{
+		TruePeakDBTP:  a.TruePeakDBTP(),
+		TrackPeak:     a.TruePeak,
+		ReferenceLUFS: ReferenceLoudness,
+	}
+	if !a.Silent() {
+		lufs := a.IntegratedLUFS
+		s.IntegratedLUFS = &lufs
+		s.TrackGainDB = GainFor(lufs)
+	}
+	s.AlbumGainDB = s.TrackGainDB
+	s.AlbumPeak = s.TrackPeak
+	return s
+}
+
+// GainFor devuelve la ganancia en dB que lleva la sonoridad al nivel de referencia
+func GainFor(lufs float64) float64 {
+	return math.Round((ReferenceLoudness-lufs)*100) / 100
+}
+
+// LinearToDB convierte una amplitud lineal a decibeles, sin bajar de SilenceDB
+func LinearToDB(v float64) float64 {
+	if v <= 0 {
+		return SilenceDB
+	}
+	return math.Max(20*math.Log10(v), SilenceDB)
+}
+
+func loudnessToEnergy(lufs float64) float64 {
+	return math.Pow(10, (lufs+0.691)/10)
+}
+
+func energyToLoudness(energy float64) float64 {
+	if energy <= 0 {
+		return math.Inf(-1)
+	}
+	return -0.691 + 10*math.Log10(energy)
+}
+
+// biquad es un filtro IIR de segundo orden (forma directa I)
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting devuelve los dos filtros de ponderación K de BS.1770
+// (realce de agudos y pasa-altos) calculados para cualquier frecuencia de
+// muestreo; a 48 kHz coinciden con los coeficientes de la norma
+func kWeighting(sampleRate int) (shelf, highPass biquad) {
+	rate := float64(sampleRate)
+
+	f0, gain, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / rate)
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf = biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / rate)
+	a0 = 1 + k/q + k*k
+	highPass = biquad{
+		b0: 1, b1: -2, b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highPass
+}
+
+// truePeakDetector estima el pico entre muestras sobremuestreando con un
+// filtro polifásico (BS.1770 anexo 2): x4 bajo 96 kHz, x2 bajo 192 kHz
+type truePeakDetector struct {
+	factor  int
+	phases  [][]float64
+	history []float64 // Buffer circular duplicado: history[pos:pos+truePeakTaps] son las últimas muestras
+	pos     int
+	peak    float64
+}
+
+func newTruePeakDetector(sampleRate int) *truePeakDetector {
+	factor := 1
+	switch {
+	case sampleRate < 96000:
+		factor = 4
+	case sampleRate < 192000:
+		factor = 2
+	}
+	d := &truePeakDetector{factor: factor, history: make([]float64, 2*truePeakTaps)}
+	if factor == 1 {
+		return d
+	}
+
+	// Pasa-bajos de sinc con ventana de Hann, cortado en la Nyquist original
+	n := factor * truePeakTaps
+	center := float64(n-1) / 2
+	d.phases = make([][]float64, factor)
+	for p := range d.phases {
+		d.phases[p] = make([]float64, truePeakTaps)
+	}
+	for i := 0; i < n; i++ {
+		t := (float64(i) - center) / float64(factor)
+		h := 1.0
+		if t != 0 {
+			h = math.Sin(math.Pi*t) / (math.Pi * t)
+		}
+		h *= 0.5 - 0.5*math.Cos(2*math.Pi*float64(i+1)/float64(n+1))
+		d.phases[i%factor][i/factor] = h
+	}
+	return d
+}
+
+func (d *truePeakDetector) add(x float64) {
+	if a := math.Abs(x); a > d.peak {
+		d.peak = a
+	}
+	if d.factor == 1 {
+		return
+	}
+	if d.pos == 0 {
+		d.pos = truePeakTaps
+	}
+	d.pos--
+	d.history[d.pos] = x
+	d.history[d.pos+truePeakTaps] = x
+	window := d.history[d.pos : d.pos+truePeakTaps]
+	for _, coeffs := range d.phases {
+		var y float64
+		for k, c := range coeffs {
+			y += c * window[k]
+		}
+		if a := math.Abs(y); a > d.peak {
+			d.peak = a
+		}
+	}
+}
+
+// LoudnessMeter mide una señal que se le entrega por partes con Write
+type LoudnessMeter struct {
+	channels     int
+	sampleRate   int
+	shelf        []biquad
+	highPass     []biquad
+	peaks        []*truePeakDetector
+	subBlockSize int
+	subBlockPos  int
+	subBlockSum  float64                 // Suma de cuadrados ponderada del sub-bloque actual
+	subBlocks    [subBlocksCount]float64 // Energías medias de los últimos sub-bloques
+	filled       int
+	blocks       []float64 // Energía de cada bloque de 400 ms que pasó la compuerta absoluta
+	histogram    LoudnessHistogram
+	frames       int64
+}
+
+// NewLoudnessMeter prepara la medición para el formato indicado. Todos los
+// canales pesan 1.0: los formatos que se decodifican son mono o estéreo.
+func NewLoudnessMeter(channels, sampleRate int) *LoudnessMeter {
+	m := &LoudnessMeter{
+		channels:     channels,
+		sampleRate:   sampleRate,
+		shelf:        make([]biquad, channels),
+		highPass:     make([]biquad, channels),
+		peaks:        make([]*truePeakDetector, channels),
+		subBlockSize: sampleRate / 10,
+		histogram:    LoudnessHistogram{},
+	}
+	for c := 0; c < channels; c++ {
+		m.shelf[c], m.highPass[c] = kWeighting(sampleRate)
+		m.peaks[c] = newTruePeakDetector(sampleRate)
+	}
+	return m
+}
+
+// Write procesa muestras intercaladas (L, R, L, R, ...) en el rango [-1, 1]
+func (m *LoudnessMeter) Write(samples []float64) {
+	for i := 0; i+m.channels <= len(samples); i += m.channels {
+		for c := 0; c < m.channels; c++ {
+			x := samples[i+c]
+			m.peaks[c].add(x)
+			y := m.highPass[c].process(m.shelf[c].process(x))
+			m.subBlockSum += y * y
+		}
+		m.frames++
+		m.subBlockPos++
+		if m.subBlockPos == m.subBlockSize {
+			m.closeSubBlock()
+		}
+	}
+}
+
+// closeSubBlock cierra 100 ms; cada sub-bloque completa un bloque de 400 ms
+// con los tres anteriores (solapamiento del 75 %)
+func (m *LoudnessMeter) closeSubBlock() {
+	copy(m.subBlocks[:], m.subBlocks[1:])
+	m.subBlocks[subBlocksCount-1] = m.subBlockSum / float64(m.subBlockSize)
+	m.subBlockSum = 0
+	m.subBlockPos = 0
+	if m.filled < subBlocksCount {
+		m.filled++
+		if m.filled < subBlocksCount {
+			return
+		}
+	}
+
+	var energy float64
+	for _, e := range m.subBlocks {
+		energy += e
+	}
+	energy /= subBlocksCount
+
+	l := energyToLoudness(energy)
+	if l < absoluteGate {
+		return
+	}
+	m.blocks = append(m.blocks, energy)
+	m.histogram[int(math.Floor(l/histogramStep))]++
+}
+
+// Result calcula la sonoridad integrada y el pico real de lo medido
+func (m *LoudnessMeter) Result() *LoudnessAnalysis {
+	a := &LoudnessAnalysis{
+		IntegratedLUFS: math.Inf(-1),
+		Histogram:      m.histogram,
+		Duration:       float64(m.frames) / float64(m.sampleRate),
+	}
+	for _, p := range m.peaks {
+		if p.peak > a.TruePeak {
+			a.TruePeak = p.peak
+		}
+	}
+	if len(m.blocks) == 0 {
+		return a
+	}
+
+	var sum float64
+	for _, e := range m.blocks {
+		sum += e
+	}
+	gate := energyToLoudness(sum/float64(len(m.blocks))) + relativeGate
+
+	sum = 0
+	var count int
+	for _, e := range m.blocks {
+		if energyToLoudness(e) >= gate {
+			sum += e
+			count++
+		}
+	}
+	if count > 0 {
+		a.IntegratedLUFS = energyToLoudness(sum / float64(count))
+	}
+	return a
+}
+
+// MeasureLoudness decodifica toda la pista y la mide
+func MeasureLoudness(src AudioStream) (*LoudnessAnalysis, error) {
+	meter := NewLoudnessMeter(src.Channels(), src.SampleRate())
+	if err := DecodeAll(src, meter); err != nil {
+		return nil, err
+	}
+	return meter.Result(), nil
+}
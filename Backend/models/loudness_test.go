@@ -0,0 +1,64 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+// sine genera seconds segundos de un seno de freq Hz con la amplitud de
+// pico dada en dBFS, igual en todos los canales
+func sine(channels, sampleRate int, freq, dbfs, seconds float64) []float64 {
+	amplitude := math.Pow(10, dbfs/20)
+	frames := int(seconds * float64(sampleRate))
+	samples := make([]float64, frames*channels)
+	for i := 0; i < frames; i++ {
+		x := amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+		for c := 0; c < channels; c++ {
+			samples[i*channels+c] = x
+		}
+	}
+	return samples
+}
+
+func TestLoudnessMeterSine(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels int
+		dbfs     float64
+		wantLUFS float64
+	}{
+		{"mono -20 dBFS", 1, -20, -23},
+		{"mono -30 dBFS", 1, -30, -33},
+		{"estéreo -20 dBFS", 2, -20, -20},
+		{"estéreo -23 dBFS", 2, -23, -23},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meter := NewLoudnessMeter(tt.channels, 48000)
+			meter.Write(sine(tt.channels, 48000, 1000, tt.dbfs, 5))
+			a := meter.Result()
+
+			if math.Abs(a.IntegratedLUFS-tt.wantLUFS) > 0.1 {
+				t.Errorf("IntegratedLUFS = %.2f, se esperaba %.2f", a.IntegratedLUFS, tt.wantLUFS)
+			}
+			if math.Abs(a.TruePeakDBTP()-tt.dbfs) > 0.1 {
+				t.Errorf("TruePeakDBTP = %.2f, se esperaba %.2f", a.TruePeakDBTP(), tt.dbfs)
+			}
+			if math.Abs(a.Duration-5) > 1e-9 {
+				t.Errorf("Duration = %v, se esperaba 5", a.Duration)
+			}
+		})
+	}
+}
+
+func TestLoudnessMeterSilence(t *testing.T) {
+	meter := NewLoudnessMeter(2, 44100)
+	meter.Write(make([]float64, 2*44100*3))
+	a := meter.Result()
+	if !a.Silent() {
+		t.Errorf("IntegratedLUFS = %.2f, se esperaba silencio", a.IntegratedLUFS)
+	}
+	if a.TruePeak != 0 {
+		t.Errorf("TruePeak = %v, se esperaba 0", a.TruePeak)
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase Lyrics, letra de una cancion en texto
+plano o sincronizada (LRC), y su extraccion de los marcos USLT/SYLT de ID3
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formato en que se guarda la letra
+const (
+	LyricsPlain = "plain" // Una línea por renglón, sin tiempos
+	LyricsLRC   = "lrc"   // [mm:ss.xx] antes de cada línea
+)
+
+// Origen de la letra de una canción
+const (
+	LyricsEmbedded = "embedded" // Extraída de las etiquetas del archivo
+	LyricsUpload   = "upload"   // Subida junto con la canción
+	LyricsEdit     = "edit"     // Cargada o corregida por un curador
+	LyricsNone     = "none"     // El archivo no trae letra (o se borró)
+)
+
+// MaxLyricsBytes es el tamaño máximo de la letra de una canción
+const MaxLyricsBytes = 64 << 10
+
+var (
+	ErrNoLyrics      = errors.New("la canción no tiene letra")
+	ErrInvalidLyrics = errors.New("la letra está vacía o supera los 64 KB")
+)
+
+// LyricsLine es una línea de la letra. TimeMs es el momento en que empieza
+// a cantarse (solo en letras sincronizadas).
+type LyricsLine struct {
+	TimeMs *int   `json:"time_ms,omitempty"`
+	Text   string `json:"text"`
+}
+
+// Lyrics es la letra de una canción lista para el reproductor
+type Lyrics struct {
+	SongID    int          `json:"song_id"`
+	Synced    bool         `json:"synced"`
+	Language  string       `json:"language,omitempty"`
+	Source    string       `json:"source"`
+	Lines     []LyricsLine `json:"lines"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// lrcTime reconoce las marcas [mm:ss], [mm:ss.x], [mm:ss.xx] y [mm:ss.xxx]
+var lrcTime = regexp.MustCompile(`^\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+
+// lrcTag reconoce las etiquetas de metadatos como [ar:Artista] u [offset:+250]
+var lrcTag = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+
+// lrcWordTime reconoce las marcas por palabra del LRC extendido (<mm:ss.xx>)
+var lrcWordTime = regexp.MustCompile(`<\d{1,3}:\d{1,2}(?:[.:]\d{1,3})?>`)
+
+// DetectLyricsFormat indica si el texto es LRC (alguna línea empieza con
+// una marca de tiempo) o texto plano
+func DetectLyricsFormat(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if lrcTime.MatchString(strings.TrimSpace(line)) {
+			return LyricsLRC
+		}
+	}
+	return LyricsPlain
+}
+
+// NormalizeLyrics limpia el texto recibido (saltos de línea de Windows,
+// espacios al final) y valida su tamaño
+func NormalizeLyrics(text string) (string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.TrimPrefix(text, "\uFEFF")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	text = strings.TrimSpace(strings.Join(lines, "\n"))
+	if text == "" || len(text) > MaxLyricsBytes {
+		return "", ErrInvalidLyrics
+	}
+	return text, nil
+}
+
+// ParseLyrics convierte la letra guardada en líneas. En LRC una línea puede
+// tener varias marcas (estribillos repetidos) y [offset:N] adelanta la
+// letra N milisegundos; las líneas se ordenan por tiempo.
+func ParseLyrics(text, format string) []LyricsLine {
+	lines := []LyricsLine{}
+	if format != LyricsLRC {
+		for _, line := range strings.Split(text, "\n") {
+			lines = append(lines, LyricsLine{Text: strings.TrimSpace(line)})
+		}
+		return lines
+	}
+
+	offset := 0
+	for _, raw := range strings.Split(text, "\n") {
+		raw = strings.TrimSpace(raw)
+		if m := lrcTag.FindStringSubmatch(raw); m != nil && !lrcTime.MatchString(raw) {
+			if strings.EqualFold(m[1], "offset") {
+				offset, _ = strconv.Atoi(strings.TrimSpace(m[2]))
+			}
+			continue
+		}
+
+		var times []int
+		for {
+			m := lrcTime.FindStringSubmatch(raw)
+			if m == nil {
+				break
+			}
+			times = append(times, lrcMillis(m[1], m[2], m[3]))
+			raw = raw[len(m[0]):]
+		}
+		if len(times) == 0 {
+			continue // Texto sin marca dentro de un LRC: no se puede ubicar
+		}
+		text := strings.TrimSpace(lrcWordTime.ReplaceAllString(raw, ""))
+		for _, t := range times {
+			t = max(0, t-offset)
+			lines = append(lines, LyricsLine{TimeMs: &t, Text: text})
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return *lines[i].TimeMs < *lines[j].TimeMs })
+	return lines
+}
+
+// lrcMillis convierte minutos, segundos y fracción (décimas, centésimas o
+// milésimas según la cantidad de dígitos) a milisegundos
+func lrcMillis(minutes, seconds, fraction string) int {
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.Atoi(seconds)
+	f, _ := strconv.Atoi(fraction)
+	for i := len(fraction); i < 3; i++ {
+		f *= 10
+	}
+	return (m*60+s)*1000 + f
+}
+
+// FormatLRC arma el texto LRC de líneas sincronizadas
+func FormatLRC(lines []LyricsLine) string {
+	var b strings.Builder
+	for _, line := range lines {
+		if line.TimeMs == nil {
+			continue
+		}
+		t := *line.TimeMs
+		fmt.Fprintf(&b, "[%02d:%02d.%02d]%s\n", t/60000, t/1000%60, t%1000/10, line.Text)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// LyricsText devuelve solo las palabras de la letra (sin marcas ni
+// etiquetas), para indexarlas en la búsqueda
+func LyricsText(text, format string) string {
+	var b strings.Builder
+	for _, line := range ParseLyrics(text, format) {
+		b.WriteString(line.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// EmbeddedLyrics es la letra incluida en las etiquetas del archivo
+type EmbeddedLyrics struct {
+	Text     string
+	Format   string
+	Language string
+}
+
+// ExtractLyrics busca la letra en la etiqueta ID3v2 del archivo. Se prefiere
+// la sincronizada (SYLT con tiempos en milisegundos) a la de texto (USLT);
+// una USLT que contiene LRC también se toma como sincronizada.
+func ExtractLyrics(r io.Reader) (*EmbeddedLyrics, error) {
+	frames, err := readID3Frames(bufio.NewReader(r))
+	if err != nil {
+		return nil, ErrNoLyrics
+	}
+
+	var unsynced *EmbeddedLyrics
+	for _, frame := range frames {
+		switch frame.ID {
+		case "SYLT", "SLT":
+			if lyrics, ok := parseSYLT(frame.Body); ok {
+				return lyrics, nil
+			}
+		case "USLT", "ULT":
+			if lyrics, ok := parseUSLT(frame.Body); ok && unsynced == nil {
+				unsynced = lyrics
+			}
+		}
+	}
+	if unsynced == nil {
+		return nil, ErrNoLyrics
+	}
+	return unsynced, nil
+}
+
+// parseUSLT lee un marco USLT: codificación, idioma (3 letras),
+// descripción terminada en cero y el texto
+func parseUSLT(body []byte) (*EmbeddedLyrics, bool) {
+	if len(body) < 5 {
+		return nil, false
+	}
+	encoding, language := body[0], string(body[1:4])
+	_, rest, ok := splitID3String(encoding, body[4:])
+	if !ok {
+		return nil, false
+	}
+	text, err := NormalizeLyrics(decodeID3Text(encoding, rest))
+	if err != nil {
+		return nil, false
+	}
+	return &EmbeddedLyrics{Text: text, Format: DetectLyricsFormat(text), Language: lyricsLanguage(language)}, true
+}
+
+// parseSYLT lee un marco SYLT: codificación, idioma, formato de tiempo
+// (2 = milisegundos), tipo de contenido, descripción y luego pares de texto
+// terminado en cero y tiempo de 32 bits. Los tiempos en cuadros MPEG no se
+// usan porque dependen de la frecuencia de muestreo.
+func parseSYLT(body []byte) (*EmbeddedLyrics, bool) {
+	if len(body) < 7 || body[4] != 2 {
+		return nil, false
+	}
+	encoding, language := body[0], string(body[1:4])
+	_, rest, ok := splitID3String(encoding, body[6:])
+	if !ok {
+		return nil, false
+	}
+
+	var lines []LyricsLine
+	for len(rest) > 0 {
+		var str []byte
+		if str, rest, ok = splitID3String(encoding, rest); !ok || len(rest) < 4 {
+			break
+		}
+		t := int(binary.BigEndian.Uint32(rest))
+		rest = rest[4:]
+		// El texto suele empezar con "\n" para indicar una línea nueva
+		text := strings.Join(strings.Fields(decodeID3Text(encoding, str)), " ")
+		lines = append(lines, LyricsLine{TimeMs: &t, Text: text})
+	}
+	text, err := NormalizeLyrics(FormatLRC(lines))
+	if err != nil {
+		return nil, false
+	}
+	return &EmbeddedLyrics{Text: text, Format: LyricsLRC, Language: lyricsLanguage(language)}, true
+}
+
+// lyricsLanguage valida el código de idioma ISO 639-2 del marco ("XXX" o
+// vacío cuando no se indica)
+func lyricsLanguage(code string) string {
+	code = strings.ToLower(code)
+	for _, r := range code {
+		if r < 'a' || r > 'z' {
+			return ""
+		}
+	}
+	if code == "xxx" {
+		return ""
+	}
+	return code
+}
@@ -0,0 +1,61 @@
+package models
+
+import "testing"
+
+func TestParseLyricsLRC(t *testing.T) {
+	type line struct {
+		ms   int
+		text string
+	}
+	tests := []struct {
+		name string
+		text string
+		want []line
+	}{
+		{
+			name: "varias marcas en una línea",
+			text: "[00:12.00][01:02.50]Estribillo\n[00:05.1]Primera",
+			want: []line{{5100, "Primera"}, {12000, "Estribillo"}, {62500, "Estribillo"}},
+		},
+		{
+			name: "offset positivo adelanta la letra",
+			text: "[offset:+500]\n[00:10.00]Uno\n[00:00.20]Cero",
+			want: []line{{0, "Cero"}, {9500, "Uno"}},
+		},
+		{
+			name: "offset negativo la atrasa",
+			text: "[ar:Artista]\n[offset:-250]\n[00:01.000]Uno",
+			want: []line{{1250, "Uno"}},
+		},
+		{
+			name: "marcas por palabra y texto sin marca",
+			text: "sin tiempo\n[00:03.00]<00:03.00>Hola <00:03.50>mundo",
+			want: []line{{3000, "Hola mundo"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseLyrics(tt.text, LyricsLRC)
+			if len(got) != len(tt.want) {
+				t.Fatalf("se obtuvieron %d líneas, se esperaban %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				if got[i].TimeMs == nil || *got[i].TimeMs != w.ms || got[i].Text != w.text {
+					t.Errorf("línea %d = %v %q, se esperaba %d %q", i, got[i].TimeMs, got[i].Text, w.ms, w.text)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLyricsPlain(t *testing.T) {
+	got := ParseLyrics("Uno\n  Dos  ", LyricsPlain)
+	if len(got) != 2 || got[0].Text != "Uno" || got[1].Text != "Dos" {
+		t.Fatalf("ParseLyrics = %+v", got)
+	}
+	for _, l := range got {
+		if l.TimeMs != nil {
+			t.Errorf("la línea %q no debería tener tiempo", l.Text)
+		}
+	}
+}
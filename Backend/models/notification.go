@@ -0,0 +1,30 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase Notification, avisos guardados para un
+usuario (por ejemplo cambios de estado de sus reportes)
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	NotificationReportStatus = "report.status" // Cambió el estado de un reporte del usuario
+	NotificationSubscription = "subscription"  // Renovación, cancelación o fin del plan pago
+)
+
+// Notification es un aviso para un usuario; ReadAt es nil mientras no lo lea
+type Notification struct {
+	ID        int             `json:"id"`
+	UserID    int             `json:"user_id"`
+	Type      string          `json:"type"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ReadAt    *time.Time      `json:"read_at,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,116 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase PaymentProvider, el proveedor de pagos
+de las suscripciones, y una implementacion falsa para pruebas locales
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PaymentProvider cobra las suscripciones y avisa de renovaciones y
+// cancelaciones mediante webhooks firmados
+type PaymentProvider interface {
+	Name() string
+	// CreateSubscription cobra el primer período y devuelve la referencia
+	// del proveedor y el fin del período pagado
+	CreateSubscription(userID int, plan Plan) (ref string, periodEnd time.Time, err error)
+	// CancelSubscription desactiva la renovación automática
+	CancelSubscription(ref string) error
+	// ParseWebhook valida la firma del webhook y devuelve el evento
+	ParseWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
+
+// FakePaymentProvider simula un proveedor de pagos: cobra siempre y permite
+// generar eventos firmados para probar renovaciones y cancelaciones
+type FakePaymentProvider struct {
+	secret string
+	mu     sync.Mutex
+	nextID int
+	subs   map[string]time.Time // Referencia -> fin del período
+}
+
+// NewFakePaymentProvider crea el proveedor falso con la clave de firma de webhooks
+func NewFakePaymentProvider(secret string) *FakePaymentProvider {
+	return &FakePaymentProvider{secret: secret, subs: make(map[string]time.Time)}
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakePaymentProvider) CreateSubscription(userID int, plan Plan) (string, time.Time, error) {
+	if plan.PriceCents <= 0 {
+		return "", time.Time{}, fmt.Errorf("el plan %s no se puede contratar", plan.Name)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nextID++
+	ref := fmt.Sprintf("fake_sub_%d_%d_%d", userID, time.Now().UnixNano(), p.nextID)
+	end := time.Now().Add(SubscriptionPeriod)
+	p.subs[ref] = end
+	return ref, end, nil
+}
+
+func (p *FakePaymentProvider) CancelSubscription(ref string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.subs, ref)
+	return nil
+}
+
+func (p *FakePaymentProvider) ParseWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	if err := VerifyWebhookSignature(p.secret, payload, signature, time.Now()); err != nil {
+		return nil, err
+	}
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, errors.New("evento de webhook inválido")
+	}
+	if event.ID == "" || event.Type == "" || event.SubscriptionRef == "" {
+		return nil, errors.New("evento de webhook incompleto")
+	}
+	return &event, nil
+}
+
+// SimulateEvent arma un evento firmado como los que enviaría el proveedor.
+// Una renovación extiende el período a partir de periodEnd.
+func (p *FakePaymentProvider) SimulateEvent(eventType, ref string, periodEnd time.Time) (payload []byte, signature string, err error) {
+	switch eventType {
+	case WebhookRenewed, WebhookCanceled, WebhookPaymentFailed, WebhookExpired:
+	default:
+		return nil, "", fmt.Errorf("tipo de evento inválido: %s", eventType)
+	}
+
+	now := time.Now()
+	p.mu.Lock()
+	p.nextID++
+	event := WebhookEvent{
+		ID:              fmt.Sprintf("fake_evt_%d_%d", now.UnixNano(), p.nextID),
+		Type:            eventType,
+		SubscriptionRef: ref,
+		CreatedAt:       now,
+	}
+	if eventType == WebhookRenewed {
+		if periodEnd.Before(now) {
+			periodEnd = now
+		}
+		event.PeriodEnd = periodEnd.Add(SubscriptionPeriod)
+		p.subs[ref] = event.PeriodEnd
+	}
+	p.mu.Unlock()
+
+	if payload, err = json.Marshal(event); err != nil {
+		return nil, "", err
+	}
+	return payload, SignWebhook(p.secret, payload, now), nil
+}
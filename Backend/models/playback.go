@@ -32,6 +32,19 @@ type Playback struct {
 	PausedAt  time.Time      `json:"paused_at"`
 }
 
+// PlaybackPosition es la última posición reportada por un cliente para una canción
+type PlaybackPosition struct {
+	UserID     int       `json:"user_id"`
+	SongID     int       `json:"song_id"`
+	PositionMs int       `json:"position_ms"`
+	DurationMs int       `json:"duration_ms"`
+	DeviceID   string    `json:"device_id"`
+	ReportedAt time.Time `json:"reported_at"`
+}
+
+// Umbral a partir del cual una canción se considera terminada al reanudar
+const resumeEndThresholdMs = 5000
+
 // NewPlayback crea una nueva instancia de reproducción
 func NewPlayback(userID, songID int) *Playback {
 	return &Playback{
@@ -117,3 +130,26 @@ func (p *Playback) GetFormattedDuration() string {
 	seconds := int(duration.Seconds()) % 60
 	return fmt.Sprintf("%d:%02d", minutes, seconds)
 }
+
+// Validate verifica que la posición reportada sea coherente
+func (pp *PlaybackPosition) Validate() error {
+	if pp.SongID <= 0 {
+		return errors.New("ID de canción inválido")
+	}
+	if pp.PositionMs < 0 || pp.DurationMs < 0 {
+		return errors.New("la posición y la duración no pueden ser negativas")
+	}
+	if pp.DurationMs > 0 && pp.PositionMs > pp.DurationMs {
+		return errors.New("la posición excede la duración de la canción")
+	}
+	return nil
+}
+
+// ResumeAt devuelve la posición desde la que conviene continuar; si la canción
+// estaba prácticamente terminada se empieza de nuevo
+func (pp *PlaybackPosition) ResumeAt() int {
+	if pp.DurationMs > 0 && pp.DurationMs-pp.PositionMs <= resumeEndThresholdMs {
+		return 0
+	}
+	return pp.PositionMs
+}
@@ -0,0 +1,119 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase playlist, con sus respectivas
+funciones para el manejo de datos
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	MaxPlaylistNameLength = 100     // Longitud máxima del nombre de una playlist
+	PlaylistPositionGap   = 1 << 16 // Separación entre posiciones consecutivas
+)
+
+// PlaylistEntry es una canción dentro de una playlist. La posición es un
+// número con huecos entre entradas, de modo que insertar o mover una canción
+// solo modifica su propia fila y no las de las demás.
+type PlaylistEntry struct {
+	ID       int       `json:"id"`
+	SongID   int       `json:"song_id"`
+	Position int64     `json:"position"`
+	AddedBy  int       `json:"added_by"`
+	AddedAt  time.Time `json:"added_at"`
+	Title    string    `json:"title"`
+	Artist   string    `json:"artist"`
+	Genre    string    `json:"genre"`
+	FilePath string    `json:"file_path"`
+}
+
+// Playlist representa una lista de reproducción creada por un usuario
+type Playlist struct {
+	ID            int             `json:"id"`
+	OwnerID       int             `json:"owner_id"`
+	Name          string          `json:"name"`
+	IsPublic      bool            `json:"is_public"`
+	Collaborative bool            `json:"collaborative"`
+	ShareToken    string          `json:"share_token,omitempty"`
+	Collaborators []int           `json:"collaborators"`
+	Entries       []PlaylistEntry `json:"entries,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// ValidatePlaylistName limpia y valida el nombre de una playlist
+func ValidatePlaylistName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("el nombre de la playlist es requerido")
+	}
+	if len([]rune(name)) > MaxPlaylistNameLength {
+		return "", errors.New("el nombre de la playlist es demasiado largo")
+	}
+	return name, nil
+}
+
+// IsCollaborator indica si el usuario fue invitado como editor
+func (p *Playlist) IsCollaborator(userID int) bool {
+	for _, id := range p.Collaborators {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanView indica si el usuario puede ver la playlist
+func (p *Playlist) CanView(userID int) bool {
+	return p.IsPublic || p.OwnerID == userID || p.IsCollaborator(userID)
+}
+
+// CanEdit indica si el usuario puede modificar las canciones de la playlist
+func (p *Playlist) CanEdit(userID int) bool {
+	return p.OwnerID == userID || (p.Collaborative && p.IsCollaborator(userID))
+}
+
+// PositionBetween calcula una posición entre dos entradas vecinas.
+// prev o next pueden ser nil cuando se inserta al inicio o al final.
+// Devuelve false si no queda espacio y es necesario renumerar la playlist.
+func PositionBetween(prev, next *int64) (int64, bool) {
+	switch {
+	case prev == nil && next == nil:
+		return PlaylistPositionGap, true
+	case prev == nil:
+		if *next <= 1 {
+			return 0, false
+		}
+		return *next / 2, true
+	case next == nil:
+		return *prev + PlaylistPositionGap, true
+	}
+	if *next-*prev < 2 {
+		return 0, false
+	}
+	return *prev + (*next-*prev)/2, true
+}
+
+// RebalancePositions reparte de nuevo las posiciones dejando la separación
+// estándar entre entradas, respetando el orden actual
+func RebalancePositions(entries []PlaylistEntry) {
+	for i := range entries {
+		entries[i].Position = int64(i+1) * PlaylistPositionGap
+	}
+}
+
+// NewShareToken genera un identificador aleatorio para compartir la playlist por enlace
+func NewShareToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
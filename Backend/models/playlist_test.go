@@ -0,0 +1,43 @@
+package models
+
+import "testing"
+
+func TestPositionBetween(t *testing.T) {
+	pos := func(v int64) *int64 { return &v }
+	tests := []struct {
+		name   string
+		prev   *int64
+		next   *int64
+		want   int64
+		wantOK bool
+	}{
+		{"playlist vacía", nil, nil, PlaylistPositionGap, true},
+		{"al final", pos(3 * PlaylistPositionGap), nil, 4 * PlaylistPositionGap, true},
+		{"al inicio", nil, pos(PlaylistPositionGap), PlaylistPositionGap / 2, true},
+		{"al inicio sin espacio", nil, pos(1), 0, false},
+		{"en el medio", pos(PlaylistPositionGap), pos(2 * PlaylistPositionGap), PlaylistPositionGap + PlaylistPositionGap/2, true},
+		{"vecinas separadas por 2", pos(10), pos(12), 11, true},
+		{"vecinas consecutivas", pos(10), pos(11), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := PositionBetween(tt.prev, tt.next)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("PositionBetween() = %d, %v; se esperaba %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if ok && tt.prev != nil && tt.next != nil && (got <= *tt.prev || got >= *tt.next) {
+				t.Errorf("la posición %d no queda entre %d y %d", got, *tt.prev, *tt.next)
+			}
+		})
+	}
+}
+
+func TestRebalancePositions(t *testing.T) {
+	entries := []PlaylistEntry{{Position: 5}, {Position: 6}, {Position: 7}}
+	RebalancePositions(entries)
+	for i, e := range entries {
+		if want := int64(i+1) * PlaylistPositionGap; e.Position != want {
+			t.Errorf("entrada %d: posición %d, se esperaba %d", i, e.Position, want)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase queue (cola de reproduccion), con sus
+respectivas funciones para el manejo de datos
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+type RepeatMode string
+
+const (
+	RepeatOff RepeatMode = "off"
+	RepeatOne RepeatMode = "one"
+	RepeatAll RepeatMode = "all"
+)
+
+const MaxQueueItems = 500 // Límite de canciones en la cola de un usuario
+
+// QueueItem es una canción dentro de la cola. Cada entrada tiene su propio
+// ID para poder encolar la misma canción más de una vez.
+type QueueItem struct {
+	ID     int `json:"id"`
+	SongID int `json:"song_id"`
+}
+
+// PlayQueue es la cola de reproducción de un usuario. Items guarda el orden
+// en que se agregaron las canciones; cuando el modo aleatorio está activo,
+// ShuffleOrder guarda el orden de reproducción (IDs de entrada) generado con
+// Fisher–Yates a partir de ShuffleSeed, de modo que es estable en la sesión.
+type PlayQueue struct {
+	UserID        int         `json:"user_id"`
+	Items         []QueueItem `json:"items"`
+	ShuffleOrder  []int       `json:"shuffle_order,omitempty"`
+	CurrentItemID int         `json:"current_item_id"` // 0 si no hay canción actual
+	Shuffle       bool        `json:"shuffle"`
+	ShuffleSeed   int64       `json:"shuffle_seed,omitempty"`
+	Repeat        RepeatMode  `json:"repeat"`
+	NextItemID    int         `json:"next_item_id"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// NewPlayQueue crea una cola vacía para el usuario
+func NewPlayQueue(userID int) *PlayQueue {
+	return &PlayQueue{
+		UserID:     userID,
+		Items:      make([]QueueItem, 0),
+		Repeat:     RepeatOff,
+		NextItemID: 1,
+		UpdatedAt:  time.Now(),
+	}
+}
+
+// ParseRepeatMode valida el modo de repetición recibido
+func ParseRepeatMode(mode string) (RepeatMode, error) {
+	switch RepeatMode(mode) {
+	case RepeatOff, RepeatOne, RepeatAll:
+		return RepeatMode(mode), nil
+	}
+	return "", fmt.Errorf("modo de repetición inválido: %s", mode)
+}
+
+// PlayOrder devuelve las entradas en el orden en que se reproducirán
+func (q *PlayQueue) PlayOrder() []QueueItem {
+	if !q.Shuffle {
+		return q.Items
+	}
+	byID := make(map[int]QueueItem, len(q.Items))
+	for _, item := range q.Items {
+		byID[item.ID] = item
+	}
+	order := make([]QueueItem, 0, len(q.ShuffleOrder))
+	for _, id := range q.ShuffleOrder {
+		if item, ok := byID[id]; ok {
+			order = append(order, item)
+		}
+	}
+	return order
+}
+
+// CurrentIndex devuelve la posición de la canción actual en el orden de reproducción
+func (q *PlayQueue) CurrentIndex() int {
+	for i, item := range q.PlayOrder() {
+		if item.ID == q.CurrentItemID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Current devuelve la entrada que se está reproduciendo
+func (q *PlayQueue) Current() (*QueueItem, bool) {
+	order := q.PlayOrder()
+	if i := q.CurrentIndex(); i >= 0 {
+		return &order[i], true
+	}
+	return nil, false
+}
+
+// Enqueue agrega canciones al final de la cola
+func (q *PlayQueue) Enqueue(songIDs ...int) error {
+	if len(q.Items)+len(songIDs) > MaxQueueItems {
+		return fmt.Errorf("la cola no puede tener más de %d canciones", MaxQueueItems)
+	}
+	for _, songID := range songIDs {
+		item := q.newItem(songID)
+		q.Items = append(q.Items, item)
+		if q.Shuffle {
+			q.ShuffleOrder = append(q.ShuffleOrder, item.ID)
+		}
+	}
+	if q.CurrentItemID == 0 && len(q.Items) > 0 {
+		q.CurrentItemID = q.PlayOrder()[0].ID
+	}
+	q.touch()
+	return nil
+}
+
+// PlayNextSong inserta una canción justo después de la actual
+func (q *PlayQueue) PlayNextSong(songID int) error {
+	if len(q.Items) >= MaxQueueItems {
+		return fmt.Errorf("la cola no puede tener más de %d canciones", MaxQueueItems)
+	}
+	item := q.newItem(songID)
+
+	q.Items = insertItemAfter(q.Items, q.CurrentItemID, item)
+	if q.Shuffle {
+		q.ShuffleOrder = insertIDAfter(q.ShuffleOrder, q.CurrentItemID, item.ID)
+	}
+	if q.CurrentItemID == 0 {
+		q.CurrentItemID = item.ID
+	}
+	q.touch()
+	return nil
+}
+
+// Remove quita una entrada de la cola. Si era la actual, pasa a la siguiente.
+func (q *PlayQueue) Remove(itemID int) error {
+	index := q.itemIndex(itemID)
+	if index < 0 {
+		return errors.New("la canción no está en la cola")
+	}
+
+	if q.CurrentItemID == itemID {
+		order := q.PlayOrder()
+		pos := q.CurrentIndex()
+		q.CurrentItemID = 0
+		if pos+1 < len(order) {
+			q.CurrentItemID = order[pos+1].ID
+		} else if q.Repeat == RepeatAll && len(order) > 1 {
+			q.CurrentItemID = order[0].ID
+		}
+	}
+
+	q.Items = append(q.Items[:index], q.Items[index+1:]...)
+	for i, id := range q.ShuffleOrder {
+		if id == itemID {
+			q.ShuffleOrder = append(q.ShuffleOrder[:i], q.ShuffleOrder[i+1:]...)
+			break
+		}
+	}
+	q.touch()
+	return nil
+}
+
+// Move cambia la posición de una entrada dentro del orden de reproducción actual
+func (q *PlayQueue) Move(itemID, toIndex int) error {
+	if q.itemIndex(itemID) < 0 {
+		return errors.New("la canción no está en la cola")
+	}
+	if toIndex < 0 || toIndex >= len(q.Items) {
+		return errors.New("posición fuera de rango")
+	}
+
+	if q.Shuffle {
+		q.ShuffleOrder = moveID(q.ShuffleOrder, itemID, toIndex)
+	} else {
+		ids := make([]int, len(q.Items))
+		byID := make(map[int]QueueItem, len(q.Items))
+		for i, item := range q.Items {
+			ids[i] = item.ID
+			byID[item.ID] = item
+		}
+		ids = moveID(ids, itemID, toIndex)
+		for i, id := range ids {
+			q.Items[i] = byID[id]
+		}
+	}
+	q.touch()
+	return nil
+}
+
+// Clear vacía la cola conservando los modos de reproducción
+func (q *PlayQueue) Clear() {
+	q.Items = make([]QueueItem, 0)
+	q.ShuffleOrder = nil
+	q.CurrentItemID = 0
+	q.touch()
+}
+
+// SetShuffle activa o desactiva el modo aleatorio. Al activarlo se genera un
+// orden Fisher–Yates con la semilla indicada y la canción actual pasa a ser la
+// primera, para que la reproducción no se interrumpa.
+func (q *PlayQueue) SetShuffle(enabled bool, seed int64) {
+	q.Shuffle = enabled
+	if !enabled {
+		q.ShuffleOrder = nil
+		q.ShuffleSeed = 0
+		q.touch()
+		return
+	}
+
+	q.ShuffleSeed = seed
+	ids := make([]int, 0, len(q.Items))
+	for _, item := range q.Items {
+		if item.ID != q.CurrentItemID {
+			ids = append(ids, item.ID)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	for i := len(ids) - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
+		ids[i], ids[j] = ids[j], ids[i]
+	}
+
+	if q.CurrentItemID != 0 {
+		ids = append([]int{q.CurrentItemID}, ids...)
+	}
+	q.ShuffleOrder = ids
+	q.touch()
+}
+
+// SetRepeat cambia el modo de repetición
+func (q *PlayQueue) SetRepeat(mode RepeatMode) {
+	q.Repeat = mode
+	q.touch()
+}
+
+// JumpTo convierte la entrada indicada en la canción actual
+func (q *PlayQueue) JumpTo(itemID int) error {
+	if q.itemIndex(itemID) < 0 {
+		return errors.New("la canción no está en la cola")
+	}
+	q.CurrentItemID = itemID
+	q.touch()
+	return nil
+}
+
+// Next avanza a la siguiente canción según el modo de repetición.
+// Con RepeatOne la canción solo se repite al terminar sola; si el usuario
+// pulsa "siguiente" (skipped) se avanza normalmente. Devuelve false cuando la
+// cola terminó.
+func (q *PlayQueue) Next(skipped bool) (*QueueItem, bool) {
+	order := q.PlayOrder()
+	if len(order) == 0 {
+		return nil, false
+	}
+
+	pos := q.CurrentIndex()
+	if pos >= 0 && q.Repeat == RepeatOne && !skipped {
+		return &order[pos], true
+	}
+
+	next := pos + 1
+	if next >= len(order) {
+		if q.Repeat == RepeatOff {
+			q.CurrentItemID = 0
+			q.touch()
+			return nil, false
+		}
+		next = 0
+	}
+
+	q.CurrentItemID = order[next].ID
+	q.touch()
+	return &order[next], true
+}
+
+// Previous retrocede a la canción anterior
+func (q *PlayQueue) Previous() (*QueueItem, bool) {
+	order := q.PlayOrder()
+	if len(order) == 0 {
+		return nil, false
+	}
+
+	prev := q.CurrentIndex() - 1
+	if prev < 0 {
+		if q.Repeat == RepeatAll {
+			prev = len(order) - 1
+		} else {
+			prev = 0
+		}
+	}
+
+	q.CurrentItemID = order[prev].ID
+	q.touch()
+	return &order[prev], true
+}
+
+func (q *PlayQueue) newItem(songID int) QueueItem {
+	if q.NextItemID == 0 {
+		q.NextItemID = 1
+	}
+	item := QueueItem{ID: q.NextItemID, SongID: songID}
+	q.NextItemID++
+	return item
+}
+
+func (q *PlayQueue) itemIndex(itemID int) int {
+	for i, item := range q.Items {
+		if item.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (q *PlayQueue) touch() {
+	q.UpdatedAt = time.Now()
+}
+
+// insertItemAfter inserta la entrada detrás de afterID, o al final si no existe
+func insertItemAfter(items []QueueItem, afterID int, item QueueItem) []QueueItem {
+	for i, it := range items {
+		if it.ID == afterID {
+			items = append(items, QueueItem{})
+			copy(items[i+2:], items[i+1:])
+			items[i+1] = item
+			return items
+		}
+	}
+	return append(items, item)
+}
+
+// insertIDAfter inserta id detrás de afterID, o al final si no existe
+func insertIDAfter(ids []int, afterID, id int) []int {
+	for i, v := range ids {
+		if v == afterID {
+			ids = append(ids, 0)
+			copy(ids[i+2:], ids[i+1:])
+			ids[i+1] = id
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+// moveID mueve id a la posición toIndex conservando el orden relativo del resto
+func moveID(ids []int, id, toIndex int) []int {
+	rest := make([]int, 0, len(ids))
+	for _, v := range ids {
+		if v != id {
+			rest = append(rest, v)
+		}
+	}
+	if toIndex > len(rest) {
+		toIndex = len(rest)
+	}
+	result := make([]int, 0, len(ids))
+	result = append(result, rest[:toIndex]...)
+	result = append(result, id)
+	return append(result, rest[toIndex:]...)
+}
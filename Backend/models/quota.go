@@ -0,0 +1,237 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase Plan, límites de canciones, espacio,
+playlists y subidas diarias de cada usuario según su plan
+(para la estructura de datos)
+*/
+package models
+
+import "fmt"
+
+const (
+	PlanFree    = "free"
+	PlanPremium = "premium"
+	PlanAdmin   = "admin"
+)
+
+// Unlimited indica que un límite no aplica
+const Unlimited = -1
+
+const (
+	megabyte = 1024 * 1024
+	gigabyte = 1024 * megabyte
+)
+
+// Plan define los límites y las funciones disponibles para un usuario
+type Plan struct {
+	Name            string       `json:"name"`
+	PriceCents      int          `json:"price_cents"` // Precio mensual; 0 = no se puede contratar
+	Entitlements    Entitlements `json:"entitlements"`
+	MaxSongs        int          `json:"max_songs"`
+	MaxStorage      int64        `json:"max_storage_bytes"`
+	MaxSongSize     int64        `json:"max_song_size_bytes"`
+	MaxPlaylists    int          `json:"max_playlists"`
+	MaxDailyUploads int          `json:"max_daily_uploads"`
+}
+
+// Plans son los planes disponibles. El plan gratuito conserva los límites
+// que tenía la biblioteca: 60 canciones de hasta 10 MB.
+var Plans = map[string]Plan{
+	PlanFree: {
+		Name: PlanFree,
+		Entitlements: Entitlements{
+			MaxAudioQuality:  QualityNormal,
+			OfflineDownloads: false,
+			SkipsPerHour:     6,
+			AdFree:           false,
+			RadioSeek:        false,
+			MaxStreams:       1,
+		},
+		MaxSongs:        60,
+		MaxStorage:      60 * 10 * megabyte,
+		MaxSongSize:     10 * megabyte,
+		MaxPlaylists:    10,
+		MaxDailyUploads: 10,
+	},
+	PlanPremium: {
+		Name:       PlanPremium,
+		PriceCents: 499,
+		Entitlements: Entitlements{
+			MaxAudioQuality:  QualityHigh,
+			OfflineDownloads: true,
+			SkipsPerHour:     Unlimited,
+			AdFree:           true,
+			RadioSeek:        true,
+			MaxStreams:       4,
+		},
+		MaxSongs:        2000,
+		MaxStorage:      20 * gigabyte,
+		MaxSongSize:     50 * megabyte,
+		MaxPlaylists:    500,
+		MaxDailyUploads: 200,
+	},
+	PlanAdmin: {
+		Name: PlanAdmin,
+		Entitlements: Entitlements{
+			MaxAudioQuality:  QualityHigh,
+			OfflineDownloads: true,
+			SkipsPerHour:     Unlimited,
+			AdFree:           true,
+			RadioSeek:        true,
+			MaxStreams:       Unlimited,
+		},
+		MaxSongs:        Unlimited,
+		MaxStorage:      Unlimited,
+		MaxSongSize:     100 * megabyte,
+		MaxPlaylists:    Unlimited,
+		MaxDailyUploads: Unlimited,
+	},
+}
+
+// DefaultPlan es el plan de los usuarios nuevos
+var DefaultPlan = Plans[PlanFree]
+
+// PlanFor devuelve el plan de un usuario: los administradores siempre
+// tienen el plan admin y un plan desconocido se trata como gratuito.
+// plan es el de su suscripción vigente o, si no tiene, el asignado.
+func PlanFor(role, plan string) Plan {
+	if role == "admin" {
+		return Plans[PlanAdmin]
+	}
+	if p, ok := Plans[plan]; ok {
+		return p
+	}
+	return DefaultPlan
+}
+
+// QuotaUsage es lo que el usuario ya tiene ocupado
+type QuotaUsage struct {
+	Songs        int   `json:"songs"`
+	StorageBytes int64 `json:"storage_bytes"`
+	Playlists    int   `json:"playlists"`
+	UploadsToday int   `json:"uploads_today"`
+}
+
+// QuotaRemaining es lo que le queda al usuario; Unlimited si no tiene límite
+type QuotaRemaining struct {
+	Songs        int   `json:"songs"`
+	StorageBytes int64 `json:"storage_bytes"`
+	Playlists    int   `json:"playlists"`
+	UploadsToday int   `json:"uploads_today"`
+}
+
+// Quota junta el plan, el uso y lo que queda
+type Quota struct {
+	Plan      Plan           `json:"plan"`
+	Usage     QuotaUsage     `json:"usage"`
+	Remaining QuotaRemaining `json:"remaining"`
+}
+
+// NewQuota calcula lo que queda de cada límite
+func NewQuota(plan Plan, usage QuotaUsage) Quota {
+	return Quota{
+		Plan:  plan,
+		Usage: usage,
+		Remaining: QuotaRemaining{
+			Songs:        int(remaining(int64(plan.MaxSongs), int64(usage.Songs))),
+			StorageBytes: remaining(plan.MaxStorage, usage.StorageBytes),
+			Playlists:    int(remaining(int64(plan.MaxPlaylists), int64(usage.Playlists))),
+			UploadsToday: int(remaining(int64(plan.MaxDailyUploads), int64(usage.UploadsToday))),
+		},
+	}
+}
+
+func remaining(limit, used int64) int64 {
+	if limit == Unlimited {
+		return Unlimited
+	}
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
+
+// QuotaError indica qué límite se superó y cuánto queda
+type QuotaError struct {
+	Resource  string `json:"resource"` // songs, storage, song_size, playlists, daily_uploads
+	Message   string `json:"error"`
+	Limit     int64  `json:"limit"`
+	Used      int64  `json:"used"`
+	Requested int64  `json:"requested"`
+	Remaining int64  `json:"remaining"`
+}
+
+func (e *QuotaError) Error() string {
+	return e.Message
+}
+
+func quotaError(resource, message string, limit, used, requested int64) *QuotaError {
+	return &QuotaError{
+		Resource:  resource,
+		Message:   message,
+		Limit:     limit,
+		Used:      used,
+		Requested: requested,
+		Remaining: remaining(limit, used),
+	}
+}
+
+// CheckSongSize valida el tamaño de un archivo contra el plan
+func (q Quota) CheckSongSize(size int64) error {
+	if q.Plan.MaxSongSize != Unlimited && size > q.Plan.MaxSongSize {
+		return quotaError("song_size",
+			fmt.Sprintf("el archivo excede el límite de %s por canción", FormatBytes(q.Plan.MaxSongSize)),
+			q.Plan.MaxSongSize, 0, size)
+	}
+	return nil
+}
+
+// CheckUpload valida que el usuario pueda subir una canción del tamaño indicado
+func (q Quota) CheckUpload(size int64) error {
+	if err := q.CheckSongSize(size); err != nil {
+		return err
+	}
+	if q.Remaining.UploadsToday == 0 {
+		return quotaError("daily_uploads",
+			fmt.Sprintf("alcanzaste el límite de %d subidas por día", q.Plan.MaxDailyUploads),
+			int64(q.Plan.MaxDailyUploads), int64(q.Usage.UploadsToday), 1)
+	}
+	if q.Remaining.Songs == 0 {
+		return quotaError("songs",
+			fmt.Sprintf("límite de canciones alcanzado (máximo %d)", q.Plan.MaxSongs),
+			int64(q.Plan.MaxSongs), int64(q.Usage.Songs), 1)
+	}
+	if q.Remaining.StorageBytes != Unlimited && size > q.Remaining.StorageBytes {
+		return quotaError("storage",
+			fmt.Sprintf("no hay espacio suficiente: quedan %s de %s",
+				FormatBytes(q.Remaining.StorageBytes), FormatBytes(q.Plan.MaxStorage)),
+			q.Plan.MaxStorage, q.Usage.StorageBytes, size)
+	}
+	return nil
+}
+
+// CheckPlaylist valida que el usuario pueda crear otra playlist
+func (q Quota) CheckPlaylist() error {
+	if q.Remaining.Playlists == 0 {
+		return quotaError("playlists",
+			fmt.Sprintf("límite de playlists alcanzado (máximo %d)", q.Plan.MaxPlaylists),
+			int64(q.Plan.MaxPlaylists), int64(q.Usage.Playlists), 1)
+	}
+	return nil
+}
+
+// FormatBytes muestra un tamaño en B, KB, MB, ...
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
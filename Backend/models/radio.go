@@ -0,0 +1,316 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase RadioStation, radio automatica que
+genera una secuencia infinita de canciones a partir de una semilla
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+type RadioSeedType string
+
+const (
+	RadioSeedSong     RadioSeedType = "song"
+	RadioSeedArtist   RadioSeedType = "artist"
+	RadioSeedGenre    RadioSeedType = "genre"
+	RadioSeedPlaylist RadioSeedType = "playlist"
+)
+
+type RadioFeedback string
+
+const (
+	RadioFeedbackSkip    RadioFeedback = "skip"
+	RadioFeedbackDislike RadioFeedback = "dislike"
+)
+
+const (
+	RadioRepeatWindow  = 30  // Canciones recientes que no se vuelven a sonar
+	RadioArtistSpacing = 3   // Canciones mínimas entre dos del mismo artista
+	DefaultRadioPage   = 10  // Canciones por página si no se indica
+	MaxRadioPage       = 50  // Máximo de canciones por página
+	maxRadioHistory    = 200 // Canciones generadas que se conservan
+)
+
+// Afinidad de una canción con la semilla según lo que comparte con ella
+const (
+	radioAffinitySeed     = 1.0
+	radioAffinityArtist   = 0.8
+	radioAffinityGenre    = 0.5
+	radioAffinityBaseline = 0.05 // Cualquier canción puede sonar, con poca probabilidad
+)
+
+// RadioSeed describe lo que debe parecerse a la semilla de la radio
+type RadioSeed struct {
+	Songs   map[int]bool
+	Artists map[string]bool
+	Genres  map[string]bool
+}
+
+// NewRadioSeed arma una semilla a partir de canciones, tomando también sus
+// artistas y géneros
+func NewRadioSeed(songs []Song) RadioSeed {
+	seed := RadioSeed{
+		Songs:   make(map[int]bool),
+		Artists: make(map[string]bool),
+		Genres:  make(map[string]bool),
+	}
+	for _, s := range songs {
+		seed.Songs[s.ID] = true
+		seed.Artists[s.Artist] = true
+		seed.Genres[s.Genre] = true
+	}
+	return seed
+}
+
+// RadioCandidate es una canción que puede sonar en la radio
+type RadioCandidate struct {
+	SongID   int
+	Artist   string
+	Affinity float64
+}
+
+// RadioPool calcula la afinidad de cada canción del catálogo con la semilla
+func RadioPool(seed RadioSeed, catalog []Song) []RadioCandidate {
+	pool := make([]RadioCandidate, 0, len(catalog))
+	for _, s := range catalog {
+		affinity := radioAffinityBaseline
+		switch {
+		case seed.Songs[s.ID]:
+			affinity = radioAffinitySeed
+		case seed.Artists[s.Artist]:
+			affinity = radioAffinityArtist
+		case seed.Genres[s.Genre]:
+			affinity = radioAffinityGenre
+		}
+		pool = append(pool, RadioCandidate{SongID: s.ID, Artist: s.Artist, Affinity: affinity})
+	}
+	return pool
+}
+
+// RadioStation es una radio de un usuario. Tracks guarda las canciones ya
+// generadas; Offset es la posición absoluta de Tracks[0], ya que las más
+// antiguas se descartan. La generación es determinista a partir de
+// RandomSeed y la posición, de modo que las páginas se pueden repetir.
+type RadioStation struct {
+	ID              int            `json:"id"`
+	UserID          int            `json:"user_id"`
+	SeedType        RadioSeedType  `json:"seed_type"`
+	SeedValue       string         `json:"seed_value"`
+	RandomSeed      int64          `json:"random_seed"`
+	Tracks          []int          `json:"tracks"`
+	Offset          int            `json:"offset"`
+	Skips           map[int]int    `json:"skips,omitempty"`
+	Disliked        []int          `json:"disliked,omitempty"`
+	DislikedArtists map[string]int `json:"disliked_artists,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// NewRadioStation crea una radio sin canciones generadas
+func NewRadioStation(userID int, seedType RadioSeedType, seedValue string) *RadioStation {
+	now := time.Now()
+	return &RadioStation{
+		UserID:     userID,
+		SeedType:   seedType,
+		SeedValue:  seedValue,
+		RandomSeed: now.UnixNano(),
+		Tracks:     []int{},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// RadioError es un error por datos inválidos en la petición a la radio, a
+// diferencia de los errores al leer el catálogo
+type RadioError string
+
+func (e RadioError) Error() string {
+	return string(e)
+}
+
+// RadioSeedError indica que la semilla no tiene canciones disponibles: la
+// canción o la playlist se borró, o la playlist ya no es visible. Al crear
+// la radio es un dato inválido; en una radio existente ya no puede sonar.
+type RadioSeedError string
+
+func (e RadioSeedError) Error() string {
+	return string(e)
+}
+
+// ParseRadioSeedType valida el tipo de semilla recibido
+func ParseRadioSeedType(seedType string) (RadioSeedType, error) {
+	switch RadioSeedType(seedType) {
+	case RadioSeedSong, RadioSeedArtist, RadioSeedGenre, RadioSeedPlaylist:
+		return RadioSeedType(seedType), nil
+	}
+	return "", RadioError(fmt.Sprintf("tipo de semilla inválido: %s", seedType))
+}
+
+// End devuelve la posición siguiente a la última canción generada
+func (s *RadioStation) End() int {
+	return s.Offset + len(s.Tracks)
+}
+
+// Page devuelve las canciones desde la posición offset, generando las que
+// falten. Las posiciones ya descartadas del historial no se pueden pedir.
+func (s *RadioStation) Page(pool []RadioCandidate, offset, limit int) ([]int, error) {
+	if limit < 1 {
+		limit = DefaultRadioPage
+	}
+	if limit > MaxRadioPage {
+		limit = MaxRadioPage
+	}
+	if offset < s.Offset {
+		return nil, RadioError("esas canciones ya no están disponibles en la radio")
+	}
+	if offset > s.End() {
+		return nil, RadioError("posición fuera de rango")
+	}
+
+	if missing := offset + limit - s.End(); missing > 0 {
+		s.generate(pool, missing)
+	}
+
+	start := offset - s.Offset
+	end := start + limit
+	if end > len(s.Tracks) {
+		end = len(s.Tracks)
+	}
+	page := make([]int, end-start)
+	copy(page, s.Tracks[start:end])
+
+	// Descartar lo más antiguo; la página pedida siempre queda al final
+	if extra := len(s.Tracks) - maxRadioHistory; extra > 0 {
+		s.Tracks = append([]int(nil), s.Tracks[extra:]...)
+		s.Offset += extra
+	}
+	return page, nil
+}
+
+// Feedback registra que el usuario saltó o rechazó una canción. Un rechazo
+// descarta las canciones generadas después de position para que las
+// siguientes páginas ya lo tengan en cuenta; devuelve desde qué posición
+// cambian las canciones (End si no cambian).
+func (s *RadioStation) Feedback(songID int, artist string, kind RadioFeedback, position int) (int, error) {
+	switch kind {
+	case RadioFeedbackSkip:
+		if s.Skips == nil {
+			s.Skips = make(map[int]int)
+		}
+		s.Skips[songID]++
+		return s.End(), nil
+	case RadioFeedbackDislike:
+		if !s.isDisliked(songID) {
+			s.Disliked = append(s.Disliked, songID)
+			if s.DislikedArtists == nil {
+				s.DislikedArtists = make(map[string]int)
+			}
+			s.DislikedArtists[artist]++
+		}
+		if position >= s.Offset && position < s.End() {
+			s.Tracks = s.Tracks[:position-s.Offset+1]
+		}
+		return s.End(), nil
+	}
+	return 0, RadioError(fmt.Sprintf("tipo de opinión inválido: %s", kind))
+}
+
+func (s *RadioStation) isDisliked(songID int) bool {
+	for _, id := range s.Disliked {
+		if id == songID {
+			return true
+		}
+	}
+	return false
+}
+
+// weight calcula la probabilidad relativa de que suene un candidato. Cada
+// salto de la canción y cada rechazo de su artista la reducen a la mitad.
+func (s *RadioStation) weight(c RadioCandidate) float64 {
+	return c.Affinity * math.Pow(0.5, float64(s.Skips[c.SongID]+s.DislikedArtists[c.Artist]))
+}
+
+// generate agrega n canciones a la radio
+func (s *RadioStation) generate(pool []RadioCandidate, n int) {
+	eligible := make([]RadioCandidate, 0, len(pool))
+	artists := make(map[int]string, len(pool))
+	distinctArtists := make(map[string]bool)
+	for _, c := range pool {
+		artists[c.SongID] = c.Artist
+		if s.isDisliked(c.SongID) || s.weight(c) <= 0 {
+			continue
+		}
+		eligible = append(eligible, c)
+		distinctArtists[c.Artist] = true
+	}
+	if len(eligible) == 0 {
+		return
+	}
+
+	// Con catálogos chicos las reglas se acortan para que siempre haya opciones
+	window := minInt(RadioRepeatWindow, len(eligible)-1)
+	spacing := minInt(RadioArtistSpacing, len(distinctArtists)-1)
+
+	rng := rand.New(rand.NewSource(s.RandomSeed + int64(s.End())))
+	for i := 0; i < n; i++ {
+		s.Tracks = append(s.Tracks, s.pick(eligible, artists, window, spacing, rng))
+	}
+}
+
+// pick elige la siguiente canción al azar, ponderada por su peso, evitando
+// las repetidas en la ventana y los artistas recientes. Si ninguna cumple
+// las reglas se relaja primero la separación por artista y luego la ventana.
+func (s *RadioStation) pick(eligible []RadioCandidate, artists map[int]string, window, spacing int, rng *rand.Rand) int {
+	rules := [][2]int{{window, spacing}, {window, 0}, {0, 0}}
+	for _, rule := range rules {
+		recentSongs := make(map[int]bool)
+		recentArtists := make(map[string]bool)
+		for i := 1; i <= len(s.Tracks) && (i <= rule[0] || i <= rule[1]); i++ {
+			id := s.Tracks[len(s.Tracks)-i]
+			if i <= rule[0] {
+				recentSongs[id] = true
+			}
+			if i <= rule[1] {
+				recentArtists[artists[id]] = true
+			}
+		}
+
+		total := 0.0
+		choices := make([]RadioCandidate, 0, len(eligible))
+		for _, c := range eligible {
+			if recentSongs[c.SongID] || recentArtists[c.Artist] {
+				continue
+			}
+			choices = append(choices, c)
+			total += s.weight(c)
+		}
+		if len(choices) == 0 {
+			continue
+		}
+
+		target := rng.Float64() * total
+		for _, c := range choices {
+			target -= s.weight(c)
+			if target < 0 {
+				return c.SongID
+			}
+		}
+		return choices[len(choices)-1].SongID
+	}
+	return eligible[0].SongID
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,302 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase Recommender, motor de recomendaciones
+basado en historial de reproduccion, favoritos y popularidad
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRecommendations = 10
+	MaxRecommendations     = 50
+	maxCachedPerUser       = MaxRecommendations
+	favoriteSignalWeight   = 3 // Un favorito pesa como tres reproducciones
+)
+
+// Pesos de cada señal en el puntaje final
+const (
+	weightCooccurrence = 0.40
+	weightArtist       = 0.25
+	weightGenre        = 0.20
+	weightPopularity   = 0.15
+)
+
+// UserSongCount es la cantidad de veces que un usuario reprodujo una canción
+type UserSongCount struct {
+	UserID int
+	SongID int
+	Count  int
+}
+
+// UserSong relaciona un usuario con una canción (por ejemplo un favorito)
+type UserSong struct {
+	UserID int
+	SongID int
+}
+
+// RecommendationData reúne todo lo necesario para recalcular las recomendaciones
+type RecommendationData struct {
+	Songs     []Song
+	Plays     []UserSongCount
+	Favorites []UserSong
+}
+
+// Recommendation es una canción recomendada con la explicación de por qué
+type Recommendation struct {
+	SongID int     `json:"song_id"`
+	Title  string  `json:"title"`
+	Artist string  `json:"artist"`
+	Genre  string  `json:"genre"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// Recommender guarda las recomendaciones precalculadas de cada usuario.
+// Se recalcula periódicamente en segundo plano con Rebuild.
+type Recommender struct {
+	mu        sync.RWMutex
+	byUser    map[int][]Recommendation
+	popular   []Recommendation
+	updatedAt time.Time
+}
+
+// NewRecommender crea un motor sin datos; For devuelve vacío hasta el primer Rebuild
+func NewRecommender() *Recommender {
+	return &Recommender{byUser: make(map[int][]Recommendation)}
+}
+
+// For devuelve las recomendaciones del usuario. Los usuarios sin historial
+// (arranque en frío) reciben las canciones más populares.
+func (r *Recommender) For(userID, limit int) ([]Recommendation, time.Time) {
+	if limit < 1 {
+		limit = DefaultRecommendations
+	}
+	if limit > MaxRecommendations {
+		limit = MaxRecommendations
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	recs, ok := r.byUser[userID]
+	if !ok {
+		recs = r.popular
+	}
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+	result := make([]Recommendation, len(recs))
+	copy(result, recs)
+	return result, r.updatedAt
+}
+
+// Rebuild recalcula las recomendaciones de todos los usuarios
+func (r *Recommender) Rebuild(data RecommendationData) {
+	songs := make(map[int]Song, len(data.Songs))
+	for _, s := range data.Songs {
+		songs[s.ID] = s
+	}
+
+	// Señal por usuario y canción: reproducciones + favoritos
+	signals := make(map[int]map[int]int)
+	add := func(userID, songID, weight int) {
+		if _, ok := songs[songID]; !ok {
+			return
+		}
+		if signals[userID] == nil {
+			signals[userID] = make(map[int]int)
+		}
+		signals[userID][songID] += weight
+	}
+	for _, p := range data.Plays {
+		add(p.UserID, p.SongID, p.Count)
+	}
+	favorites := make(map[int]map[int]bool)
+	for _, f := range data.Favorites {
+		add(f.UserID, f.SongID, favoriteSignalWeight)
+		if favorites[f.UserID] == nil {
+			favorites[f.UserID] = make(map[int]bool)
+		}
+		favorites[f.UserID][f.SongID] = true
+	}
+
+	popularity, maxPopularity := popularityScores(signals)
+	cooccurrence := cooccurrenceCounts(signals)
+
+	popular := make([]Recommendation, 0, len(songs))
+	for id, s := range songs {
+		score := 0.0
+		if maxPopularity > 0 {
+			score = float64(popularity[id]) / float64(maxPopularity)
+		}
+		popular = append(popular, Recommendation{
+			SongID: id, Title: s.Title, Artist: s.Artist, Genre: s.Genre,
+			Score: score, Reason: "Popular entre los oyentes",
+		})
+	}
+	sortRecommendations(popular)
+	if len(popular) > maxCachedPerUser {
+		popular = popular[:maxCachedPerUser]
+	}
+
+	byUser := make(map[int][]Recommendation, len(signals))
+	for userID, history := range signals {
+		byUser[userID] = recommendFor(history, favorites[userID], songs, cooccurrence, popularity, maxPopularity)
+	}
+
+	r.mu.Lock()
+	r.byUser = byUser
+	r.popular = popular
+	r.updatedAt = time.Now()
+	r.mu.Unlock()
+}
+
+// recommendFor combina afinidad por género y artista, co-ocurrencia
+// ("quienes escucharon X también escucharon Y") y popularidad
+func recommendFor(history map[int]int, favorites map[int]bool, songs map[int]Song,
+	cooccurrence map[int]map[int]int, popularity map[int]int, maxPopularity int) []Recommendation {
+
+	genreAffinity := make(map[string]float64)
+	artistAffinity := make(map[string]float64)
+	total := 0.0
+	for songID, weight := range history {
+		s := songs[songID]
+		genreAffinity[s.Genre] += float64(weight)
+		artistAffinity[s.Artist] += float64(weight)
+		total += float64(weight)
+	}
+
+	// Co-ocurrencia acumulada de cada candidata con el historial del usuario,
+	// recordando qué canción del historial aportó más para explicarlo
+	coScore := make(map[int]float64)
+	coSource := make(map[int]int)
+	coBest := make(map[int]float64)
+	maxCo := 0.0
+	for songID, weight := range history {
+		for other, n := range cooccurrence[songID] {
+			if _, heard := history[other]; heard {
+				continue
+			}
+			contribution := float64(n * weight)
+			coScore[other] += contribution
+			if contribution > coBest[other] {
+				coBest[other] = contribution
+				coSource[other] = songID
+			}
+			if coScore[other] > maxCo {
+				maxCo = coScore[other]
+			}
+		}
+	}
+
+	var recs []Recommendation
+	for id, s := range songs {
+		if _, heard := history[id]; heard || favorites[id] {
+			continue
+		}
+
+		var coValue, popValue float64
+		if maxCo > 0 {
+			coValue = weightCooccurrence * coScore[id] / maxCo
+		}
+		if maxPopularity > 0 {
+			popValue = weightPopularity * float64(popularity[id]) / float64(maxPopularity)
+		}
+		parts := []struct {
+			name  string
+			value float64
+		}{
+			{"cooccurrence", coValue},
+			{"artist", weightArtist * artistAffinity[s.Artist] / total},
+			{"genre", weightGenre * genreAffinity[s.Genre] / total},
+			{"popularity", popValue},
+		}
+
+		// La explicación corresponde a la señal que más aportó
+		score, strongest, best := 0.0, "popularity", 0.0
+		for _, p := range parts {
+			score += p.value
+			if p.value > best {
+				strongest, best = p.name, p.value
+			}
+		}
+		if score <= 0 {
+			continue
+		}
+
+		var reason string
+		switch strongest {
+		case "cooccurrence":
+			reason = fmt.Sprintf("Porque escuchaste %s", songs[coSource[id]].Title)
+		case "artist":
+			reason = fmt.Sprintf("Porque te gusta %s", s.Artist)
+		case "genre":
+			reason = fmt.Sprintf("Porque escuchas %s", s.Genre)
+		default:
+			reason = "Popular entre los oyentes"
+		}
+
+		recs = append(recs, Recommendation{
+			SongID: id, Title: s.Title, Artist: s.Artist, Genre: s.Genre,
+			Score: score, Reason: reason,
+		})
+	}
+
+	sortRecommendations(recs)
+	if len(recs) > maxCachedPerUser {
+		recs = recs[:maxCachedPerUser]
+	}
+	return recs
+}
+
+// popularityScores suma la señal de todos los usuarios por canción
+func popularityScores(signals map[int]map[int]int) (map[int]int, int) {
+	popularity := make(map[int]int)
+	maxPopularity := 0
+	for _, history := range signals {
+		for songID, weight := range history {
+			popularity[songID] += weight
+			if popularity[songID] > maxPopularity {
+				maxPopularity = popularity[songID]
+			}
+		}
+	}
+	return popularity, maxPopularity
+}
+
+// cooccurrenceCounts cuenta, para cada par de canciones, cuántos usuarios escucharon ambas
+func cooccurrenceCounts(signals map[int]map[int]int) map[int]map[int]int {
+	counts := make(map[int]map[int]int)
+	for _, history := range signals {
+		for a := range history {
+			for b := range history {
+				if a == b {
+					continue
+				}
+				if counts[a] == nil {
+					counts[a] = make(map[int]int)
+				}
+				counts[a][b]++
+			}
+		}
+	}
+	return counts
+}
+
+func sortRecommendations(recs []Recommendation) {
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].SongID < recs[j].SongID
+	})
+}
@@ -0,0 +1,142 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase Report, reportes de problemas enviados
+por los usuarios y gestionados por los administradores
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type ReportStatus string
+
+const (
+	ReportOpen       ReportStatus = "open"
+	ReportInProgress ReportStatus = "in_progress"
+	ReportResolved   ReportStatus = "resolved"
+)
+
+const (
+	MaxReportTitle       = 150
+	MaxReportDescription = 5000
+	MaxReportComment     = 2000
+	MaxReportAttachments = 5               // Archivos adjuntos por reporte
+	MaxAttachmentSize    = 5 * 1024 * 1024 // 5 MB por archivo
+)
+
+// Tipos de archivo que se aceptan como adjunto, por extensión
+var attachmentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".txt":  "text/plain",
+	".log":  "text/plain",
+	".pdf":  "application/pdf",
+}
+
+// Report es un problema reportado por un usuario, opcionalmente sobre una canción
+type Report struct {
+	ID          int                `json:"id"`
+	UserID      int                `json:"user_id"`
+	UserName    string             `json:"user_name,omitempty"`
+	SongID      *int               `json:"song_id,omitempty"`
+	SongTitle   string             `json:"song_title,omitempty"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Status      ReportStatus       `json:"status"`
+	AssignedTo  *int               `json:"assigned_to,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Comments    []ReportComment    `json:"comments,omitempty"`
+	Attachments []ReportAttachment `json:"attachments,omitempty"`
+}
+
+// ReportComment es un comentario de un administrador o del autor del reporte
+type ReportComment struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReportAttachment es un archivo adjunto a un reporte
+type ReportAttachment struct {
+	ID          int       `json:"id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	FilePath    string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ParseReportStatus valida el estado recibido
+func ParseReportStatus(status string) (ReportStatus, error) {
+	switch ReportStatus(status) {
+	case ReportOpen, ReportInProgress, ReportResolved:
+		return ReportStatus(status), nil
+	}
+	return "", fmt.Errorf("estado inválido: %s", status)
+}
+
+// Label devuelve el nombre del estado para mostrar al usuario
+func (s ReportStatus) Label() string {
+	switch s {
+	case ReportOpen:
+		return "abierto"
+	case ReportInProgress:
+		return "en progreso"
+	case ReportResolved:
+		return "resuelto"
+	}
+	return string(s)
+}
+
+// ValidateReport limpia y valida el título y la descripción de un reporte
+func ValidateReport(title, description string) (string, string, error) {
+	title = strings.TrimSpace(title)
+	description = strings.TrimSpace(description)
+	if title == "" || description == "" {
+		return "", "", errors.New("el título y la descripción son obligatorios")
+	}
+	if len([]rune(title)) > MaxReportTitle {
+		return "", "", fmt.Errorf("el título no puede superar %d caracteres", MaxReportTitle)
+	}
+	if len([]rune(description)) > MaxReportDescription {
+		return "", "", fmt.Errorf("la descripción no puede superar %d caracteres", MaxReportDescription)
+	}
+	return title, description, nil
+}
+
+// ValidateComment limpia y valida el texto de un comentario
+func ValidateComment(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", errors.New("el comentario no puede estar vacío")
+	}
+	if len([]rune(body)) > MaxReportComment {
+		return "", fmt.Errorf("el comentario no puede superar %d caracteres", MaxReportComment)
+	}
+	return body, nil
+}
+
+// AttachmentContentType valida un adjunto y devuelve su tipo de contenido
+func AttachmentContentType(fileName string, size int64) (string, error) {
+	if size > MaxAttachmentSize {
+		return "", fmt.Errorf("el archivo %s supera el límite de %d MB", fileName, MaxAttachmentSize/(1024*1024))
+	}
+	contentType, ok := attachmentTypes[strings.ToLower(filepath.Ext(fileName))]
+	if !ok {
+		return "", fmt.Errorf("tipo de archivo no permitido: %s", fileName)
+	}
+	return contentType, nil
+}
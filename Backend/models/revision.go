@@ -0,0 +1,77 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase SongRevision, historial de cambios
+de los datos de una canción (título, artista y género)
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldChange es el valor anterior y el nuevo de un campo modificado
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SongRevision es una versión de los datos de una canción. Guarda los
+// valores completos de esa versión y qué cambió respecto a la anterior.
+// La versión 1 son los datos con los que se subió la canción.
+type SongRevision struct {
+	ID           int                    `json:"id"`
+	SongID       int                    `json:"song_id"`
+	Version      int                    `json:"version"`
+	Title        string                 `json:"title"`
+	Artist       string                 `json:"artist"`
+	Genre        string                 `json:"genre"`
+	Changes      map[string]FieldChange `json:"changes,omitempty"`
+	RevertedFrom *int                   `json:"reverted_from,omitempty"` // Versión restaurada
+	ChangedBy    *int                   `json:"changed_by,omitempty"`
+	ChangedName  string                 `json:"changed_by_name,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// MetadataChanges compara los datos de dos versiones de una canción y
+// devuelve los campos que cambiaron
+func MetadataChanges(before, after Song) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	if before.Title != after.Title {
+		changes["title"] = FieldChange{From: before.Title, To: after.Title}
+	}
+	if before.Artist != after.Artist {
+		changes["artist"] = FieldChange{From: before.Artist, To: after.Artist}
+	}
+	if before.Genre != after.Genre {
+		changes["genre"] = FieldChange{From: before.Genre, To: after.Genre}
+	}
+	return changes
+}
+
+// SongETag arma la etiqueta HTTP de una versión de la canción
+func SongETag(songID, version int) string {
+	return fmt.Sprintf("\"song-%d-v%d\"", songID, version)
+}
+
+// ParseSongETag obtiene la versión de una cabecera If-Match. Acepta la
+// etiqueta completa (también débil, W/"...") o solo el número de versión.
+func ParseSongETag(songID int, header string) (int, error) {
+	tag := strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	tag = strings.Trim(tag, "\"")
+
+	prefix := fmt.Sprintf("song-%d-v", songID)
+	if strings.HasPrefix(tag, prefix) {
+		tag = strings.TrimPrefix(tag, prefix)
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("If-Match inválido: %s", header)
+	}
+	return version, nil
+}
@@ -0,0 +1,299 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase RulesEngine, reglas de escucha que se
+evaluan al iniciar, saltar o adelantar una cancion (limite de saltos,
+adelantar en la radio y reproducciones simultaneas)
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Acciones del reproductor que pasan por las reglas
+const (
+	ActionStart = "start" // Empieza una canción
+	ActionSkip  = "skip"  // Empieza una canción saltando la anterior
+	ActionSeek  = "seek"  // Adelanta o retrocede dentro de la canción
+)
+
+// SourceRadio indica que la canción viene de una radio automática
+const SourceRadio = "radio"
+
+// SourceImport marca las reproducciones de un historial importado
+const SourceImport = "import"
+
+// SkipWindow es la ventana en la que se cuentan los saltos
+const SkipWindow = time.Hour
+
+const (
+	// SkipTolerance es el margen para no contar como salto el cambio a la
+	// siguiente canción justo cuando termina la anterior
+	SkipTolerance = 5 * time.Second
+	// UnknownTrackLength se usa cuando todavía no se conoce la duración
+	// de la canción anterior (no se procesó su audio)
+	UnknownTrackLength = 30 * time.Second
+	// SeekTolerance es el margen entre dos reportes de posición para el
+	// retraso de la red y el redondeo del reproductor
+	SeekTolerance = 5 * time.Second
+	// MaxSourceLength es el largo máximo del origen de una reproducción
+	MaxSourceLength = 16
+)
+
+// IsSkip indica si empezar una canción en now salta la anterior: la
+// anterior empezó en previousStart y no le alcanzó el tiempo para terminar.
+// Un previousStart en cero (no hay reproducción anterior) no es salto.
+func IsSkip(previousStart time.Time, trackLength time.Duration, now time.Time) bool {
+	if previousStart.IsZero() {
+		return false
+	}
+	if trackLength <= 0 {
+		trackLength = UnknownTrackLength
+	}
+	return now.Sub(previousStart) < trackLength-SkipTolerance
+}
+
+// IsSeek indica si la posición reportada en at no se explica por el tiempo
+// transcurrido desde el reporte anterior (prevMs en prevAt): avanzó más
+// que el reloj o retrocedió. Estar en pausa no avanza la posición.
+func IsSeek(prevMs int, prevAt time.Time, positionMs int, at time.Time) bool {
+	elapsed := max(0, at.Sub(prevAt))
+	tolerance := int(SeekTolerance.Milliseconds())
+	return positionMs > prevMs+int(elapsed.Milliseconds())+tolerance || positionMs < prevMs-tolerance
+}
+
+// PlaybackContext es lo que saben las reglas de la acción pedida
+type PlaybackContext struct {
+	UserID        int
+	Entitlements  Entitlements
+	Action        string
+	Source        string // radio, playlist, queue, ... (vacío si no se sabe)
+	DeviceID      string
+	ActiveStreams int // Otros dispositivos reproduciendo en este momento
+	Now           time.Time
+}
+
+// RuleViolation es el error que recibe el reproductor cuando una regla no
+// permite la acción; trae lo necesario para mostrar un mensaje al usuario
+type RuleViolation struct {
+	Rule         string     `json:"rule"`
+	Message      string     `json:"error"`
+	Limit        int        `json:"limit,omitempty"`
+	Used         int        `json:"used,omitempty"`
+	RetryAfter   int        `json:"retry_after_seconds,omitempty"`
+	RetryAt      *time.Time `json:"retry_at,omitempty"`
+	UpgradePlans []string   `json:"upgrade_plans,omitempty"` // Planes que no tienen esta restricción
+}
+
+func (v *RuleViolation) Error() string {
+	return v.Message
+}
+
+// Rule es una regla de escucha
+type Rule interface {
+	Name() string
+	Applies(action string) bool
+	// Evaluate decide si se permite la acción; window es el historial de
+	// la regla para el usuario
+	Evaluate(ctx PlaybackContext, window *SlidingWindow) *RuleViolation
+	// Record anota la acción una vez que todas las reglas la permitieron
+	Record(ctx PlaybackContext, window *SlidingWindow)
+}
+
+// SlidingWindow guarda los momentos de las acciones de los últimos Size
+type SlidingWindow struct {
+	Size   time.Duration
+	events []time.Time
+}
+
+// Count devuelve cuántas acciones hubo en la ventana que termina en now
+func (w *SlidingWindow) Count(now time.Time) int {
+	w.prune(now)
+	return len(w.events)
+}
+
+// Add anota una acción
+func (w *SlidingWindow) Add(at time.Time) {
+	w.events = append(w.events, at)
+}
+
+// NextFree devuelve cuándo sale de la ventana la acción más antigua
+func (w *SlidingWindow) NextFree(now time.Time) time.Time {
+	w.prune(now)
+	if len(w.events) == 0 {
+		return now
+	}
+	return w.events[0].Add(w.Size)
+}
+
+func (w *SlidingWindow) prune(now time.Time) {
+	cutoff := now.Add(-w.Size)
+	i := 0
+	for i < len(w.events) && !w.events[i].After(cutoff) {
+		i++
+	}
+	w.events = w.events[i:]
+}
+
+// SkipLimitRule limita los saltos por hora según el plan
+type SkipLimitRule struct{}
+
+func (SkipLimitRule) Name() string { return "skip_limit" }
+
+func (SkipLimitRule) Applies(action string) bool { return action == ActionSkip }
+
+func (r SkipLimitRule) Evaluate(ctx PlaybackContext, window *SlidingWindow) *RuleViolation {
+	limit := ctx.Entitlements.SkipsPerHour
+	if limit == Unlimited {
+		return nil
+	}
+	used := window.Count(ctx.Now)
+	if used < limit {
+		return nil
+	}
+	retryAt := window.NextFree(ctx.Now)
+	return &RuleViolation{
+		Rule:         r.Name(),
+		Message:      fmt.Sprintf("Alcanzaste el límite de %d saltos por hora", limit),
+		Limit:        limit,
+		Used:         used,
+		RetryAfter:   int(retryAt.Sub(ctx.Now).Seconds()) + 1,
+		RetryAt:      &retryAt,
+		UpgradePlans: PlansWith(FeatureUnlimitedSkips),
+	}
+}
+
+func (SkipLimitRule) Record(ctx PlaybackContext, window *SlidingWindow) {
+	window.Add(ctx.Now)
+}
+
+// RadioSeekRule impide adelantar o retroceder en la radio si el plan no lo incluye
+type RadioSeekRule struct{}
+
+func (RadioSeekRule) Name() string { return "radio_seek" }
+
+func (RadioSeekRule) Applies(action string) bool { return action == ActionSeek }
+
+func (r RadioSeekRule) Evaluate(ctx PlaybackContext, _ *SlidingWindow) *RuleViolation {
+	if ctx.Source != SourceRadio || ctx.Entitlements.RadioSeek {
+		return nil
+	}
+	return &RuleViolation{
+		Rule:         r.Name(),
+		Message:      "Tu plan no permite adelantar canciones en la radio",
+		UpgradePlans: PlansWith(FeatureRadioSeek),
+	}
+}
+
+func (RadioSeekRule) Record(PlaybackContext, *SlidingWindow) {}
+
+// ConcurrentStreamsRule limita cuántos dispositivos reproducen a la vez
+type ConcurrentStreamsRule struct{}
+
+func (ConcurrentStreamsRule) Name() string { return "concurrent_streams" }
+
+func (ConcurrentStreamsRule) Applies(action string) bool {
+	return action == ActionStart || action == ActionSkip
+}
+
+func (r ConcurrentStreamsRule) Evaluate(ctx PlaybackContext, _ *SlidingWindow) *RuleViolation {
+	limit := ctx.Entitlements.MaxStreams
+	if limit == Unlimited || ctx.ActiveStreams < limit {
+		return nil
+	}
+	return &RuleViolation{
+		Rule:    r.Name(),
+		Message: fmt.Sprintf("Tu cuenta ya está reproduciendo en %d dispositivo(s), el máximo de tu plan", ctx.ActiveStreams),
+		Limit:   limit,
+		Used:    ctx.ActiveStreams,
+	}
+}
+
+func (ConcurrentStreamsRule) Record(PlaybackContext, *SlidingWindow) {}
+
+// DefaultRules son las reglas que se aplican al reproducir
+func DefaultRules() []Rule {
+	return []Rule{SkipLimitRule{}, RadioSeekRule{}, ConcurrentStreamsRule{}}
+}
+
+// RulesEngine evalúa las reglas y guarda el historial de cada usuario en
+// ventanas deslizantes en memoria
+type RulesEngine struct {
+	mu      sync.Mutex
+	rules   []Rule
+	windows map[int]map[string]*SlidingWindow // Usuario -> regla -> ventana
+}
+
+// NewRulesEngine crea el motor con las reglas indicadas
+func NewRulesEngine(rules ...Rule) *RulesEngine {
+	return &RulesEngine{rules: rules, windows: make(map[int]map[string]*SlidingWindow)}
+}
+
+// Check evalúa la acción y, si todas las reglas la permiten, la anota.
+// Devuelve la primera regla que no la permite.
+func (e *RulesEngine) Check(ctx PlaybackContext) *RuleViolation {
+	return e.evaluate(ctx, true)
+}
+
+// Preview evalúa la acción sin anotarla
+func (e *RulesEngine) Preview(ctx PlaybackContext) *RuleViolation {
+	return e.evaluate(ctx, false)
+}
+
+func (e *RulesEngine) evaluate(ctx PlaybackContext, record bool) *RuleViolation {
+	if ctx.Now.IsZero() {
+		ctx.Now = time.Now()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var applied []Rule
+	for _, rule := range e.rules {
+		if !rule.Applies(ctx.Action) {
+			continue
+		}
+		if v := rule.Evaluate(ctx, e.window(ctx.UserID, rule.Name())); v != nil {
+			e.cleanup(ctx.UserID, ctx.Now)
+			return v
+		}
+		applied = append(applied, rule)
+	}
+	if record {
+		for _, rule := range applied {
+			rule.Record(ctx, e.window(ctx.UserID, rule.Name()))
+		}
+	}
+	e.cleanup(ctx.UserID, ctx.Now)
+	return nil
+}
+
+func (e *RulesEngine) window(userID int, rule string) *SlidingWindow {
+	user, ok := e.windows[userID]
+	if !ok {
+		user = make(map[string]*SlidingWindow)
+		e.windows[userID] = user
+	}
+	w, ok := user[rule]
+	if !ok {
+		w = &SlidingWindow{Size: SkipWindow}
+		user[rule] = w
+	}
+	return w
+}
+
+// cleanup quita las ventanas vacías para no acumular usuarios inactivos
+func (e *RulesEngine) cleanup(userID int, now time.Time) {
+	for name, w := range e.windows[userID] {
+		if w.Count(now) == 0 {
+			delete(e.windows[userID], name)
+		}
+	}
+	if len(e.windows[userID]) == 0 {
+		delete(e.windows, userID)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Funciones de normalizacion y tokenizacion de texto para la
+busqueda de canciones (minusculas, sin acentos, por palabras)
+*/
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Peso de cada campo al ordenar los resultados por relevancia
+const (
+	SearchWeightTitle  = 10
+	SearchWeightArtist = 6
+	SearchWeightGenre  = 3
+	SearchWeightLyrics = 1
+)
+
+// MaxSearchTermLength es el largo de la columna term; las palabras más
+// largas (frecuentes en letras, como "aaaaah") no se indexan
+const MaxSearchTermLength = 100
+
+const (
+	DefaultSearchPageSize = 20
+	MaxSearchPageSize     = 100
+)
+
+// accentFolding reemplaza letras acentuadas por su versión sin acento
+var accentFolding = map[rune]rune{
+	'á': 'a', 'à': 'a', 'ä': 'a', 'â': 'a', 'ã': 'a', 'å': 'a',
+	'é': 'e', 'è': 'e', 'ë': 'e', 'ê': 'e',
+	'í': 'i', 'ì': 'i', 'ï': 'i', 'î': 'i',
+	'ó': 'o', 'ò': 'o', 'ö': 'o', 'ô': 'o', 'õ': 'o',
+	'ú': 'u', 'ù': 'u', 'ü': 'u', 'û': 'u',
+	'ñ': 'n', 'ç': 'c', 'ý': 'y', 'ÿ': 'y',
+}
+
+// Palabras demasiado comunes que no aportan a la búsqueda
+var stopWords = map[string]bool{
+	"el": true, "la": true, "los": true, "las": true, "de": true, "del": true,
+	"y": true, "en": true, "un": true, "una": true,
+	"the": true, "of": true, "and": true, "a": true,
+}
+
+// NormalizeText pasa el texto a minúsculas y quita los acentos,
+// de modo que "Canción" y "cancion" se comparan igual
+func NormalizeText(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range strings.ToLower(text) {
+		if folded, ok := accentFolding[r]; ok {
+			r = folded
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Tokenize normaliza el texto y lo separa en palabras, descartando signos de
+// puntuación, palabras vacías y repetidas
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(NormalizeText(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if stopWords[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// SearchTerm es una palabra indexada de una canción con el peso de su campo
+type SearchTerm struct {
+	Term   string
+	Field  string
+	Weight int
+}
+
+// SearchTerms genera los términos indexables de una canción. Si una palabra
+// aparece en varios campos se conserva el de mayor peso.
+func SearchTerms(title, artist, genre, lyrics string) []SearchTerm {
+	fields := []struct {
+		name   string
+		text   string
+		weight int
+	}{
+		{"title", title, SearchWeightTitle},
+		{"artist", artist, SearchWeightArtist},
+		{"genre", genre, SearchWeightGenre},
+		{"lyrics", lyrics, SearchWeightLyrics},
+	}
+
+	best := make(map[string]SearchTerm)
+	var order []string
+	for _, f := range fields {
+		for _, token := range Tokenize(f.text) {
+			if len(token) > MaxSearchTermLength {
+				continue
+			}
+			current, ok := best[token]
+			if !ok {
+				order = append(order, token)
+			}
+			if !ok || f.weight > current.Weight {
+				best[token] = SearchTerm{Term: token, Field: f.name, Weight: f.weight}
+			}
+		}
+	}
+
+	terms := make([]SearchTerm, 0, len(order))
+	for _, token := range order {
+		terms = append(terms, best[token])
+	}
+	return terms
+}
+
+// NormalizePage corrige los parámetros de paginación recibidos
+func NormalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultSearchPageSize
+	}
+	if pageSize > MaxSearchPageSize {
+		pageSize = MaxSearchPageSize
+	}
+	return page, pageSize
+}
@@ -14,6 +14,13 @@ import (
 	"time"
 )
 
+// Largo máximo de los datos de la canción (columnas de la tabla songs)
+const (
+	MaxSongTitle  = 255
+	MaxSongArtist = 255
+	MaxSongGenre  = 100
+)
+
 // Estructura de la canción
 type Song struct {
 	ID         int       `json:"id"`
@@ -24,7 +31,7 @@ type Song struct {
 	AddedAt    time.Time `json:"added_at"`
 	PlayCount  int       `json:"play_count"`
 	LastPlayed time.Time `json:"last_played"`
-	IsFavorite bool      `json:"is_favorite"` // Nuevo campo
+	IsFavorite bool      `json:"is_favorite"` // Calculado para el usuario que consulta
 }
 
 // Constructor para Song
@@ -50,13 +57,15 @@ func NewSong(id int, title, artist, genre string, fileSize int) (*Song, error) {
 	return song, nil
 }
 
-// ValidateSize Se asegura que la canción no sea más grande de 10MB antes de agregarla
+// ValidateSize Se asegura que la canción no supere el tamaño permitido
+// por el plan por defecto antes de agregarla
 func (s *Song) ValidateSize() error {
-	const MaxSize = 10 * 1024 * 1024 // 10MB en bytes
-	if s.FileSize > MaxSize {
-		return fmt.Errorf("el tamaño del archivo excede el límite de 10MB")
-	}
-	return nil
+	return s.ValidateSizeFor(DefaultPlan)
+}
+
+// ValidateSizeFor Se asegura que la canción no supere el tamaño permitido por el plan
+func (s *Song) ValidateSizeFor(plan Plan) error {
+	return NewQuota(plan, QuotaUsage{}).CheckSongSize(int64(s.FileSize))
 }
 
 // Getters
@@ -76,16 +85,7 @@ func (s *Song) GetGenre() string {
 
 // GetFormattedFileSize Muestra el tamaño del archivo
 func (s *Song) GetFormattedFileSize() string {
-	const unit = 1024
-	if s.FileSize < unit {
-		return fmt.Sprintf("%d B", s.FileSize)
-	}
-	div, exp := int64(unit), 0
-	for n := s.FileSize / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %cB", float64(s.FileSize)/float64(div), "KMGTPE"[exp])
+	return FormatBytes(int64(s.FileSize))
 }
 
 // GetInfo Junta toda la información importante de la canción en un solo texto
@@ -104,8 +104,16 @@ func (s *Song) IncrementPlayCount() {
 	s.LastPlayed = time.Now()
 }
 
-// UpdateMetadata Permite cambiar la información básica de la canción
+// UpdateMetadata Permite cambiar la información básica de la canción.
+// Los campos vacíos se dejan como están.
 func (s *Song) UpdateMetadata(title, artist, genre string) error {
+	title, artist, genre = strings.TrimSpace(title), strings.TrimSpace(artist), strings.TrimSpace(genre)
+	if len([]rune(title)) > MaxSongTitle || len([]rune(artist)) > MaxSongArtist {
+		return fmt.Errorf("el título y el artista no pueden superar %d caracteres", MaxSongTitle)
+	}
+	if len([]rune(genre)) > MaxSongGenre {
+		return fmt.Errorf("el género no puede superar %d caracteres", MaxSongGenre)
+	}
 	if title != "" {
 		s.Title = title
 	}
@@ -125,8 +133,3 @@ func (s *Song) MatchesSearch(query string) bool {
 		strings.Contains(strings.ToLower(s.Artist), query) ||
 		strings.Contains(strings.ToLower(s.Genre), query)
 }
-
-// ToggleFavorite Cambia el estado de favorito de la canción
-func (s *Song) ToggleFavorite() {
-	s.IsFavorite = !s.IsFavorite
-}
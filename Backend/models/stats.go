@@ -0,0 +1,199 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase ListeningStats, estadisticas de escucha
+por usuario y resumen anual ("tu año en musica")
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type StatsPeriod string
+
+const (
+	StatsWeek  StatsPeriod = "week"
+	StatsMonth StatsPeriod = "month"
+	StatsYear  StatsPeriod = "year"
+)
+
+const StatsTopLimit = 10 // Elementos en cada ranking
+
+// ParseStatsPeriod valida el periodo recibido; por defecto el mes
+func ParseStatsPeriod(period string) (StatsPeriod, error) {
+	switch StatsPeriod(period) {
+	case "":
+		return StatsMonth, nil
+	case StatsWeek, StatsMonth, StatsYear:
+		return StatsPeriod(period), nil
+	}
+	return "", fmt.Errorf("periodo inválido: %s", period)
+}
+
+// Range devuelve el inicio (incluido) y el fin (excluido) del periodo que
+// contiene la fecha indicada. Las semanas empiezan el lunes.
+func (p StatsPeriod) Range(ref time.Time) (time.Time, time.Time) {
+	day := time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, ref.Location())
+	switch p {
+	case StatsWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		from := day.AddDate(0, 0, -offset)
+		return from, from.AddDate(0, 0, 7)
+	case StatsYear:
+		from := time.Date(day.Year(), 1, 1, 0, 0, 0, 0, day.Location())
+		return from, from.AddDate(1, 0, 0)
+	default:
+		from := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return from, from.AddDate(0, 1, 0)
+	}
+}
+
+// RankedItem es una canción, artista o género con su tiempo de escucha
+type RankedItem struct {
+	SongID  int    `json:"song_id,omitempty"`
+	Name    string `json:"name"`
+	Artist  string `json:"artist,omitempty"`
+	Plays   int    `json:"plays"`
+	Minutes int    `json:"minutes"`
+}
+
+// Streaks son los días seguidos con al menos una reproducción
+type Streaks struct {
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+}
+
+// ListeningStats es el resumen de escucha de un usuario en un periodo
+type ListeningStats struct {
+	Period       StatsPeriod  `json:"period"`
+	From         time.Time    `json:"from"`
+	To           time.Time    `json:"to"`
+	TotalMinutes int          `json:"total_minutes"`
+	TotalPlays   int          `json:"total_plays"`
+	DaysListened int          `json:"days_listened"`
+	TopSongs     []RankedItem `json:"top_songs"`
+	TopArtists   []RankedItem `json:"top_artists"`
+	TopGenres    []RankedItem `json:"top_genres"`
+	HourMinutes  [24]int      `json:"hour_minutes"` // Minutos escuchados por hora del día
+	Streaks      Streaks      `json:"streaks"`
+}
+
+// YearInReview es el resumen anual con el detalle de cada mes
+type YearInReview struct {
+	Year            int            `json:"year"`
+	UserName        string         `json:"user_name"`
+	Stats           ListeningStats `json:"stats"`
+	MonthlyMinutes  [12]int        `json:"monthly_minutes"`
+	TopMonth        string         `json:"top_month"`
+	FavoriteHour    int            `json:"favorite_hour"`
+	NewArtistsFound int            `json:"new_artists_found"`
+}
+
+var monthNames = [12]string{
+	"enero", "febrero", "marzo", "abril", "mayo", "junio",
+	"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre",
+}
+
+// ComputeStreaks calcula la racha actual y la más larga a partir de los
+// días con escucha en orden ascendente. La racha actual sigue vigente si
+// el último día es hoy o ayer.
+func ComputeStreaks(days []time.Time, today time.Time) Streaks {
+	var s Streaks
+	run := 0
+	var prev time.Time
+	for i, d := range days {
+		d = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, today.Location())
+		if i > 0 && d.Equal(prev.AddDate(0, 0, 1)) {
+			run++
+		} else if i == 0 || !d.Equal(prev) {
+			run = 1
+		}
+		if run > s.Longest {
+			s.Longest = run
+		}
+		prev = d
+	}
+
+	if len(days) > 0 {
+		todayStart := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+		if prev.Equal(todayStart) || prev.Equal(todayStart.AddDate(0, 0, -1)) {
+			s.Current = run
+		}
+	}
+	return s
+}
+
+// Finalize completa los datos derivados del resumen anual
+func (y *YearInReview) Finalize() {
+	best := -1
+	for i, minutes := range y.MonthlyMinutes {
+		if minutes > 0 && (best == -1 || minutes > y.MonthlyMinutes[best]) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		y.TopMonth = monthNames[best]
+	}
+
+	y.FavoriteHour = 0
+	for hour, minutes := range y.Stats.HourMinutes {
+		if minutes > y.Stats.HourMinutes[y.FavoriteHour] {
+			y.FavoriteHour = hour
+		}
+	}
+}
+
+// Text genera el resumen anual en texto plano para descargarlo
+func (y *YearInReview) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "TU AÑO EN MÚSICA %d\n", y.Year)
+	if y.UserName != "" {
+		fmt.Fprintf(&b, "Usuario: %s\n", y.UserName)
+	}
+	b.WriteString(strings.Repeat("=", 40) + "\n\n")
+
+	fmt.Fprintf(&b, "Minutos escuchados: %d\n", y.Stats.TotalMinutes)
+	fmt.Fprintf(&b, "Reproducciones: %d\n", y.Stats.TotalPlays)
+	fmt.Fprintf(&b, "Días con música: %d\n", y.Stats.DaysListened)
+	fmt.Fprintf(&b, "Racha más larga: %d días\n", y.Stats.Streaks.Longest)
+	fmt.Fprintf(&b, "Artistas descubiertos: %d\n", y.NewArtistsFound)
+	if y.TopMonth != "" {
+		fmt.Fprintf(&b, "Mes con más música: %s\n", y.TopMonth)
+	}
+	if y.Stats.TotalMinutes > 0 {
+		fmt.Fprintf(&b, "Hora favorita: %02d:00\n", y.FavoriteHour)
+	}
+
+	sections := []struct {
+		title string
+		items []RankedItem
+	}{
+		{"Canciones más escuchadas", y.Stats.TopSongs},
+		{"Artistas más escuchados", y.Stats.TopArtists},
+		{"Géneros más escuchados", y.Stats.TopGenres},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(&b, "\n%s\n", section.title)
+		if len(section.items) == 0 {
+			b.WriteString("  (sin datos)\n")
+		}
+		for i, item := range section.items {
+			name := item.Name
+			if item.Artist != "" {
+				name += " - " + item.Artist
+			}
+			fmt.Fprintf(&b, "  %2d. %s (%d reproducciones, %d min)\n", i+1, name, item.Plays, item.Minutes)
+		}
+	}
+
+	b.WriteString("\nMinutos por mes\n")
+	for i, minutes := range y.MonthlyMinutes {
+		fmt.Fprintf(&b, "  %-11s %d\n", monthNames[i], minutes)
+	}
+	return b.String()
+}
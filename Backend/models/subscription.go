@@ -0,0 +1,185 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase Subscription, suscripciones pagas de
+los usuarios y las funciones que habilita cada plan (entitlements)
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calidades de audio, de menor a mayor
+const (
+	QualityLow    = "low"    // 96 kbps
+	QualityNormal = "normal" // 160 kbps
+	QualityHigh   = "high"   // 320 kbps
+)
+
+var qualityRank = map[string]int{QualityLow: 1, QualityNormal: 2, QualityHigh: 3}
+
+// Funciones que se pueden restringir por plan
+const (
+	FeatureOfflineDownloads = "offline_downloads"
+	FeatureAdFree           = "ad_free"
+	FeatureHighQuality      = "high_quality"
+	FeatureUnlimitedSkips   = "unlimited_skips"
+	FeatureRadioSeek        = "radio_seek"
+)
+
+// Entitlements son las funciones que habilita un plan
+type Entitlements struct {
+	MaxAudioQuality  string `json:"max_audio_quality"`
+	OfflineDownloads bool   `json:"offline_downloads"`
+	SkipsPerHour     int    `json:"skips_per_hour"` // Unlimited = sin límite
+	AdFree           bool   `json:"ad_free"`
+	RadioSeek        bool   `json:"radio_seek"`  // Adelantar o retroceder en la radio
+	MaxStreams       int    `json:"max_streams"` // Reproducciones simultáneas por cuenta
+}
+
+// AllowsQuality indica si el plan permite escuchar en la calidad indicada
+func (e Entitlements) AllowsQuality(quality string) bool {
+	rank, ok := qualityRank[quality]
+	return ok && rank <= qualityRank[e.MaxAudioQuality]
+}
+
+// Allows indica si el plan habilita la función
+func (e Entitlements) Allows(feature string) bool {
+	switch feature {
+	case FeatureOfflineDownloads:
+		return e.OfflineDownloads
+	case FeatureAdFree:
+		return e.AdFree
+	case FeatureHighQuality:
+		return e.AllowsQuality(QualityHigh)
+	case FeatureUnlimitedSkips:
+		return e.SkipsPerHour == Unlimited
+	case FeatureRadioSeek:
+		return e.RadioSeek
+	}
+	return false
+}
+
+// PlansWith devuelve los planes que se pueden contratar y habilitan la función
+func PlansWith(feature string) []string {
+	var names []string
+	for _, name := range []string{PlanFree, PlanPremium} {
+		if plan := Plans[name]; plan.PriceCents > 0 && plan.Entitlements.Allows(feature) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionActive   SubscriptionStatus = "active"
+	SubscriptionCanceled SubscriptionStatus = "canceled" // No se renueva, pero sigue vigente hasta el fin del período
+	SubscriptionPastDue  SubscriptionStatus = "past_due" // Falló el cobro de la renovación
+	SubscriptionExpired  SubscriptionStatus = "expired"
+)
+
+// SubscriptionPeriod es la duración de cada período pagado
+const SubscriptionPeriod = 30 * 24 * time.Hour
+
+// UserSubscription es la suscripción de un usuario a un plan pago
+type UserSubscription struct {
+	ID               int                `json:"id"`
+	UserID           int                `json:"user_id"`
+	Plan             string             `json:"plan"`
+	Status           SubscriptionStatus `json:"status"`
+	Provider         string             `json:"provider"`
+	ProviderRef      string             `json:"provider_ref"`
+	AutoRenew        bool               `json:"auto_renew"`
+	StartedAt        time.Time          `json:"started_at"`
+	CurrentPeriodEnd time.Time          `json:"current_period_end"`
+	CanceledAt       *time.Time         `json:"canceled_at,omitempty"`
+	EndedAt          *time.Time         `json:"ended_at,omitempty"`
+}
+
+// Grants indica si la suscripción da acceso a su plan en el momento indicado
+func (s UserSubscription) Grants(now time.Time) bool {
+	return (s.Status == SubscriptionActive || s.Status == SubscriptionCanceled) && now.Before(s.CurrentPeriodEnd)
+}
+
+// RenewsAt devuelve cuándo se renueva, o nil si no se va a renovar
+func (s UserSubscription) RenewsAt() *time.Time {
+	if !s.AutoRenew || s.Status != SubscriptionActive {
+		return nil
+	}
+	end := s.CurrentPeriodEnd
+	return &end
+}
+
+// Tipos de evento que envía el proveedor de pagos
+const (
+	WebhookRenewed       = "subscription.renewed"
+	WebhookCanceled      = "subscription.canceled"
+	WebhookPaymentFailed = "payment.failed"
+	WebhookExpired       = "subscription.expired"
+)
+
+// WebhookEvent es un aviso del proveedor de pagos sobre una suscripción
+type WebhookEvent struct {
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	SubscriptionRef string    `json:"subscription_ref"`
+	PeriodEnd       time.Time `json:"period_end,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// WebhookTolerance es la antigüedad máxima de una firma de webhook
+const WebhookTolerance = 5 * time.Minute
+
+var ErrInvalidSignature = errors.New("firma de webhook inválida")
+
+// SignWebhook firma el cuerpo de un webhook. El resultado va en la cabecera
+// X-Billing-Signature con el formato "t=<unix>,v1=<hmac-sha256 hex>", donde
+// el HMAC se calcula sobre "<unix>.<cuerpo>".
+func SignWebhook(secret string, payload []byte, at time.Time) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + ts + ",v1=" + webhookMAC(secret, ts, payload)
+}
+
+// VerifyWebhookSignature valida la firma y que no sea más antigua que WebhookTolerance
+func VerifyWebhookSignature(secret string, payload []byte, header string, now time.Time) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(webhookMAC(secret, ts, payload))) {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > WebhookTolerance || age < -WebhookTolerance {
+		return fmt.Errorf("firma de webhook vencida")
+	}
+	return nil
+}
+
+func webhookMAC(secret, ts string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
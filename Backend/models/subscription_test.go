@@ -0,0 +1,46 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	const secret = "clave-de-prueba"
+	payload := []byte(`{"type":"payment.succeeded","user_id":7}`)
+	signedAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	header := SignWebhook(secret, payload, signedAt)
+	last := "0"
+	if strings.HasSuffix(header, "0") {
+		last = "1"
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		payload []byte
+		header  string
+		now     time.Time
+		wantErr bool
+	}{
+		{"válida", secret, payload, header, signedAt, false},
+		{"dentro de la tolerancia", secret, payload, header, signedAt.Add(WebhookTolerance), false},
+		{"vencida", secret, payload, header, signedAt.Add(WebhookTolerance + time.Second), true},
+		{"del futuro", secret, payload, header, signedAt.Add(-WebhookTolerance - time.Second), true},
+		{"cuerpo alterado", secret, []byte(`{"type":"payment.succeeded","user_id":8}`), header, signedAt, true},
+		{"otra clave", "otra-clave", payload, header, signedAt, true},
+		{"marca de tiempo alterada", secret, payload, strings.Replace(header, "t=", "t=1", 1), signedAt, true},
+		{"firma alterada", secret, payload, header[:len(header)-1] + last, signedAt, true},
+		{"sin firma", secret, payload, "t=" + header[2:strings.Index(header, ",")], signedAt, true},
+		{"vacía", secret, payload, "", signedAt, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhookSignature(tt.secret, tt.payload, tt.header, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyWebhookSignature() = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Codificacion a MP3 de las versiones de menor calidad de cada
+cancion (renditions), con un codificador escrito en Go
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/braheezy/shine-mp3/pkg/mp3"
+)
+
+// QualityOriginal pide el archivo tal como se subió (requiere la calidad alta)
+const QualityOriginal = "original"
+
+// Qualities son las calidades que se generan, de menor a mayor
+var Qualities = []string{QualityLow, QualityNormal, QualityHigh}
+
+// QualityBitrates es la tasa en kbps de cada calidad
+var QualityBitrates = map[string]int{
+	QualityLow:    96,
+	QualityNormal: 160,
+	QualityHigh:   320,
+}
+
+// ErrUnsupportedBitrate indica una combinación de frecuencia de muestreo y
+// tasa que MP3 no admite (por ejemplo 320 kbps a 22050 Hz)
+var ErrUnsupportedBitrate = errors.New("tasa de bits no soportada para la frecuencia de muestreo")
+
+// Tasas válidas por índice de la cabecera MP3 (capa III)
+var (
+	mpeg1Bitrates = []int{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320}
+	mpeg2Bitrates = []int{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160}
+)
+
+// MP3Encoder recibe las muestras decodificadas (es un AudioSink) y escribe
+// el MP3 a la tasa pedida. Los errores de escritura se guardan y se
+// devuelven en Close.
+type MP3Encoder struct {
+	w        io.Writer
+	enc      *mp3.Encoder
+	channels int
+	frame    []int16 // Muestras intercaladas de un cuadro completo
+	n        int
+	err      error
+}
+
+// NewMP3Encoder prepara el codificador. Solo admite audio mono o estéreo a
+// las frecuencias de MP3 (32, 44.1 o 48 kHz y sus mitades y cuartos).
+func NewMP3Encoder(w io.Writer, channels, sampleRate, kbps int) (*MP3Encoder, error) {
+	if channels < 1 || channels > 2 {
+		return nil, ErrUnsupportedAudio
+	}
+	if _, err := mp3.CheckConfig(sampleRate, kbps); err != nil {
+		return nil, ErrUnsupportedBitrate
+	}
+
+	enc := mp3.NewEncoder(sampleRate, channels)
+
+	// NewEncoder siempre configura 128 kbps; se recalcula el tamaño de
+	// cuadro como lo hace él pero con la tasa pedida
+	table := mpeg2Bitrates
+	if enc.Mpeg.GranulesPerFrame == 2 {
+		table = mpeg1Bitrates
+	}
+	for i, rate := range table {
+		if rate == kbps {
+			enc.Mpeg.BitrateIndex = int64(i)
+		}
+	}
+	enc.Mpeg.Bitrate = int64(kbps)
+	slots := float64(enc.Mpeg.GranulesPerFrame*mp3.GRANULE_SIZE) / float64(sampleRate) * float64(kbps) * 1000 / float64(enc.Mpeg.BitsPerSlot)
+	enc.Mpeg.WholeSlotsPerFrame = int64(slots)
+	enc.Mpeg.FracSlotsPerFrame = slots - float64(enc.Mpeg.WholeSlotsPerFrame)
+	enc.Mpeg.SlotLag = -enc.Mpeg.FracSlotsPerFrame
+	if enc.Mpeg.FracSlotsPerFrame == 0 {
+		enc.Mpeg.Padding = 0
+	}
+
+	return &MP3Encoder{
+		w:        w,
+		enc:      enc,
+		channels: channels,
+		frame:    make([]int16, int(enc.Mpeg.GranulesPerFrame)*mp3.GRANULE_SIZE*channels),
+	}, nil
+}
+
+// Write recibe muestras intercaladas en [-1, 1] y codifica cada cuadro completo
+func (e *MP3Encoder) Write(samples []float64) {
+	if e.err != nil {
+		return
+	}
+	for _, v := range samples {
+		e.frame[e.n] = int16(math.Max(-32768, math.Min(32767, math.Round(v*32768))))
+		e.n++
+		if e.n == len(e.frame) {
+			if e.err = e.encodeFrame(); e.err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Close codifica el último cuadro (completado con silencio) y devuelve el
+// primer error que haya ocurrido
+func (e *MP3Encoder) Close() error {
+	if e.err == nil && e.n > 0 {
+		clear(e.frame[e.n:])
+		e.err = e.encodeFrame()
+	}
+	return e.err
+}
+
+func (e *MP3Encoder) encodeFrame() error {
+	data, written := e.enc.EncodeBufferInterleaved(e.frame)
+	e.n = 0
+	_, err := e.w.Write(data[:written])
+	return err
+}
@@ -0,0 +1,32 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase TrashedSong, canciones eliminadas que
+se pueden restaurar durante un tiempo antes de borrarse definitivamente
+(para la estructura de datos)
+*/
+package models
+
+import "time"
+
+// TrashRetention es el tiempo que una canción eliminada se puede restaurar
+const TrashRetention = 30 * 24 * time.Hour
+
+// TrashedSong es una canción en la papelera
+type TrashedSong struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Artist    string    `json:"artist"`
+	Genre     string    `json:"genre"`
+	FileSize  int       `json:"file_size"`
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy *int      `json:"deleted_by,omitempty"`
+	PurgeAt   time.Time `json:"purge_at"` // Desde cuándo se borra definitivamente
+}
+
+// PurgeTime devuelve desde cuándo se puede borrar definitivamente una
+// canción eliminada en deletedAt
+func PurgeTime(deletedAt time.Time) time.Time {
+	return deletedAt.Add(TrashRetention)
+}
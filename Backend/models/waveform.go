@@ -0,0 +1,208 @@
+/*
+Autores: Henry Aliaga / Ismael Espinoza
+Fecha: 19/10/2026
+Lenguaje: Golang
+Descripcion: Asignacion de la clase Waveform, forma de onda reducida
+(picos minimo/maximo en varias resoluciones) para dibujar la barra de
+progreso del reproductor, y su formato binario
+(para la estructura de datos)
+*/
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// WaveformPeaksPerSecond es la resolución base desde la que se reducen las demás
+const WaveformPeaksPerSecond = 100
+
+// WaveformResolutions son las cantidades de picos que se guardan por canción
+var WaveformResolutions = []int{256, 1024, 4096}
+
+// waveformMagic identifica el archivo de forma de onda (versión 1)
+var waveformMagic = [4]byte{'W', 'V', 'F', '1'}
+
+var ErrInvalidWaveform = errors.New("archivo de forma de onda inválido")
+
+// WaveformLevel son los picos de una resolución. Data intercala mínimo y
+// máximo de cada tramo escalados a [-127, 127]: [min0, max0, min1, max1, ...]
+type WaveformLevel struct {
+	Peaks int    `json:"peaks"`
+	Data  []int8 `json:"data"`
+}
+
+// Waveform es la forma de onda de una canción
+type Waveform struct {
+	SampleRate int             `json:"sample_rate"`
+	DurationMs int             `json:"duration_ms"`
+	Levels     []WaveformLevel `json:"levels"`
+}
+
+// Level devuelve la resolución con exactamente peaks picos, o la más
+// cercana por arriba (o la mayor si ninguna alcanza)
+func (w *Waveform) Level(peaks int) *WaveformLevel {
+	var best *WaveformLevel
+	for i := range w.Levels {
+		l := &w.Levels[i]
+		if l.Peaks >= peaks && (best == nil || l.Peaks < best.Peaks) {
+			best = l
+		}
+	}
+	if best == nil {
+		for i := range w.Levels {
+			if best == nil || w.Levels[i].Peaks > best.Peaks {
+				best = &w.Levels[i]
+			}
+		}
+	}
+	return best
+}
+
+// MarshalBinary codifica la forma de onda (little-endian):
+//
+//	"WVF1" | sample_rate u32 | duration_ms u32 | niveles u16 |
+//	por nivel: picos u32 | picos × (min i8, max i8)
+func (w *Waveform) MarshalBinary() ([]byte, error) {
+	var b bytes.Buffer
+	b.Write(waveformMagic[:])
+	binary.Write(&b, binary.LittleEndian, uint32(w.SampleRate))
+	binary.Write(&b, binary.LittleEndian, uint32(w.DurationMs))
+	binary.Write(&b, binary.LittleEndian, uint16(len(w.Levels)))
+	for _, l := range w.Levels {
+		if len(l.Data) != 2*l.Peaks {
+			return nil, ErrInvalidWaveform
+		}
+		binary.Write(&b, binary.LittleEndian, uint32(l.Peaks))
+		binary.Write(&b, binary.LittleEndian, l.Data)
+	}
+	return b.Bytes(), nil
+}
+
+func (w *Waveform) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	var magic [4]byte
+	var header struct {
+		SampleRate, DurationMs uint32
+		Levels                 uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &magic); err != nil || magic != waveformMagic {
+		return ErrInvalidWaveform
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return ErrInvalidWaveform
+	}
+
+	w.SampleRate = int(header.SampleRate)
+	w.DurationMs = int(header.DurationMs)
+	w.Levels = make([]WaveformLevel, header.Levels)
+	for i := range w.Levels {
+		var peaks uint32
+		if err := binary.Read(r, binary.LittleEndian, &peaks); err != nil {
+			return ErrInvalidWaveform
+		}
+		if int64(peaks)*2 > int64(r.Len()) {
+			return ErrInvalidWaveform
+		}
+		w.Levels[i] = WaveformLevel{Peaks: int(peaks), Data: make([]int8, 2*peaks)}
+		if err := binary.Read(r, binary.LittleEndian, w.Levels[i].Data); err != nil {
+			return ErrInvalidWaveform
+		}
+	}
+	return nil
+}
+
+// WaveformBuilder calcula la forma de onda mientras se decodifica la
+// canción. Los canales se mezclan a mono.
+type WaveformBuilder struct {
+	channels  int
+	rate      int
+	perPeak   int // Muestras por pico de la resolución base
+	count     int
+	min, max  float64
+	mins      []float32
+	maxs      []float32
+	frames    int64
+	hasSample bool
+}
+
+func NewWaveformBuilder(channels, sampleRate int) *WaveformBuilder {
+	perPeak := sampleRate / WaveformPeaksPerSecond
+	if perPeak < 1 {
+		perPeak = 1
+	}
+	return &WaveformBuilder{channels: channels, rate: sampleRate, perPeak: perPeak}
+}
+
+// Write recibe muestras intercaladas en [-1, 1]
+func (b *WaveformBuilder) Write(samples []float64) {
+	for i := 0; i+b.channels <= len(samples); i += b.channels {
+		var v float64
+		for c := 0; c < b.channels; c++ {
+			v += samples[i+c]
+		}
+		v /= float64(b.channels)
+
+		if !b.hasSample || v < b.min {
+			b.min = v
+		}
+		if !b.hasSample || v > b.max {
+			b.max = v
+		}
+		b.hasSample = true
+		b.frames++
+		b.count++
+		if b.count == b.perPeak {
+			b.flush()
+		}
+	}
+}
+
+func (b *WaveformBuilder) flush() {
+	if !b.hasSample {
+		return
+	}
+	b.mins = append(b.mins, float32(b.min))
+	b.maxs = append(b.maxs, float32(b.max))
+	b.count = 0
+	b.hasSample = false
+}
+
+// Waveform reduce la resolución base a cada una de WaveformResolutions.
+// Una canción con menos picos base que la resolución pedida se guarda con
+// los que tiene.
+func (b *WaveformBuilder) Waveform() *Waveform {
+	b.flush()
+	w := &Waveform{
+		SampleRate: b.rate,
+		DurationMs: int(b.frames * 1000 / int64(b.rate)),
+	}
+	base := len(b.mins)
+	for _, peaks := range WaveformResolutions {
+		if peaks > base {
+			peaks = base
+		}
+		if n := len(w.Levels); n > 0 && w.Levels[n-1].Peaks == peaks {
+			continue
+		}
+		level := WaveformLevel{Peaks: peaks, Data: make([]int8, 2*peaks)}
+		for i := 0; i < peaks; i++ {
+			from, to := i*base/peaks, (i+1)*base/peaks
+			lo, hi := b.mins[from], b.maxs[from]
+			for j := from + 1; j < to; j++ {
+				lo = min(lo, b.mins[j])
+				hi = max(hi, b.maxs[j])
+			}
+			level.Data[2*i] = quantizePeak(lo)
+			level.Data[2*i+1] = quantizePeak(hi)
+		}
+		w.Levels = append(w.Levels, level)
+	}
+	return w
+}
+
+func quantizePeak(v float32) int8 {
+	return int8(math.Max(-127, math.Min(127, math.Round(float64(v)*127))))
+}
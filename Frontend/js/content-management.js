@@ -26,6 +26,19 @@ document.addEventListener('DOMContentLoaded', () => {
         formData.append('title', document.getElementById('title').value);
         formData.append('artist', document.getElementById('artist').value);
         formData.append('genre', document.getElementById('genre').value);
+        formData.append('album', document.getElementById('album').value);
+
+        const coverFile = document.getElementById('coverFile').files[0];
+        if (coverFile) {
+            formData.append('coverFile', coverFile);
+        }
+
+        const lyricsFile = document.getElementById('lyricsFile').files[0];
+        if (lyricsFile) {
+            formData.append('lyricsFile', lyricsFile);
+        } else {
+            formData.append('lyrics', document.getElementById('lyrics').value);
+        }
         
         try {
             const token = localStorage.getItem('userToken');
@@ -14,12 +14,19 @@ document.addEventListener('DOMContentLoaded', function() {
                 headers: {
                     'Content-Type': 'application/json',
                 },
-                body: JSON.stringify({ email, password })
+                body: JSON.stringify({
+                    email,
+                    password,
+                    device_id: getDeviceId(),
+                    device_name: deviceName(),
+                    device_type: 'web'
+                })
             });
 
             if (response.ok) {
                 const data = await response.json();
                 localStorage.setItem('userToken', data.token);
+                localStorage.setItem('deviceId', data.device_id);
                 
                 // Redirigir según el rol del usuario
                 if (data.role === 'admin') {
@@ -38,4 +45,20 @@ document.addEventListener('DOMContentLoaded', function() {
             errorMessage.textContent = 'Error al conectar con el servidor';
         }
     });
-});
\ No newline at end of file
+});
+// Mismo ID que usa el reproductor (ver MusicPlayer.getDeviceId)
+function getDeviceId() {
+    let deviceId = localStorage.getItem('deviceId');
+    if (!deviceId) {
+        deviceId = `web-${Date.now()}-${Math.random().toString(36).slice(2, 10)}`;
+        localStorage.setItem('deviceId', deviceId);
+    }
+    return deviceId;
+}
+
+function deviceName() {
+    const ua = navigator.userAgent;
+    const browser = ['Edg', 'Firefox', 'Chrome', 'Safari'].find(name => ua.includes(name)) || 'Navegador';
+    const os = ['Windows', 'Android', 'iPhone', 'Mac', 'Linux'].find(name => ua.includes(name)) || '';
+    return `${browser === 'Edg' ? 'Edge' : browser}${os ? ' en ' + os : ''}`;
+}
@@ -4,10 +4,18 @@ class MusicPlayer {
         this.audio = new Audio();
         this.songs = [];
         this.isPlaying = false;
+        this.playlist = null;
+        this.heartbeatTimer = null;
+        this.deviceId = this.getDeviceId();
+        this.eventSource = null;
+        this.radio = null;
         this.albumCoverElement = document.getElementById('albumCover');
+        this.quality = this.preferredQuality();
+        this.stalls = 0;
         this.initializeElements();
         this.loadSongs();
         this.setupEventListeners();
+        this.connectEvents();
     }
 
     initializeElements() {
@@ -17,9 +25,20 @@ class MusicPlayer {
         this.songListElement = document.getElementById('songList');
         this.currentSongElement = document.getElementById('currentSong');
         this.currentArtistElement = document.getElementById('currentArtist');
+        this.waveformCanvas = document.getElementById('waveform');
+        this.waveform = null;
+        this.lyricsElement = document.getElementById('lyrics');
+        this.lyrics = null;
+        this.lyricsIndex = -1;
     }
 
     async loadSongs() {
+        // Si la URL trae ?playlist=ID o ?shared=TOKEN se reproduce esa playlist
+        const params = new URLSearchParams(window.location.search);
+        if (params.get('playlist') || params.get('shared')) {
+            return this.loadPlaylist(params.get('playlist'), params.get('shared'));
+        }
+
         try {
             const response = await fetch('/api/songs/list', {
                 headers: {
@@ -31,6 +50,11 @@ class MusicPlayer {
                 this.songs = await response.json();
                 console.log('Canciones cargadas:', this.songs);
                 this.displaySongs(this.songs);
+                this.restoreLastPosition();
+            } else if (response.status === 401) {
+                // Token vencido, revocado o de una versión sin sesiones
+                localStorage.removeItem('userToken');
+                window.location.href = '/pages/login.html';
             } else {
                 console.error('Error al cargar canciones:', response.statusText);
             }
@@ -39,13 +63,268 @@ class MusicPlayer {
         }
     }
 
+    async loadPlaylist(playlistId, shareToken) {
+        const url = shareToken
+            ? `/api/playlists/shared/${shareToken}`
+            : `/api/playlists/${playlistId}`;
+
+        try {
+            const response = await fetch(url, {
+                headers: {
+                    'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+                }
+            });
+
+            if (response.ok) {
+                this.playlist = await response.json();
+                // Las entradas ya vienen ordenadas por posición
+                this.songs = this.playlist.entries || [];
+                console.log('Playlist cargada:', this.playlist.name, this.songs);
+                this.displaySongs(this.songs);
+            } else {
+                console.error('Error al cargar la playlist:', response.statusText);
+            }
+        } catch (error) {
+            console.error('Error cargando playlist:', error);
+        }
+    }
+
+    getDeviceId() {
+        let deviceId = localStorage.getItem('deviceId');
+        if (!deviceId) {
+            deviceId = `web-${Date.now()}-${Math.random().toString(36).slice(2, 10)}`;
+            localStorage.setItem('deviceId', deviceId);
+        }
+        return deviceId;
+    }
+
+    songId(song) {
+        // Las entradas de playlist traen song_id; la lista general trae id
+        return song.song_id || song.id;
+    }
+
+    // Continuar donde lo dejaste: deja preparada la última canción en su posición
+    async restoreLastPosition() {
+        try {
+            const response = await fetch('/api/playback/resume', {
+                headers: {
+                    'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+                }
+            });
+            if (!response.ok) return;
+
+            const data = await response.json();
+            const index = this.songs.findIndex(song => this.songId(song) === data.song_id);
+            if (index === -1) return;
+
+            this.playSong(index, data.resume_at_ms / 1000, false);
+        } catch (error) {
+            console.error('Error obteniendo la última posición:', error);
+        }
+    }
+
+    sendHeartbeat() {
+        if (this.currentSong === null || !this.songs[this.currentSong]) return;
+
+        const song = this.songs[this.currentSong];
+        fetch('/api/playback/heartbeat', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+            },
+            body: JSON.stringify({
+                song_id: this.songId(song),
+                position_ms: Math.floor(this.audio.currentTime * 1000),
+                duration_ms: Math.floor((this.audio.duration || 0) * 1000),
+                reported_at: Date.now(),
+                paused: this.audio.paused
+            })
+        })
+            .then(async response => {
+                if (response.ok) {
+                    this.lastPosition = this.audio.currentTime;
+                    return;
+                }
+                // El plan no permite adelantar en la radio: se vuelve a la
+                // última posición aceptada
+                const data = await response.json().catch(() => null);
+                if (data && data.rule === 'radio_seek') {
+                    this.audio.currentTime = this.lastPosition || 0;
+                    this.showRuleViolation(data);
+                }
+            })
+            .catch(error => console.error('Error enviando posición:', error));
+    }
+
+    // Historial de reproducción: alimenta las recomendaciones personalizadas
+    // También aplica las reglas del plan (saltos por hora, dispositivos a la
+    // vez); el servidor decide si cuenta como salto
+    startPlayback(song) {
+        this.playbackId = null;
+        fetch('/api/playback/start', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+            },
+            body: JSON.stringify({
+                song_id: this.songId(song),
+                source: song.radio_position !== undefined ? 'radio' : 'queue'
+            })
+        })
+            .then(async response => {
+                if (response.ok) return response.json();
+                const data = await response.json().catch(() => null);
+                if (data && data.rule === 'concurrent_streams') {
+                    if (await this.takeOverPlayback(data)) this.startPlayback(song);
+                } else if (data && data.rule) {
+                    this.showRuleViolation(data);
+                }
+                return null;
+            })
+            .then(data => { if (data) this.playbackId = data.playback_id; })
+            .catch(error => console.error('Error registrando reproducción:', error));
+    }
+
+    // "Reproducir aquí": pausa los otros dispositivos de la cuenta
+    async takeOverPlayback(violation) {
+        if (!confirm(`${violation.error}. ¿Reproducir aquí y pausar el otro dispositivo?`)) {
+            this.showRuleViolation(violation, false);
+            return false;
+        }
+        try {
+            const response = await fetch(`/api/me/devices/${encodeURIComponent(this.deviceId)}/play-here`, {
+                method: 'POST',
+                headers: {
+                    'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+                }
+            });
+            return response.ok;
+        } catch (error) {
+            console.error('Error cambiando de dispositivo:', error);
+            return false;
+        }
+    }
+
+    showRuleViolation(violation, notify = true) {
+        this.audio.pause();
+        this.isPlaying = false;
+        this.updatePlayButton();
+        if (!notify) return;
+
+        let message = violation.error;
+        if (violation.retry_at) {
+            message += `. Podrás volver a saltar a las ${new Date(violation.retry_at).toLocaleTimeString()}`;
+        }
+        if (violation.upgrade_plans && violation.upgrade_plans.length > 0) {
+            message += `. Disponible en: ${violation.upgrade_plans.join(', ')}`;
+        }
+        alert(message);
+    }
+
+    finishPlayback(completed) {
+        if (!this.playbackId) return;
+
+        const playbackId = this.playbackId;
+        this.playbackId = null;
+        fetch('/api/playback/finish', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+            },
+            body: JSON.stringify({
+                playback_id: playbackId,
+                duration: Math.floor(this.audio.currentTime),
+                completed
+            })
+        }).catch(error => console.error('Error cerrando reproducción:', error));
+    }
+
+    startHeartbeat() {
+        this.stopHeartbeat();
+        this.heartbeatTimer = setInterval(() => this.sendHeartbeat(), 10000);
+    }
+
+    stopHeartbeat() {
+        if (this.heartbeatTimer) {
+            clearInterval(this.heartbeatTimer);
+            this.heartbeatTimer = null;
+        }
+    }
+
+    // Canal en tiempo real: otros dispositivos del usuario ven los cambios al
+    // instante y pueden enviar órdenes (por ejemplo pausar desde el teléfono).
+    // EventSource reconecta solo y envía Last-Event-ID para recuperar lo perdido.
+    connectEvents() {
+        if (!window.EventSource) return;
+
+        const token = encodeURIComponent(localStorage.getItem('userToken') || '');
+        this.eventSource = new EventSource(`/api/events?token=${token}`);
+
+        const parse = (handler) => (e) => {
+            try {
+                handler(JSON.parse(e.data));
+            } catch (error) {
+                console.error('Evento inválido:', error);
+            }
+        };
+
+        this.eventSource.addEventListener('command.play', parse(() => {
+            if (this.audio.paused) this.togglePlay();
+        }));
+        this.eventSource.addEventListener('command.pause', parse(() => {
+            if (!this.audio.paused) this.togglePlay();
+        }));
+        this.eventSource.addEventListener('command.next', parse(() => this.playNext()));
+        this.eventSource.addEventListener('command.previous', parse(() => this.playPrevious()));
+        this.eventSource.addEventListener('playback.track', parse((event) => {
+            // Otro dispositivo cambió de canción: mostrarlo sin interrumpir este
+            if (this.audio.paused && event.data) {
+                if (this.currentSongElement) this.currentSongElement.textContent = `${event.data.title} (en otro dispositivo)`;
+                if (this.currentArtistElement) this.currentArtistElement.textContent = event.data.artist;
+            }
+        }));
+        this.eventSource.addEventListener('sync.reset', parse(() => this.restoreLastPosition()));
+        this.eventSource.addEventListener('device.revoked', parse(() => {
+            // Se cerró la sesión de este dispositivo desde otro
+            this.eventSource.close();
+            localStorage.removeItem('userToken');
+            window.location.href = "/";
+        }));
+        this.eventSource.onerror = () => console.warn('Canal de eventos desconectado, reintentando...');
+    }
+
+    publishEvent(type, data = {}, targetDevice = '') {
+        fetch('/api/events/publish', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+            },
+            body: JSON.stringify({
+                type,
+                target_device: targetDevice,
+                data
+            })
+        }).catch(error => console.error('Error publicando evento:', error));
+    }
+
+    // La portada se pide con su hash (?v=) para que el navegador la guarde
+    // en caché; sin portada el servidor entrega una generada
+    coverUrl(song, size) {
+        const version = song.cover_hash ? `&v=${song.cover_hash}` : '';
+        return `/api/songs/${this.songId(song)}/cover?size=${size}${version}`;
+    }
+
     displaySongs(songs) {
         const musicList = document.querySelector('.song-list-container');
         if (!musicList) return;
 
         const songItems = songs.map((song, index) => `
             <div class="song-item" data-index="${index}">
-                <img src="../images/music-cover.jpg" alt="${song.title}" class="custom-img-size">
+                <img src="${this.coverUrl(song, 64)}" alt="${song.title}" class="custom-img-size">
                 <div class="song-info">
                     <h3>${song.title}</h3>
                     <p>${song.artist}</p>
@@ -65,23 +344,37 @@ class MusicPlayer {
         });
     }
 
-    playSong(index) {
+    playSong(index, startAt = 0, autoplay = true) {
         if (index < 0 || index >= this.songs.length) return;
         
         const song = this.songs[index];
+        this.finishPlayback(false);
         this.currentSong = index;
         
-        // Construir la URL completa para el archivo de audio
-        const fileName = song.file_path.split('\\').pop(); // Manejar rutas de Windows
-        const audioUrl = `/uploads/songs/${fileName}`;
-        console.log('Intentando reproducir:', audioUrl);
-        
         // Actualizar interfaz
         if (this.currentSongElement) this.currentSongElement.textContent = song.title;
         if (this.currentArtistElement) this.currentArtistElement.textContent = song.artist;
-        if (this.albumCoverElement) this.albumCoverElement.src = '../images/music-cover.jpg';
+        if (this.albumCoverElement) this.albumCoverElement.src = this.coverUrl(song, 256);
         
-        this.audio.src = audioUrl;
+        this.stalls = 0;
+        this.lastPosition = startAt;
+        this.audio.src = this.streamUrl(song, this.quality);
+        this.applyLoudness(song);
+        this.loadWaveform(song);
+        this.loadLyrics(song);
+        if (startAt > 0) {
+            this.audio.addEventListener('loadedmetadata', () => {
+                this.audio.currentTime = startAt;
+            }, { once: true });
+        }
+        if (!autoplay) return;
+
+        this.startPlayback(song);
+        this.publishEvent('playback.track', {
+            song_id: this.songId(song),
+            title: song.title,
+            artist: song.artist
+        });
         this.audio.play()
             .catch(error => {
                 console.error('Error reproduciendo canción:', error);
@@ -91,6 +384,216 @@ class MusicPlayer {
         this.updatePlayButton();
     }
 
+    // El servidor elige la versión de la canción: sin calidad entrega la
+    // máxima del plan. <audio> no manda cabeceras, así que el token va en la URL.
+    streamUrl(song, quality) {
+        const token = encodeURIComponent(localStorage.getItem('userToken') || '');
+        const param = quality ? `&quality=${quality}` : '';
+        return `/api/songs/${this.songId(song)}/stream?token=${token}${param}`;
+    }
+
+    // Con ahorro de datos o una conexión lenta se pide la calidad baja
+    preferredQuality() {
+        const connection = navigator.connection;
+        if (connection && (connection.saveData || ['slow-2g', '2g', '3g'].includes(connection.effectiveType))) {
+            return 'low';
+        }
+        return '';
+    }
+
+    // Si la reproducción se corta varias veces por falta de datos se pasa a
+    // la calidad baja desde la misma posición
+    handleStall() {
+        if (this.currentSong === null || this.quality === 'low' || this.audio.paused) return;
+        this.stalls++;
+        if (this.stalls < 3) return;
+
+        console.log('Conexión lenta: cambiando a calidad baja');
+        this.quality = 'low';
+        const position = this.audio.currentTime;
+        this.audio.src = this.streamUrl(this.songs[this.currentSong], this.quality);
+        this.audio.addEventListener('loadedmetadata', () => {
+            this.audio.currentTime = position;
+        }, { once: true });
+        this.audio.play().catch(error => console.error('Error reproduciendo canción:', error));
+    }
+
+    // Normalización de volumen con la ganancia de pista medida en el servidor.
+    // audio.volume solo puede bajar el volumen, así que todas las pistas se
+    // atenúan con un margen fijo y las más bajas usan ese margen para subir.
+    applyLoudness(song) {
+        const headroomDb = 6;
+        const loudness = song.loudness;
+        if (!loudness || localStorage.getItem('normalizeVolume') === 'false') {
+            this.audio.volume = 1;
+            return;
+        }
+
+        let gain = Math.pow(10, (loudness.track_gain_db - headroomDb) / 20);
+        if (loudness.track_peak > 0) {
+            gain = Math.min(gain, 1 / loudness.track_peak); // Evitar recortes
+        }
+        this.audio.volume = Math.max(0, Math.min(1, gain));
+    }
+
+    // Forma de onda de la canción para dibujar la barra de progreso
+    async loadWaveform(song) {
+        this.waveform = null;
+        this.drawWaveform();
+        if (!this.waveformCanvas) return;
+
+        const songId = this.songId(song);
+        try {
+            const response = await fetch(`/api/songs/${songId}/waveform?format=json&peaks=${this.waveformCanvas.width}`, {
+                headers: {
+                    'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+                }
+            });
+            if (!response.ok) return; // Todavía no se generó: se dibuja una barra simple
+            const data = await response.json();
+            if (this.songId(this.songs[this.currentSong]) !== songId) return;
+            this.waveform = data.levels[0];
+            this.drawWaveform();
+        } catch (error) {
+            console.error('Error obteniendo la forma de onda:', error);
+        }
+    }
+
+    drawWaveform() {
+        const canvas = this.waveformCanvas;
+        if (!canvas) return;
+
+        const ctx = canvas.getContext('2d');
+        const { width, height } = canvas;
+        const progress = this.audio.duration ? this.audio.currentTime / this.audio.duration : 0;
+        ctx.clearRect(0, 0, width, height);
+
+        if (!this.waveform) {
+            ctx.fillStyle = 'rgba(255, 255, 255, 0.2)';
+            ctx.fillRect(0, height / 2 - 2, width, 4);
+            ctx.fillStyle = '#FFD700';
+            ctx.fillRect(0, height / 2 - 2, width * progress, 4);
+            return;
+        }
+
+        const { peaks, data } = this.waveform;
+        const barWidth = width / peaks;
+        for (let i = 0; i < peaks; i++) {
+            const min = data[2 * i] / 127;
+            const max = data[2 * i + 1] / 127;
+            const top = (1 - max) * height / 2;
+            const bottom = (1 - min) * height / 2;
+            ctx.fillStyle = i / peaks < progress ? '#FFD700' : 'rgba(255, 255, 255, 0.35)';
+            ctx.fillRect(i * barWidth, top, Math.max(barWidth, 1), Math.max(bottom - top, 1));
+        }
+    }
+
+    // Buscar haciendo clic en la forma de onda. En la radio se consulta antes
+    // si el plan permite adelantar.
+    async loadLyrics(song) {
+        this.lyrics = null;
+        this.lyricsIndex = -1;
+        if (!this.lyricsElement) return;
+        this.showLyricsMessage('Cargando letra...');
+
+        const songId = this.songId(song);
+        try {
+            const response = await fetch(`/api/songs/${songId}/lyrics`, {
+                headers: {
+                    'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+                }
+            });
+            if (this.songId(this.songs[this.currentSong]) !== songId) return;
+            if (!response.ok) {
+                this.showLyricsMessage('Esta canción no tiene letra');
+                return;
+            }
+            this.lyrics = await response.json();
+            this.renderLyrics();
+        } catch (error) {
+            console.error('Error obteniendo la letra:', error);
+            this.showLyricsMessage('No se pudo cargar la letra');
+        }
+    }
+
+    showLyricsMessage(message) {
+        this.lyricsElement.innerHTML = '';
+        const p = document.createElement('p');
+        p.className = 'lyrics-empty';
+        p.textContent = message;
+        this.lyricsElement.appendChild(p);
+    }
+
+    renderLyrics() {
+        this.lyricsElement.innerHTML = '';
+        this.lyrics.lines.forEach(line => {
+            const p = document.createElement('p');
+            p.className = 'lyrics-line';
+            p.textContent = line.text || '♪';
+            // En una letra sincronizada, al hacer clic se salta a esa línea
+            if (this.lyrics.synced) {
+                p.classList.add('synced');
+                p.addEventListener('click', () => {
+                    if (this.audio.duration) this.seekTo(line.time_ms / 1000 / this.audio.duration);
+                });
+            }
+            this.lyricsElement.appendChild(p);
+        });
+        this.lyricsElement.scrollTop = 0;
+    }
+
+    // Resalta la última línea cuyo tiempo ya pasó y la mantiene centrada
+    highlightLyrics() {
+        if (!this.lyrics || !this.lyrics.synced || !this.lyricsElement) return;
+
+        const now = this.audio.currentTime * 1000;
+        const lines = this.lyrics.lines;
+        let low = 0, high = lines.length - 1, index = -1;
+        while (low <= high) {
+            const mid = (low + high) >> 1;
+            if (lines[mid].time_ms <= now) {
+                index = mid;
+                low = mid + 1;
+            } else {
+                high = mid - 1;
+            }
+        }
+        if (index === this.lyricsIndex) return;
+
+        const elements = this.lyricsElement.children;
+        elements[this.lyricsIndex]?.classList.remove('active');
+        this.lyricsIndex = index;
+        const current = elements[index];
+        if (!current) return;
+        current.classList.add('active');
+        this.lyricsElement.scrollTop = current.offsetTop - this.lyricsElement.clientHeight / 2;
+    }
+
+    async seekTo(fraction) {
+        if (this.currentSong === null || !this.audio.duration) return;
+
+        const song = this.songs[this.currentSong];
+        if (song.radio_position !== undefined) {
+            const response = await fetch('/api/playback/check', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                    'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+                },
+                body: JSON.stringify({ action: 'seek', source: 'radio' })
+            });
+            if (!response.ok) {
+                const data = await response.json().catch(() => null);
+                if (data && data.rule) alert(data.error);
+                return;
+            }
+        }
+
+        this.audio.currentTime = fraction * this.audio.duration;
+        this.publishEvent('playback.seek', { position_ms: Math.floor(this.audio.currentTime * 1000) });
+        this.drawWaveform();
+    }
+
     togglePlay() {
         if (this.audio.paused) {
             this.audio.play()
@@ -111,15 +614,81 @@ class MusicPlayer {
         }
     }
 
-    playNext() {
-        if (this.currentSong !== null) {
-            const nextIndex = (this.currentSong + 1) % this.songs.length;
-            this.playSong(nextIndex);
+    async playNext(skipped = true) {
+        if (this.currentSong === null || this.songs.length === 0) return;
+
+        const song = this.songs[this.currentSong];
+        if (skipped && song.radio_position !== undefined) {
+            this.sendRadioFeedback(song, 'skip');
         }
+
+        // Al terminar la lista se continúa con una radio basada en la canción actual
+        if (this.currentSong === this.songs.length - 1 && await this.loadRadioTracks(song)) {
+            this.playSong(this.currentSong + 1);
+            return;
+        }
+
+        const nextIndex = (this.currentSong + 1) % this.songs.length;
+        this.playSong(nextIndex);
+    }
+
+    // Radio automática: agrega a la lista las siguientes canciones generadas
+    async loadRadioTracks(seedSong) {
+        const headers = {
+            'Content-Type': 'application/json',
+            'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+        };
+
+        try {
+            if (!this.radio) {
+                const response = await fetch('/api/radio', {
+                    method: 'POST',
+                    headers,
+                    body: JSON.stringify({ seed_type: 'song', seed_value: String(this.songId(seedSong)) })
+                });
+                if (!response.ok) return false;
+                this.radio = { id: (await response.json()).id, nextOffset: 0 };
+            }
+
+            const response = await fetch(`/api/radio/${this.radio.id}/tracks?offset=${this.radio.nextOffset}&limit=10`, { headers });
+            // La semilla se borró: la próxima vez se crea otra radio
+            if (response.status === 410) this.radio = null;
+            if (!response.ok) return false;
+
+            const page = await response.json();
+            if (page.tracks.length === 0) return false;
+
+            page.tracks.forEach((track, i) => {
+                this.songs.push({ ...track, radio_position: page.offset + i });
+            });
+            this.radio.nextOffset = page.next_offset;
+            this.displaySongs(this.songs);
+            return true;
+        } catch (error) {
+            console.error('Error cargando la radio:', error);
+            return false;
+        }
+    }
+
+    sendRadioFeedback(song, type) {
+        if (!this.radio) return;
+
+        fetch(`/api/radio/${this.radio.id}/feedback`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+                'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+            },
+            body: JSON.stringify({
+                song_id: this.songId(song),
+                type,
+                position: song.radio_position
+            })
+        }).catch(error => console.error('Error enviando opinión a la radio:', error));
     }
 
     playPrevious() {
-        if (this.currentSong !== null) {
+        if (this.currentSong !== null && this.songs.length > 0) {
             const prevIndex = (this.currentSong - 1 + this.songs.length) % this.songs.length;
             this.playSong(prevIndex);
         }
@@ -136,8 +705,35 @@ class MusicPlayer {
             this.nextBtn.addEventListener('click', () => this.playNext());
         }
 
+        this.waveformCanvas?.addEventListener('click', (e) => {
+            const rect = this.waveformCanvas.getBoundingClientRect();
+            this.seekTo((e.clientX - rect.left) / rect.width);
+        });
+        this.audio.addEventListener('timeupdate', () => {
+            this.drawWaveform();
+            this.highlightLyrics();
+        });
+        this.audio.addEventListener('waiting', () => this.handleStall());
+        navigator.connection?.addEventListener('change', () => {
+            this.quality = this.preferredQuality();
+        });
+
         // Evento para cuando termine la canción
-        this.audio.addEventListener('ended', () => this.playNext());
+        this.audio.addEventListener('ended', () => {
+            this.finishPlayback(true);
+            this.playNext(false);
+        });
+
+        // Reportar la posición mientras suena y al pausar
+        this.audio.addEventListener('play', () => {
+            this.startHeartbeat();
+            this.publishEvent('playback.play', { position_ms: Math.floor(this.audio.currentTime * 1000) });
+        });
+        this.audio.addEventListener('pause', () => {
+            this.stopHeartbeat();
+            this.sendHeartbeat();
+            this.publishEvent('playback.pause', { position_ms: Math.floor(this.audio.currentTime * 1000) });
+        });
     }
 }
 
@@ -151,7 +747,10 @@ document.querySelector(".logout-btn")?.addEventListener("click", () => {
     if (confirm("¿Estás seguro de que deseas cerrar sesión?")) {
         fetch("/api/logout", {
             method: "POST",
-            headers: { "Content-Type": "application/json" },
+            headers: {
+                "Content-Type": "application/json",
+                "Authorization": `Bearer ${localStorage.getItem('userToken')}`
+            },
         })
             .then((response) => {
                 if (response.ok) {
@@ -6,37 +6,53 @@ document.addEventListener("DOMContentLoaded", () => {
     const statusDiv = document.getElementById("status");
     const logoutButton = document.querySelector('.logout');
 
-    // Función para guardar el reporte
-    const guardarReporte = () => {
+    const cancionInput = document.getElementById("cancion");
+    const adjuntosInput = document.getElementById("adjuntos");
+
+    const mostrarEstado = (mensaje, error) => {
+        statusDiv.innerText = mensaje;
+        statusDiv.classList.toggle("text-danger", error);
+        statusDiv.classList.toggle("text-success", !error);
+    };
+
+    // Función para enviar el reporte al servidor
+    const guardarReporte = async () => {
         const titulo = tituloInput.value.trim();
         const descripcion = descripcionInput.value.trim();
 
         // Validar campos
         if (!titulo || !descripcion) {
-            statusDiv.innerText = "Por favor, completa todos los campos.";
-            statusDiv.classList.add("text-danger");
+            mostrarEstado("Por favor, completa todos los campos.", true);
             return;
         }
 
-        // Crear el contenido del reporte
-        const contenido = `Título: ${titulo}\nDescripción: ${descripcion}\n\n`;
-
-        // Crear un Blob con el contenido del reporte
-        const blob = new Blob([contenido], { type: "text/plain" });
-
-        // Crear un enlace temporal para descargar el archivo
-        const enlace = document.createElement("a");
-        enlace.href = URL.createObjectURL(blob);
-        enlace.download = "reporte.txt";
-
-        // Simular un clic para descargar el archivo
-        enlace.click();
+        // Se envía como formulario para poder incluir los adjuntos
+        const datos = new FormData();
+        datos.append("title", titulo);
+        datos.append("description", descripcion);
+        if (cancionInput?.value) {
+            datos.append("song_id", cancionInput.value);
+        }
+        for (const archivo of adjuntosInput?.files || []) {
+            datos.append("attachments", archivo);
+        }
 
-        // Actualizar el estado y limpiar el formulario
-        statusDiv.innerText = "Reporte guardado con éxito.";
-        statusDiv.classList.remove("text-danger");
-        statusDiv.classList.add("text-success");
-        formulario.reset();
+        try {
+            const response = await fetch("/api/reports", {
+                method: "POST",
+                headers: { "Authorization": `Bearer ${localStorage.getItem("userToken")}` },
+                body: datos,
+            });
+            if (!response.ok) {
+                throw new Error(await response.text());
+            }
+            const reporte = await response.json();
+            mostrarEstado(`Reporte #${reporte.id} enviado con éxito.`, false);
+            formulario.reset();
+        } catch (error) {
+            console.error("Error:", error);
+            mostrarEstado(`Error al enviar el reporte: ${error.message}`, true);
+        }
     };
 
     // Asignar la funcionalidad de guardar reporte al botón correspondiente
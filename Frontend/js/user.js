@@ -140,7 +140,7 @@ class MusicPlayer {
         this.currentCover.src = `../images/${song.genre.toLowerCase()}.jpg`;
 
         // Reproducir audio
-        this.audio.src = song.file_path;
+        this.audio.src = this.streamUrl(song);
         this.audio.play()
             .then(() => {
                 this.isPlaying = true;
@@ -149,9 +149,16 @@ class MusicPlayer {
             .catch(error => console.error('Error reproduciendo:', error));
     }
 
+    // El audio se pide al servidor, que entrega la calidad que permite el plan.
+    // <audio> no manda cabeceras, así que el token va en la URL.
+    streamUrl(song) {
+        const token = encodeURIComponent(localStorage.getItem('userToken') || '');
+        return `/api/songs/${song.id}/stream?token=${token}`;
+    }
+
     previewSong(index) {
         const song = this.songs[index];
-        this.audio.src = song.file_path;
+        this.audio.src = this.streamUrl(song);
         this.audio.volume = 0.3; // Volumen más bajo para preview
         this.audio.currentTime = 0;
         this.audio.play().catch(error => console.error('Error en preview:', error));
@@ -228,7 +235,10 @@ class MusicPlayer {
             try {
                 const response = await fetch('/api/logout', {
                     method: 'POST',
-                    headers: { 'Content-Type': 'application/json' }
+                    headers: {
+                        'Content-Type': 'application/json',
+                        'Authorization': `Bearer ${localStorage.getItem('userToken')}`
+                    }
                 });
 
                 if (response.ok) {
@@ -2,6 +2,15 @@ module PROYECTO_STREAMING
 
 go 1.23.2
 
-require github.com/go-sql-driver/mysql v1.8.1
+require (
+	github.com/braheezy/shine-mp3 v0.2.0
+	github.com/go-sql-driver/mysql v1.8.1
+	github.com/hajimehoshi/go-mp3 v0.3.4
+)
 
-require filippo.io/edwards25519 v1.1.0 // indirect
+require (
+	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/go-audio/audio v1.0.0 // indirect
+	github.com/go-audio/riff v1.0.0 // indirect
+	github.com/go-audio/wav v1.1.0 // indirect
+)